@@ -0,0 +1,179 @@
+// Command orderbench compares order placement latency over REST and the binary protocol.
+//
+// Usage:
+//
+//	go run cmd/orderbench/main.go -n 10000 -bootstrap-secret s3cret -rest http://localhost:50053 -binary localhost:9900
+//
+// REST requests and the binary login are signed with -key and -secret; without them a first key
+// is issued for -user with the server's -bootstrap-secret. Start the server with BINARY_PORT=9900
+// to enable the binary gateway. The key creates the benchmark pair, so it needs the admin
+// permission: run the server with KEY_BOOTSTRAP_SECRET set and ADMIN_USER_IDS including -user. Raise RATE_LIMIT_ORDER_BURST and RATE_LIMIT_ORDER_RATE above
+// -n so REST orders are not rate limited.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/pkg/binproto"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+func main() {
+	n := flag.Int("n", 10000, "orders per protocol")
+	restURL := flag.String("rest", "http://localhost:50053", "REST base URL")
+	binaryAddr := flag.String("binary", "localhost:9900", "binary gateway address")
+	userID := flag.Int64("user", 1, "user ID placing the orders")
+	apiKey := flag.String("key", "", "API key signing REST orders and the binary login")
+	apiSecret := flag.String("secret", "", "secret of -key")
+	bootstrapSecret := flag.String("bootstrap-secret", "", "server KEY_BOOTSTRAP_SECRET, issues a first key when -key is not set")
+	flag.Parse()
+
+	if *apiKey == "" {
+		key, err := issueKey(*restURL, *userID, *bootstrapSecret)
+		if err != nil {
+			log.Fatalf("Error: issuing API key: %v", err)
+		}
+		*apiKey, *apiSecret = key.Key, key.Secret
+	}
+
+	// Each run uses a fresh asset and pair so the book starts empty
+	base := fmt.Sprintf("BENCH%d", time.Now().Unix()%100000)
+	pair := base + "/USDT"
+	body, _ := json.Marshal(map[string]interface{}{"symbol": base, "decimals": 8})
+	req, _ := http.NewRequest(http.MethodPut, *restURL+"/api/v1/admin/assets", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	signRequest(req, *apiKey, *apiSecret, body, -2)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatalf("Error: registering asset: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("Error: registering asset: unexpected status %s", resp.Status)
+	}
+
+	body, _ = json.Marshal(map[string]string{"base": base, "quote": "USDT"})
+	req, _ = http.NewRequest(http.MethodPost, *restURL+"/api/v1/pairs", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	signRequest(req, *apiKey, *apiSecret, body, -1)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatalf("Error: creating pair: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("Error: creating pair: unexpected status %s", resp.Status)
+	}
+
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 1}}
+	restLatencies := make([]time.Duration, 0, *n)
+	for i := 0; i < *n; i++ {
+		// Alternate sides at non-crossing prices so every order rests
+		side, price := orderAt(i)
+		body, _ := json.Marshal(map[string]interface{}{
+			"pair": pair, "side": side, "price": price, "quantity": 1,
+		})
+
+		start := time.Now()
+		req, _ := http.NewRequest(http.MethodPost, *restURL+"/api/v1/orders", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		signRequest(req, *apiKey, *apiSecret, body, int64(i))
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Fatalf("Error: REST order: %v", err)
+		}
+		var out bytes.Buffer
+		out.ReadFrom(resp.Body)
+		resp.Body.Close()
+		restLatencies = append(restLatencies, time.Since(start))
+	}
+
+	binClient, err := binproto.Dial(*binaryAddr, *apiKey, *apiSecret)
+	if err != nil {
+		log.Fatalf("Error: binary login: %v", err)
+	}
+	defer binClient.Close()
+
+	binaryLatencies := make([]time.Duration, 0, *n)
+	for i := 0; i < *n; i++ {
+		side, price := orderAt(i)
+		binSide := binproto.SideBuy
+		if side == "sell" {
+			binSide = binproto.SideSell
+		}
+
+		start := time.Now()
+		if _, err := binClient.PlaceOrder(pair, binSide, price, 1); err != nil {
+			log.Fatalf("Error: binary order: %v", err)
+		}
+		binaryLatencies = append(binaryLatencies, time.Since(start))
+	}
+
+	fmt.Printf("%-8s %10s %10s %10s %10s\n", "protocol", "mean", "p50", "p99", "max")
+	report("rest", restLatencies)
+	report("binary", binaryLatencies)
+}
+
+// issueKey requests the first API key for a user with the server's bootstrap secret
+func issueKey(restURL string, userID int64, bootstrapSecret string) (*auth.APIKey, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"user_id": userID, "permissions": []auth.Permission{auth.PermissionRead, auth.PermissionTrade, auth.PermissionAdmin},
+	})
+	req, _ := http.NewRequest(http.MethodPost, restURL+"/api/v1/keys", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(auth.HeaderBootstrapSecret, bootstrapSecret)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var out struct {
+		Key auth.APIKey `json:"key"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return &out.Key, nil
+}
+
+func signRequest(req *http.Request, key string, secret string, body []byte, seq int64) {
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	nonce := timestamp + "-" + strconv.FormatInt(seq, 10)
+	req.Header.Set(auth.HeaderAPIKey, key)
+	req.Header.Set(auth.HeaderTimestamp, timestamp)
+	req.Header.Set(auth.HeaderNonce, nonce)
+	req.Header.Set(auth.HeaderSignature, auth.Sign(secret, timestamp, nonce, req.Method, req.URL.RequestURI(), body))
+}
+
+func orderAt(i int) (string, float64) {
+	if i%2 == 0 {
+		return "buy", float64(100 + i%50)
+	}
+	return "sell", float64(1000 + i%50)
+}
+
+func report(name string, latencies []time.Duration) {
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	mean := total / time.Duration(len(latencies))
+	p50 := latencies[len(latencies)*50/100]
+	p99 := latencies[len(latencies)*99/100]
+	max := latencies[len(latencies)-1]
+
+	fmt.Printf("%-8s %10s %10s %10s %10s\n", name, mean, p50, p99, max)
+}
@@ -0,0 +1,51 @@
+package apperrors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrAssetNotFound = &ServerError{
+		Code:             "ASSET_NOT_FOUND",
+		Message:          "Asset not found",
+		HTTPResponseCode: http.StatusNotFound,
+		GRPCResponseCode: uint32(codes.NotFound),
+	}
+
+	ErrAssetDisabled = &ServerError{
+		Code:             "ASSET_DISABLED",
+		Message:          "Asset is disabled and cannot be used by new pairs",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.FailedPrecondition),
+	}
+
+	ErrInvalidAssetSymbol = &ServerError{
+		Code:             "INVALID_ASSET_SYMBOL",
+		Message:          "Symbol must be 1 to 10 letters or digits",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidAssetDecimals = &ServerError{
+		Code:             "INVALID_ASSET_DECIMALS",
+		Message:          "Decimals must be between 0 and 18",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrAssetDecimalsLocked = &ServerError{
+		Code:             "ASSET_DECIMALS_LOCKED",
+		Message:          "Decimals of an asset traded in a pair cannot change",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.FailedPrecondition),
+	}
+
+	ErrInvalidAssetStatus = &ServerError{
+		Code:             "INVALID_ASSET_STATUS",
+		Message:          "Status must be active or disabled",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+)
@@ -0,0 +1,109 @@
+package apperrors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrMissingCredentials = &ServerError{
+		Code:             "MISSING_CREDENTIALS",
+		Message:          "X-API-KEY, X-API-TIMESTAMP, X-API-NONCE and X-API-SIGNATURE are required",
+		HTTPResponseCode: http.StatusUnauthorized,
+		GRPCResponseCode: uint32(codes.Unauthenticated),
+	}
+
+	ErrInvalidAPIKey = &ServerError{
+		Code:             "INVALID_API_KEY",
+		Message:          "API key not found",
+		HTTPResponseCode: http.StatusUnauthorized,
+		GRPCResponseCode: uint32(codes.Unauthenticated),
+	}
+
+	ErrInvalidSignature = &ServerError{
+		Code:             "INVALID_SIGNATURE",
+		Message:          "Request signature does not match",
+		HTTPResponseCode: http.StatusUnauthorized,
+		GRPCResponseCode: uint32(codes.Unauthenticated),
+	}
+
+	ErrRequestExpired = &ServerError{
+		Code:             "REQUEST_EXPIRED",
+		Message:          "Request timestamp is outside the allowed window",
+		HTTPResponseCode: http.StatusUnauthorized,
+		GRPCResponseCode: uint32(codes.Unauthenticated),
+	}
+
+	ErrNonceReused = &ServerError{
+		Code:             "NONCE_REUSED",
+		Message:          "Request nonce has already been used",
+		HTTPResponseCode: http.StatusUnauthorized,
+		GRPCResponseCode: uint32(codes.Unauthenticated),
+	}
+
+	ErrInvalidBootstrapSecret = &ServerError{
+		Code:             "INVALID_BOOTSTRAP_SECRET",
+		Message:          "Unsigned key issuance requires the server's bootstrap secret in X-BOOTSTRAP-SECRET",
+		HTTPResponseCode: http.StatusUnauthorized,
+		GRPCResponseCode: uint32(codes.Unauthenticated),
+	}
+
+	ErrKeyIssuanceForbidden = &ServerError{
+		Code:             "KEY_ISSUANCE_FORBIDDEN",
+		Message:          "User already has API keys; sign the request with one of them to issue another",
+		HTTPResponseCode: http.StatusForbidden,
+		GRPCResponseCode: uint32(codes.PermissionDenied),
+	}
+)
+
+var (
+	ErrPermissionDenied = &ServerError{
+		Code:             "PERMISSION_DENIED",
+		Message:          "API key does not have the permission required by this endpoint",
+		HTTPResponseCode: http.StatusForbidden,
+		GRPCResponseCode: uint32(codes.PermissionDenied),
+	}
+
+	ErrIPNotAllowed = &ServerError{
+		Code:             "IP_NOT_ALLOWED",
+		Message:          "Request IP is not in the API key's allowlist",
+		HTTPResponseCode: http.StatusForbidden,
+		GRPCResponseCode: uint32(codes.PermissionDenied),
+	}
+
+	ErrPermissionEscalation = &ServerError{
+		Code:             "PERMISSION_ESCALATION",
+		Message:          "Cannot grant or revoke permissions the signing key does not have",
+		HTTPResponseCode: http.StatusForbidden,
+		GRPCResponseCode: uint32(codes.PermissionDenied),
+	}
+
+	ErrAllowlistEscalation = &ServerError{
+		Code:             "ALLOWLIST_ESCALATION",
+		Message:          "Cannot allow IPs outside the signing key's allowlist",
+		HTTPResponseCode: http.StatusForbidden,
+		GRPCResponseCode: uint32(codes.PermissionDenied),
+	}
+
+	ErrInvalidPermission = &ServerError{
+		Code:             "INVALID_PERMISSION",
+		Message:          "Permissions must be read, trade, withdraw or admin",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidAllowedIP = &ServerError{
+		Code:             "INVALID_ALLOWED_IP",
+		Message:          "Allowed IPs must be IP addresses or CIDR ranges",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrAPIKeyNotFound = &ServerError{
+		Code:             "API_KEY_NOT_FOUND",
+		Message:          "API key not found",
+		HTTPResponseCode: http.StatusNotFound,
+		GRPCResponseCode: uint32(codes.NotFound),
+	}
+)
@@ -0,0 +1,30 @@
+package apperrors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrInvalidIdempotencyKey = &ServerError{
+		Code:             "INVALID_IDEMPOTENCY_KEY",
+		Message:          "Idempotency-Key must be between 1 and 255 characters",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrIdempotencyKeyReused = &ServerError{
+		Code:             "IDEMPOTENCY_KEY_REUSED",
+		Message:          "Idempotency-Key was already used for a different request",
+		HTTPResponseCode: http.StatusUnprocessableEntity,
+		GRPCResponseCode: uint32(codes.FailedPrecondition),
+	}
+
+	ErrIdempotencyKeyInProgress = &ServerError{
+		Code:             "IDEMPOTENCY_KEY_IN_PROGRESS",
+		Message:          "A request with this Idempotency-Key is still being processed",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.Aborted),
+	}
+)
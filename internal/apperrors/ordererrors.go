@@ -2,6 +2,8 @@ package apperrors
 
 import (
 	"net/http"
+
+	"google.golang.org/grpc/codes"
 )
 
 type ServerError struct {
@@ -20,30 +22,190 @@ var (
 		Code:             "PAIR_NOT_FOUND",
 		Message:          "Trading pair not found",
 		HTTPResponseCode: http.StatusNotFound,
+		GRPCResponseCode: uint32(codes.NotFound),
 	}
 
 	ErrInvalidPrice = &ServerError{
 		Code:             "INVALID_PRICE",
 		Message:          "Price must be greater than 0",
 		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
 	}
 
 	ErrInvalidQuantity = &ServerError{
 		Code:             "INVALID_QUANTITY",
 		Message:          "Quantity must be greater than 0",
 		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidPriceIncrement = &ServerError{
+		Code:             "INVALID_PRICE_INCREMENT",
+		Message:          "Price has more decimal places than the pair allows",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidQuantityIncrement = &ServerError{
+		Code:             "INVALID_QUANTITY_INCREMENT",
+		Message:          "Quantity has more decimal places than the pair allows",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
 	}
 
 	ErrInvalidSide = &ServerError{
 		Code:             "INVALID_SIDE",
 		Message:          "Side must be 'buy' or 'sell'",
 		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
 	}
 
 	ErrInvalidUserID = &ServerError{
 		Code:             "INVALID_USER_ID",
 		Message:          "User ID must be greater than 0",
 		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidInterval = &ServerError{
+		Code:             "INVALID_INTERVAL",
+		Message:          "Interval must be one of 1m, 5m, 15m, 1h, 4h, 1d",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidTimeRange = &ServerError{
+		Code:             "INVALID_TIME_RANGE",
+		Message:          "Start and end must be unix seconds or RFC3339 with start not after end",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrOrderNotFound = &ServerError{
+		Code:             "ORDER_NOT_FOUND",
+		Message:          "Order not found",
+		HTTPResponseCode: http.StatusNotFound,
+		GRPCResponseCode: uint32(codes.NotFound),
+	}
+
+	ErrOrderNotOpen = &ServerError{
+		Code:             "ORDER_NOT_OPEN",
+		Message:          "Order is already filled or cancelled",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.FailedPrecondition),
+	}
+
+	ErrInvalidReplaceQuantity = &ServerError{
+		Code:             "INVALID_REPLACE_QUANTITY",
+		Message:          "Replacement quantity must be greater than the filled quantity",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+)
+
+var (
+	ErrTooManyOpenOrders = &ServerError{
+		Code:             "TOO_MANY_OPEN_ORDERS",
+		Message:          "Maximum number of open orders for this pair reached",
+		HTTPResponseCode: http.StatusTooManyRequests,
+		GRPCResponseCode: uint32(codes.ResourceExhausted),
+	}
+
+	ErrOrderToTradeRatioExceeded = &ServerError{
+		Code:             "ORDER_TO_TRADE_RATIO_EXCEEDED",
+		Message:          "Maximum order-to-trade ratio exceeded",
+		HTTPResponseCode: http.StatusTooManyRequests,
+		GRPCResponseCode: uint32(codes.ResourceExhausted),
+	}
+
+	ErrInvalidOrderLimits = &ServerError{
+		Code:             "INVALID_ORDER_LIMITS",
+		Message:          "Order limits must not be negative",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidOrderStatus = &ServerError{
+		Code:             "INVALID_ORDER_STATUS",
+		Message:          "Status must be open, partial, filled or cancelled",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidSortOrder = &ServerError{
+		Code:             "INVALID_SORT_ORDER",
+		Message:          "Sort must be asc or desc",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidPageLimit = &ServerError{
+		Code:             "INVALID_PAGE_LIMIT",
+		Message:          "Limit must be between 1 and 1000",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidCursor = &ServerError{
+		Code:             "INVALID_CURSOR",
+		Message:          "Cursor is not a next_cursor returned by this endpoint",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidClientOrderID = &ServerError{
+		Code:             "INVALID_CLIENT_ORDER_ID",
+		Message:          "Client order ID must be at most 36 letters, digits, '-', '_', '.' or ':'",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrDuplicateClientOrderID = &ServerError{
+		Code:             "DUPLICATE_CLIENT_ORDER_ID",
+		Message:          "Client order ID is already used by an open or recent order",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.AlreadyExists),
+	}
+
+	ErrInvalidBatchMode = &ServerError{
+		Code:             "INVALID_BATCH_MODE",
+		Message:          "Batch mode must be atomic or best_effort",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidBatchSize = &ServerError{
+		Code:             "INVALID_BATCH_SIZE",
+		Message:          "Batch must contain between 1 and 50 orders",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrBatchRejected = &ServerError{
+		Code:             "BATCH_REJECTED",
+		Message:          "Atomic batch rejected because at least one order failed validation",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrBatchRolledBack = &ServerError{
+		Code:             "BATCH_ROLLED_BACK",
+		Message:          "Atomic batch rolled back because the matching engine rejected one of its orders",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.Aborted),
+	}
+
+	ErrInvalidCancelTimeout = &ServerError{
+		Code:             "INVALID_CANCEL_TIMEOUT",
+		Message:          "Cancel-all-after timeout must be between 0 and 600000 milliseconds",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidGracePeriod = &ServerError{
+		Code:             "INVALID_GRACE_PERIOD",
+		Message:          "Cancel-on-disconnect grace period must be between 0 and 300000 milliseconds",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
 	}
-	
 )
@@ -0,0 +1,118 @@
+package apperrors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrPairHalted = &ServerError{
+		Code:             "PAIR_HALTED",
+		Message:          "Trading pair is halted",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.FailedPrecondition),
+	}
+
+	ErrPairCancelOnly = &ServerError{
+		Code:             "PAIR_CANCEL_ONLY",
+		Message:          "Trading pair only accepts cancels",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.FailedPrecondition),
+	}
+
+	ErrPairDelisted = &ServerError{
+		Code:             "PAIR_DELISTED",
+		Message:          "Trading pair is delisted",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.FailedPrecondition),
+	}
+
+	ErrPostOnlyWouldCross = &ServerError{
+		Code:             "POST_ONLY_WOULD_CROSS",
+		Message:          "Trading pair is post-only and the order would match immediately",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.FailedPrecondition),
+	}
+
+	ErrInvalidPairStatus = &ServerError{
+		Code:             "INVALID_PAIR_STATUS",
+		Message:          "Status must be trading, halted, cancel_only, post_only, auction or delisted",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidPairTransition = &ServerError{
+		Code:             "INVALID_PAIR_TRANSITION",
+		Message:          "Delisted pairs cannot change status",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.FailedPrecondition),
+	}
+)
+
+var (
+	ErrPriceOutsideBand = &ServerError{
+		Code:             "PRICE_OUTSIDE_BAND",
+		Message:          "Order price is outside the pair's price band",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidRiskConfig = &ServerError{
+		Code:             "INVALID_RISK_CONFIG",
+		Message:          "Percentages and durations must not be negative and band mode must be reject or cap",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+)
+
+var (
+	ErrInvalidAuctionDuration = &ServerError{
+		Code:             "INVALID_AUCTION_DURATION",
+		Message:          "Auction duration must not be negative",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidMatchingMode = &ServerError{
+		Code:             "INVALID_MATCHING_MODE",
+		Message:          "Matching mode must be continuous or batch",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidBatchInterval = &ServerError{
+		Code:             "INVALID_BATCH_INTERVAL",
+		Message:          "Batch interval must not be negative",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidAllocation = &ServerError{
+		Code:             "INVALID_ALLOCATION",
+		Message:          "Allocation must be fifo, pro_rata or pro_rata_top, with percentages between 0 and 100 for pro_rata_top only",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrAllocationNotSupported = &ServerError{
+		Code:             "ALLOCATION_NOT_SUPPORTED",
+		Message:          "Allocation applies to continuous matching only; batch pairs fill in time priority",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrPairExists = &ServerError{
+		Code:             "PAIR_EXISTS",
+		Message:          "Trading pair already exists",
+		HTTPResponseCode: http.StatusConflict,
+		GRPCResponseCode: uint32(codes.AlreadyExists),
+	}
+
+	ErrInvalidPair = &ServerError{
+		Code:             "INVALID_PAIR",
+		Message:          "Base and quote must be different assets",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+)
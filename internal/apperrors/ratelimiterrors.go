@@ -0,0 +1,16 @@
+package apperrors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrRateLimited = &ServerError{
+		Code:             "RATE_LIMITED",
+		Message:          "Too many requests, retry after the number of seconds in Retry-After",
+		HTTPResponseCode: http.StatusTooManyRequests,
+		GRPCResponseCode: uint32(codes.ResourceExhausted),
+	}
+)
@@ -0,0 +1,58 @@
+package apperrors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	ErrInvalidRequestBody = &ServerError{
+		Code:             "INVALID_REQUEST_BODY",
+		Message:          "Request body must be valid JSON",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrMissingParameter = &ServerError{
+		Code:             "MISSING_PARAMETER",
+		Message:          "A required parameter is missing",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrInvalidParameter = &ServerError{
+		Code:             "INVALID_PARAMETER",
+		Message:          "A parameter has an invalid value",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrValidationFailed = &ServerError{
+		Code:             "VALIDATION_FAILED",
+		Message:          "Request validation failed",
+		HTTPResponseCode: http.StatusBadRequest,
+		GRPCResponseCode: uint32(codes.InvalidArgument),
+	}
+
+	ErrRouteNotFound = &ServerError{
+		Code:             "ROUTE_NOT_FOUND",
+		Message:          "No endpoint matches the request path",
+		HTTPResponseCode: http.StatusNotFound,
+		GRPCResponseCode: uint32(codes.Unimplemented),
+	}
+
+	ErrMethodNotAllowed = &ServerError{
+		Code:             "METHOD_NOT_ALLOWED",
+		Message:          "The endpoint does not support the request method",
+		HTTPResponseCode: http.StatusMethodNotAllowed,
+		GRPCResponseCode: uint32(codes.Unimplemented),
+	}
+
+	ErrInternal = &ServerError{
+		Code:             "INTERNAL_ERROR",
+		Message:          "Internal server error",
+		HTTPResponseCode: http.StatusInternalServerError,
+		GRPCResponseCode: uint32(codes.Internal),
+	}
+)
@@ -0,0 +1,55 @@
+package assets
+
+import (
+	"log"
+	"mini-crypto-exchange/internal/models"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultAssets are registered when ASSETS is not set
+var DefaultAssets = []models.Asset{
+	{Symbol: "BTC", Name: "Bitcoin", Decimals: 8, Status: models.AssetStatusActive},
+	{Symbol: "ETH", Name: "Ether", Decimals: 18, Status: models.AssetStatusActive},
+	{Symbol: "SOL", Name: "Solana", Decimals: 9, Status: models.AssetStatusActive},
+	{Symbol: "USDT", Name: "Tether USD", Decimals: 6, Status: models.AssetStatusActive},
+	{Symbol: "USDC", Name: "USD Coin", Decimals: 6, Status: models.AssetStatusActive},
+	{Symbol: "USD", Name: "US Dollar", Decimals: 2, Status: models.AssetStatusActive},
+	{Symbol: "EUR", Name: "Euro", Decimals: 2, Status: models.AssetStatusActive},
+}
+
+// Config holds the assets registered at startup
+type Config struct {
+	Assets []models.Asset
+}
+
+// ConfigFromEnv builds a Config from ASSETS, a comma separated list of SYMBOL:Name:decimals entries
+// such as "BTC:Bitcoin:8,USDT:Tether USD:6" that replaces DefaultAssets
+func ConfigFromEnv() Config {
+	value := os.Getenv("ASSETS")
+	if value == "" {
+		return Config{Assets: DefaultAssets}
+	}
+
+	var config Config
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 {
+			log.Printf("Ignoring invalid ASSETS entry %q", entry)
+			continue
+		}
+		decimals, err := strconv.Atoi(parts[2])
+		if err != nil {
+			log.Printf("Ignoring invalid ASSETS entry %q", entry)
+			continue
+		}
+		config.Assets = append(config.Assets, models.Asset{
+			Symbol:   parts[0],
+			Name:     parts[1],
+			Decimals: decimals,
+			Status:   models.AssetStatusActive,
+		})
+	}
+	return config
+}
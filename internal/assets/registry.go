@@ -0,0 +1,110 @@
+// Package assets keeps the currencies trading pairs can be built from.
+package assets
+
+import (
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Asset limits
+const (
+	MaxSymbolLength = 10
+	MaxDecimals     = 18
+)
+
+// Registry is the in-memory set of known assets, keyed by normalized symbol
+type Registry struct {
+	mu     sync.RWMutex
+	assets map[string]models.Asset
+}
+
+// NewRegistry creates a registry holding the configured assets, skipping invalid ones
+func NewRegistry(config Config) *Registry {
+	r := &Registry{assets: make(map[string]models.Asset)}
+	for _, asset := range config.Assets {
+		if _, err := r.Register(asset); err != nil {
+			log.Printf("Skipping asset %q: %v", asset.Symbol, err)
+		}
+	}
+	return r
+}
+
+// NormalizeSymbol returns the canonical form of an asset symbol, trimmed and upper case
+func NormalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// NormalizePair returns the canonical form of a pair name with both symbols normalized, so
+// "btc/usdt" becomes "BTC/USDT"
+func NormalizePair(pair string) string {
+	base, quote, found := strings.Cut(pair, "/")
+	if !found {
+		return NormalizeSymbol(pair)
+	}
+	return NormalizeSymbol(base) + "/" + NormalizeSymbol(quote)
+}
+
+// Register adds an asset or replaces the one with the same symbol. The symbol is normalized, the
+// name defaults to the symbol and the status to active.
+func (r *Registry) Register(asset models.Asset) (models.Asset, error) {
+	asset.Symbol = NormalizeSymbol(asset.Symbol)
+	asset.Name = strings.TrimSpace(asset.Name)
+	if asset.Name == "" {
+		asset.Name = asset.Symbol
+	}
+	if asset.Status == "" {
+		asset.Status = models.AssetStatusActive
+	}
+	if !validSymbol(asset.Symbol) {
+		return models.Asset{}, apperrors.ErrInvalidAssetSymbol
+	}
+	if asset.Decimals < 0 || asset.Decimals > MaxDecimals {
+		return models.Asset{}, apperrors.ErrInvalidAssetDecimals
+	}
+	if !asset.Status.IsValid() {
+		return models.Asset{}, apperrors.ErrInvalidAssetStatus
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.assets[asset.Symbol] = asset
+	return asset, nil
+}
+
+// Get returns the asset with the given symbol, which is normalized first
+func (r *Registry) Get(symbol string) (models.Asset, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	asset, exists := r.assets[NormalizeSymbol(symbol)]
+	return asset, exists
+}
+
+// List returns all assets sorted by symbol
+func (r *Registry) List() []models.Asset {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	assets := make([]models.Asset, 0, len(r.assets))
+	for _, asset := range r.assets {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Symbol < assets[j].Symbol })
+	return assets
+}
+
+// validSymbol accepts 1 to MaxSymbolLength upper case letters and digits
+func validSymbol(symbol string) bool {
+	if symbol == "" || len(symbol) > MaxSymbolLength {
+		return false
+	}
+	for _, c := range symbol {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
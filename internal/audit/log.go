@@ -0,0 +1,97 @@
+// Package audit records actions performed through admin endpoints.
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry is one admin action
+type Entry struct {
+	ID          int64           `json:"id"`
+	AdminUserID int64           `json:"admin_user_id"`
+	APIKey      string          `json:"api_key"`
+	Action      string          `json:"action"`
+	Source      string          `json:"source"` // "rest" or "grpc"
+	Path        string          `json:"path"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Status      int             `json:"status"` // HTTP status of the outcome
+	Timestamp   time.Time       `json:"timestamp"`
+}
+
+// Config configures the audit log
+type Config struct {
+	// File receives entries as JSON lines in addition to memory, when set
+	File string
+}
+
+// ConfigFromEnv builds a Config from AUDIT_LOG_FILE
+func ConfigFromEnv() Config {
+	return Config{File: os.Getenv("AUDIT_LOG_FILE")}
+}
+
+// Log is an append-only audit log kept in memory and optionally mirrored to a file
+type Log struct {
+	mu      sync.Mutex
+	entries []Entry
+	nextID  int64
+	file    *os.File
+}
+
+// NewLog creates an audit log
+func NewLog(config Config) (*Log, error) {
+	l := &Log{nextID: 1}
+	if config.File != "" {
+		file, err := os.OpenFile(config.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		l.file = file
+	}
+	return l, nil
+}
+
+// Record assigns the entry an ID and timestamp and appends it
+func (l *Log) Record(entry Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.ID = l.nextID
+	l.nextID++
+	entry.Timestamp = time.Now()
+	l.entries = append(l.entries, entry)
+
+	if l.file == nil {
+		return entry, nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return entry, err
+	}
+	_, err = l.file.Write(append(line, '\n'))
+	return entry, err
+}
+
+// Entries returns up to limit of the most recent entries, oldest first. limit <= 0 returns all.
+func (l *Log) Entries(limit int) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := 0
+	if limit > 0 && len(l.entries) > limit {
+		start = len(l.entries) - limit
+	}
+	entries := make([]Entry, len(l.entries)-start)
+	copy(entries, l.entries[start:])
+	return entries
+}
+
+// Close closes the mirror file
+func (l *Log) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordAssignsIDsAndTimestamps(t *testing.T) {
+	l, err := NewLog(Config{})
+	if err != nil {
+		t.Fatalf("NewLog: %v", err)
+	}
+	before := time.Now()
+	first, _ := l.Record(Entry{ID: 42, AdminUserID: 1, Action: "create_pair", Source: "rest", Status: 200})
+	second, _ := l.Record(Entry{AdminUserID: 2, Action: "set_pair_status", Source: "grpc", Status: 409})
+
+	if first.ID != 1 || second.ID != 2 {
+		t.Fatalf("got IDs %d and %d, want 1 and 2 whatever the caller set", first.ID, second.ID)
+	}
+	if first.Timestamp.Before(before) || second.Timestamp.Before(first.Timestamp) {
+		t.Fatalf("timestamps %v and %v, want them set when recorded", first.Timestamp, second.Timestamp)
+	}
+
+	entries := l.Entries(0)
+	if len(entries) != 2 || entries[0].ID != first.ID || entries[0].Action != "create_pair" || entries[1].Action != "set_pair_status" || entries[1].Status != 409 {
+		t.Fatalf("entries %+v, want both in the order recorded", entries)
+	}
+}
+
+func TestEntriesReturnsTheMostRecent(t *testing.T) {
+	l, _ := NewLog(Config{})
+	for i := 0; i < 5; i++ {
+		l.Record(Entry{Action: "create_pair"})
+	}
+
+	for _, tt := range []struct {
+		limit   int
+		firstID int64
+		count   int
+	}{{0, 1, 5}, {-1, 1, 5}, {2, 4, 2}, {5, 1, 5}, {10, 1, 5}} {
+		entries := l.Entries(tt.limit)
+		if len(entries) != tt.count || entries[0].ID != tt.firstID || entries[len(entries)-1].ID != 5 {
+			t.Errorf("limit %d: got %d entries from %d, want %d from %d oldest first", tt.limit, len(entries), entries[0].ID, tt.count, tt.firstID)
+		}
+	}
+
+	// Callers get a copy
+	entries := l.Entries(0)
+	entries[0].Action = "tampered"
+	if l.Entries(0)[0].Action != "create_pair" {
+		t.Fatal("changing a returned entry changed the log")
+	}
+}
+
+func TestRecordMirrorsEntriesToTheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte(`{"id":1,"action":"earlier_run"}`+"\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	l, err := NewLog(Config{File: path})
+	if err != nil {
+		t.Fatalf("NewLog: %v", err)
+	}
+	recorded, err := l.Record(Entry{AdminUserID: 7, APIKey: "key", Action: "set_risk", Source: "rest", Path: "PUT /api/admin/pairs/BTC-USDT/risk", Payload: json.RawMessage(`{"breaker_percent":5}`), Status: 200})
+	if err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer file.Close()
+	var lines []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("line %q is not a JSON entry: %v", scanner.Text(), err)
+		}
+		lines = append(lines, entry)
+	}
+	if len(lines) != 2 || lines[0].Action != "earlier_run" {
+		t.Fatalf("file holds %+v, want the new entry appended to the existing one", lines)
+	}
+	got := lines[1]
+	if got.ID != recorded.ID || got.AdminUserID != 7 || got.Action != "set_risk" || string(got.Payload) != `{"breaker_percent":5}` || !got.Timestamp.Equal(recorded.Timestamp) {
+		t.Fatalf("file entry %+v, want %+v", got, recorded)
+	}
+}
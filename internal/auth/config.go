@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config configures API key issuance
+type Config struct {
+	// BootstrapSecret allows issuing a user's first key without a signed request. When empty,
+	// keys can only be issued by a signed request.
+	BootstrapSecret string
+	// AdminUserIDs may be issued an admin key with the bootstrap secret
+	AdminUserIDs map[int64]bool
+}
+
+// ConfigFromEnv builds a Config from KEY_BOOTSTRAP_SECRET and ADMIN_USER_IDS, a comma separated
+// list of user IDs
+func ConfigFromEnv() Config {
+	config := Config{
+		BootstrapSecret: os.Getenv("KEY_BOOTSTRAP_SECRET"),
+		AdminUserIDs:    make(map[int64]bool),
+	}
+	for _, entry := range strings.Split(os.Getenv("ADMIN_USER_IDS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		userID, err := strconv.ParseInt(entry, 10, 64)
+		if err != nil || userID <= 0 {
+			log.Printf("Ignoring invalid admin user ID %q", entry)
+			continue
+		}
+		config.AdminUserIDs[userID] = true
+	}
+	return config
+}
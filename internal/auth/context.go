@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"context"
+)
+
+type contextKey int
+
+const (
+	userContextKey contextKey = iota
+	apiKeyContextKey
+)
+
+// WithUser returns a context carrying the authenticated user ID
+func WithUser(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userContextKey, userID)
+}
+
+// UserFromContext returns the authenticated user ID, if any
+func UserFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userContextKey).(int64)
+	return userID, ok
+}
+
+// WithAPIKey returns a context carrying the authenticated key and its user
+func WithAPIKey(ctx context.Context, apiKey *APIKey) context.Context {
+	return context.WithValue(WithUser(ctx, apiKey.UserID), apiKeyContextKey, apiKey)
+}
+
+// APIKeyFromContext returns the key the request was signed with, if any
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	apiKey, ok := ctx.Value(apiKeyContextKey).(*APIKey)
+	return apiKey, ok
+}
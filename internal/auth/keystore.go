@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// APIKey is a credential issued to a user. The secret is only returned when the key is issued.
+type APIKey struct {
+	Key         string       `json:"api_key"`
+	Secret      string       `json:"secret,omitempty"`
+	UserID      int64        `json:"user_id"`
+	Permissions []Permission `json:"permissions"`
+	AllowedIPs  []string     `json:"allowed_ips,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// KeyStore keeps issued API keys in memory
+type KeyStore struct {
+	mu     sync.RWMutex
+	keys   map[string]*APIKey // key -> APIKey
+	byUser map[int64][]string // user ID -> keys
+}
+
+// NewKeyStore creates a new API key store
+func NewKeyStore() *KeyStore {
+	return &KeyStore{
+		keys:   make(map[string]*APIKey),
+		byUser: make(map[int64][]string),
+	}
+}
+
+// Issue creates a new key and secret for a user with the given permissions and IP allowlist
+func (ks *KeyStore) Issue(userID int64, permissions []Permission, allowedIPs []string) (*APIKey, error) {
+	key, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &APIKey{
+		Key:         key,
+		Secret:      secret,
+		UserID:      userID,
+		Permissions: permissions,
+		AllowedIPs:  allowedIPs,
+		CreatedAt:   time.Now(),
+	}
+
+	ks.mu.Lock()
+	ks.keys[key] = apiKey
+	ks.byUser[userID] = append(ks.byUser[userID], key)
+	ks.mu.Unlock()
+
+	issued := *apiKey
+	return &issued, nil
+}
+
+// Get returns a copy of the key including its secret
+func (ks *KeyStore) Get(key string) (APIKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	apiKey, exists := ks.keys[key]
+	if !exists {
+		return APIKey{}, false
+	}
+	return *apiKey, true
+}
+
+// List returns the user's keys without their secrets, oldest first
+func (ks *KeyStore) List(userID int64) []APIKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keys := make([]APIKey, 0, len(ks.byUser[userID]))
+	for _, key := range ks.byUser[userID] {
+		apiKey := *ks.keys[key]
+		apiKey.Secret = ""
+		keys = append(keys, apiKey)
+	}
+	return keys
+}
+
+// Revoke deletes one of the user's keys, reporting false when the user has no such key
+func (ks *KeyStore) Revoke(userID int64, key string) bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	apiKey, exists := ks.keys[key]
+	if !exists || apiKey.UserID != userID {
+		return false
+	}
+	delete(ks.keys, key)
+
+	userKeys := ks.byUser[userID]
+	for i, k := range userKeys {
+		if k == key {
+			ks.byUser[userID] = append(userKeys[:i], userKeys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// HasKeys reports whether the user has been issued any key
+func (ks *KeyStore) HasKeys(userID int64) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return len(ks.byUser[userID]) > 0
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"mini-crypto-exchange/internal/apperrors"
+	"net"
+	"strings"
+)
+
+// Permission is a scope granted to an API key
+type Permission string
+
+const (
+	PermissionRead     Permission = "read"
+	PermissionTrade    Permission = "trade"
+	PermissionWithdraw Permission = "withdraw"
+	PermissionAdmin    Permission = "admin"
+)
+
+// DefaultPermissions are granted when a key is issued without explicit permissions
+var DefaultPermissions = []Permission{PermissionRead, PermissionTrade}
+
+// ParsePermissions validates and de-duplicates permission names
+func ParsePermissions(names []string) ([]Permission, error) {
+	permissions := make([]Permission, 0, len(names))
+	seen := make(map[Permission]bool)
+	for _, name := range names {
+		permission := Permission(strings.ToLower(strings.TrimSpace(name)))
+		switch permission {
+		case PermissionRead, PermissionTrade, PermissionWithdraw, PermissionAdmin:
+		default:
+			return nil, apperrors.ErrInvalidPermission
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
+// ParseAllowedIPs validates an IP allowlist of single addresses and CIDR ranges,
+// returning it in canonical form
+func ParseAllowedIPs(entries []string) ([]string, error) {
+	allowed := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			allowed = append(allowed, network.String())
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, apperrors.ErrInvalidAllowedIP
+		}
+		allowed = append(allowed, ip.String())
+	}
+	return allowed, nil
+}
+
+// HasPermission reports whether the key was granted a permission
+func (k *APIKey) HasPermission(permission Permission) bool {
+	for _, granted := range k.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIPs reports whether the key allows every address of a canonical allowlist, so keys it issues
+// cannot be used from elsewhere. An empty allowlist stands for every address.
+func (k *APIKey) AllowsIPs(allowed []string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+	if len(allowed) == 0 {
+		return false
+	}
+	for _, entry := range allowed {
+		if !k.allowsEntry(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsEntry reports whether an address or every address of a CIDR range is in the allowlist
+func (k *APIKey) allowsEntry(entry string) bool {
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		return k.AllowsIP(net.ParseIP(entry))
+	}
+	ones, bits := network.Mask.Size()
+	for _, own := range k.AllowedIPs {
+		if _, ownNetwork, err := net.ParseCIDR(own); err == nil {
+			ownOnes, ownBits := ownNetwork.Mask.Size()
+			if ownBits == bits && ownOnes <= ones && ownNetwork.Contains(network.IP) {
+				return true
+			}
+		} else if ones == bits && net.ParseIP(own).Equal(network.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIP reports whether requests from ip may use the key. Keys without an allowlist accept any IP.
+func (k *APIKey) AllowsIP(ip net.IP) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, entry := range k.AllowedIPs {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteIP extracts the IP from a host:port remote address
+func RemoteIP(remoteAddr string) net.IP {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// Authorize checks a request from ip against the key's allowlist and the permissions it requires
+func (k *APIKey) Authorize(ip net.IP, required ...Permission) error {
+	if !k.AllowsIP(ip) {
+		return apperrors.ErrIPNotAllowed
+	}
+	for _, permission := range required {
+		if !k.HasPermission(permission) {
+			return apperrors.ErrPermissionDenied
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"mini-crypto-exchange/internal/apperrors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers carrying request credentials. gRPC uses the same names, lower cased, as metadata keys.
+const (
+	HeaderAPIKey    = "X-API-KEY"
+	HeaderTimestamp = "X-API-TIMESTAMP"
+	HeaderNonce     = "X-API-NONCE"
+	HeaderSignature = "X-API-SIGNATURE"
+
+	// HeaderBootstrapSecret carries the server's bootstrap secret on unsigned key issuance
+	HeaderBootstrapSecret = "X-BOOTSTRAP-SECRET"
+)
+
+// Credentials are the signed request fields, for transports without HTTP headers
+type Credentials struct {
+	APIKey    string
+	Timestamp string // unix milliseconds
+	Nonce     string
+	Signature string
+}
+
+// SignatureWindow is how far a request timestamp may drift from server time.
+// Nonces are remembered for the same window to reject replays.
+const SignatureWindow = 30 * time.Second
+
+// Sign computes the hex HMAC-SHA256 of timestamp + nonce + method + path + body with the key's secret.
+// For REST, method is the HTTP method and path the request URI including the query string;
+// for gRPC, method is "POST" and path the full RPC method name.
+func Sign(secret string, timestamp string, nonce string, method string, path string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte(method))
+	mac.Write([]byte(path))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Authenticator verifies signed requests against issued API keys
+type Authenticator struct {
+	keys *KeyStore
+
+	mu     sync.Mutex
+	nonces map[string]map[string]time.Time // key -> nonce -> first seen
+	now    func() time.Time
+}
+
+// NewAuthenticator creates a new request authenticator
+func NewAuthenticator(keys *KeyStore) *Authenticator {
+	return &Authenticator{
+		keys:   keys,
+		nonces: make(map[string]map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+// Keys returns the key store backing the authenticator
+func (a *Authenticator) Keys() *KeyStore {
+	return a.keys
+}
+
+// Verify checks a request signature and returns the authenticated key.
+// timestamp is unix milliseconds.
+func (a *Authenticator) Verify(key string, timestamp string, nonce string, signature string, method string, path string, body []byte) (*APIKey, error) {
+	if key == "" || timestamp == "" || nonce == "" || signature == "" {
+		return nil, apperrors.ErrMissingCredentials
+	}
+
+	apiKey, exists := a.keys.Get(key)
+	if !exists {
+		return nil, apperrors.ErrInvalidAPIKey
+	}
+
+	millis, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, apperrors.ErrRequestExpired
+	}
+	now := a.now()
+	drift := now.Sub(time.UnixMilli(millis))
+	if drift > SignatureWindow || drift < -SignatureWindow {
+		return nil, apperrors.ErrRequestExpired
+	}
+
+	expected := Sign(apiKey.Secret, timestamp, nonce, method, path, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, apperrors.ErrInvalidSignature
+	}
+
+	if !a.useNonce(key, nonce, now) {
+		return nil, apperrors.ErrNonceReused
+	}
+
+	apiKey.Secret = ""
+	return &apiKey, nil
+}
+
+// useNonce records a nonce, reporting false when it was already used within the window
+func (a *Authenticator) useNonce(key string, nonce string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	seen, exists := a.nonces[key]
+	if !exists {
+		seen = make(map[string]time.Time)
+		a.nonces[key] = seen
+	}
+
+	for n, at := range seen {
+		if now.Sub(at) > 2*SignatureWindow {
+			delete(seen, n)
+		}
+	}
+
+	if _, used := seen[nonce]; used {
+		return false
+	}
+	seen[nonce] = now
+	return true
+}
@@ -0,0 +1,599 @@
+package bingateway
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/ratelimit"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/pkg/binproto"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Config configures the binary order entry gateway
+type Config struct {
+	Port string // the gateway is only started when a port is set
+}
+
+// ConfigFromEnv builds a Config from BINARY_PORT
+func ConfigFromEnv() Config {
+	return Config{Port: os.Getenv("BINARY_PORT")}
+}
+
+// Enabled reports whether the gateway should be started
+func (c Config) Enabled() bool {
+	return c.Port != ""
+}
+
+// connection is one logged in client
+type connection struct {
+	conn    net.Conn
+	ip      net.IP
+	writeMu sync.Mutex
+	userID  int64
+	options binproto.SessionOptions
+}
+
+// readTimeout is how long the connection may stay silent, zero without heartbeats
+func (c *connection) readTimeout() time.Duration {
+	return 2 * time.Duration(c.options.HeartbeatIntervalMs) * time.Millisecond
+}
+
+func (c *connection) send(msg interface{}) {
+	frame, err := binproto.Encode(msg)
+	if err != nil {
+		log.Printf("Binary gateway: failed to encode response: %v", err)
+		return
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.conn.Write(frame); err != nil {
+		log.Printf("Binary gateway: failed to write to %s: %v", c.conn.RemoteAddr(), err)
+	}
+}
+
+// orderRef tracks an engine order placed through the gateway
+type orderRef struct {
+	conn          *connection
+	clientOrderID uint64
+	quantity      float64 // total quantity as seen by the client, across amends
+	filled        float64
+	closed        bool
+}
+
+// engineEvent is a trade or a cancelled order queued by the engine callbacks
+type engineEvent struct {
+	trade     *models.Trade
+	cancelled *models.Order
+}
+
+// Gateway accepts binary protocol connections and routes their requests into the matching engine
+type Gateway struct {
+	config            Config
+	engine            *engine.MatchingEngine
+	placeOrderService services.PlaceOrderService
+	apiKeyService     services.APIKeyService
+	limiters          *ratelimit.Limiters
+	listener          net.Listener
+
+	// mu guards order tracking and serializes order entry with execution reports
+	mu     sync.Mutex
+	orders map[int64]*orderRef // engine order ID -> ref, removed once the order is closed
+	// pending holds disconnected cancel-on-disconnect connections still in their grace period
+	pending map[*connection]bool
+
+	// Trades and cancels are queued by the engine callbacks and reported under mu
+	eventsMu sync.Mutex
+	events   []engineEvent
+	signal   chan struct{}
+	done     chan struct{}
+}
+
+// NewGateway creates a binary order entry gateway. Logins draw from the query rate limiter and
+// order entry from the order rate limiter, shared with the REST API.
+func NewGateway(config Config, matchingEngine *engine.MatchingEngine, placeOrderService services.PlaceOrderService, apiKeyService services.APIKeyService, limiters *ratelimit.Limiters) *Gateway {
+	return &Gateway{
+		config:            config,
+		engine:            matchingEngine,
+		placeOrderService: placeOrderService,
+		apiKeyService:     apiKeyService,
+		limiters:          limiters,
+		orders:            make(map[int64]*orderRef),
+		pending:           make(map[*connection]bool),
+		signal:            make(chan struct{}, 1),
+		done:              make(chan struct{}),
+	}
+}
+
+// Start listens on the configured port and begins accepting connections
+func (g *Gateway) Start() error {
+	listener, err := net.Listen("tcp", ":"+g.config.Port)
+	if err != nil {
+		return err
+	}
+	g.listener = listener
+
+	go g.reportLoop()
+	go g.acceptLoop()
+
+	log.Printf("Starting binary order entry gateway on port %s", g.config.Port)
+	return nil
+}
+
+// Addr returns the listening address
+func (g *Gateway) Addr() net.Addr {
+	return g.listener.Addr()
+}
+
+// Stop stops accepting connections
+func (g *Gateway) Stop() error {
+	close(g.done)
+	return g.listener.Close()
+}
+
+// OnTrade queues a trade so fills on gateway orders are reported as executions
+func (g *Gateway) OnTrade(trade *models.Trade) {
+	g.queue(engineEvent{trade: trade})
+}
+
+// OnOrderCancelled queues a cancelled order so gateway orders cancelled outside their connection,
+// e.g. by a REST mass cancel, the dead man's switch or a delisting, are reported as executions
+func (g *Gateway) OnOrderCancelled(order *models.Order) {
+	g.queue(engineEvent{cancelled: order})
+}
+
+func (g *Gateway) queue(event engineEvent) {
+	g.eventsMu.Lock()
+	g.events = append(g.events, event)
+	g.eventsMu.Unlock()
+
+	select {
+	case g.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (g *Gateway) acceptLoop() {
+	for {
+		conn, err := g.listener.Accept()
+		if err != nil {
+			select {
+			case <-g.done:
+				return
+			default:
+			}
+			log.Printf("Binary gateway: accept failed: %v", err)
+			continue
+		}
+		go g.handleConnection(conn)
+	}
+}
+
+func (g *Gateway) reportLoop() {
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-g.signal:
+			g.mu.Lock()
+			g.processEvents()
+			g.mu.Unlock()
+		}
+	}
+}
+
+func (g *Gateway) handleConnection(conn net.Conn) {
+	c := &connection{conn: conn, ip: auth.RemoteIP(conn.RemoteAddr().String())}
+	defer g.disconnected(c)
+
+	reader := bufio.NewReader(conn)
+	buf := make([]byte, 0, 128)
+
+	for {
+		// Without a heartbeat interval the deadline is cleared
+		var deadline time.Time
+		if timeout := c.readTimeout(); timeout > 0 {
+			deadline = time.Now().Add(timeout)
+		}
+		conn.SetReadDeadline(deadline)
+
+		payload, err := binproto.ReadFrame(reader, buf)
+		if err != nil {
+			return
+		}
+		msg, err := binproto.Decode(payload)
+		if err != nil {
+			log.Printf("Binary gateway: closing %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+
+		switch m := msg.(type) {
+		case *binproto.Login:
+			if !g.handleLogin(c, m) {
+				return
+			}
+		case *binproto.NewOrder:
+			g.handleNewOrder(c, m)
+		case *binproto.CancelOrder:
+			g.handleCancelOrder(c, m)
+		case *binproto.AmendOrder:
+			g.handleAmendOrder(c, m)
+		case *binproto.SessionOptions:
+			g.handleSessionOptions(c, m)
+		case *binproto.Heartbeat:
+		default:
+			log.Printf("Binary gateway: closing %s: unexpected client message", conn.RemoteAddr())
+			return
+		}
+	}
+}
+
+// handleLogin authenticates the connection with a signed API key holding the trade permission. It
+// reports false when the login failed and the connection must be closed.
+func (g *Gateway) handleLogin(c *connection, m *binproto.Login) bool {
+	if c.userID != 0 {
+		c.send(&binproto.Reject{RequestType: binproto.TypeLogin, Code: binproto.CodeAlreadyLoggedIn})
+		return true
+	}
+
+	// Logins are limited per IP before the signature is checked
+	if !g.limiters.Queries.Take(ratelimit.IPKey(c.ip), 1).Allowed {
+		c.send(&binproto.Reject{RequestType: binproto.TypeLogin, Code: binproto.CodeRateLimited})
+		return false
+	}
+
+	credentials := auth.Credentials{
+		APIKey:    m.APIKey,
+		Timestamp: strconv.FormatInt(m.Timestamp, 10),
+		Nonce:     strconv.FormatUint(m.Nonce, 10),
+		Signature: m.Signature,
+	}
+	apiKey, err := g.apiKeyService.Authenticate(context.Background(), credentials, binproto.LoginMethod, binproto.LoginPath, nil, c.ip, auth.PermissionTrade)
+	if err != nil {
+		log.Printf("Binary gateway: login from %s rejected: %v", c.conn.RemoteAddr(), err)
+		c.send(&binproto.Reject{RequestType: binproto.TypeLogin, Code: errorCode(err)})
+		return false
+	}
+
+	c.userID = apiKey.UserID
+	c.send(&binproto.Ack{RequestType: binproto.TypeLogin})
+	return true
+}
+
+func (g *Gateway) handleSessionOptions(c *connection, m *binproto.SessionOptions) {
+	if c.userID == 0 {
+		c.send(&binproto.Reject{RequestType: binproto.TypeOptions, Code: binproto.CodeNotLoggedIn})
+		return
+	}
+
+	g.mu.Lock()
+	c.options = *m
+	if c.options.CancelOnDisconnect {
+		g.resume(c)
+	}
+	g.mu.Unlock()
+	c.send(&binproto.Ack{RequestType: binproto.TypeOptions})
+}
+
+// disconnected closes the connection and, with cancel on disconnect, cancels its open orders
+// once the grace period passes without the user logging in again
+func (g *Gateway) disconnected(c *connection) {
+	c.conn.Close()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c.userID == 0 || !c.options.CancelOnDisconnect {
+		return
+	}
+
+	grace := time.Duration(c.options.GracePeriodMs) * time.Millisecond
+	if grace == 0 {
+		g.cancelSession(c)
+		return
+	}
+	g.pending[c] = true
+	time.AfterFunc(grace, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.pending[c] {
+			delete(g.pending, c)
+			g.cancelSession(c)
+		}
+	})
+}
+
+// resume hands the orders of every connection of the same user still in its grace period to c,
+// which has just enabled cancel on disconnect, so the orders stay protected. Caller must hold g.mu.
+func (g *Gateway) resume(c *connection) {
+	resumed := 0
+	for previous := range g.pending {
+		if previous.userID != c.userID {
+			continue
+		}
+		delete(g.pending, previous)
+		resumed++
+		g.processEvents()
+		for _, ref := range g.orders {
+			if ref.conn == previous {
+				ref.conn = c
+			}
+		}
+	}
+	if resumed > 0 {
+		log.Printf("Binary gateway: user %d reconnected, open orders of %d sessions kept", c.userID, resumed)
+	}
+}
+
+// cancelSession cancels the open orders placed through a connection. Caller must hold g.mu.
+func (g *Gateway) cancelSession(c *connection) {
+	// Report fills that raced the disconnect first so refs are up to date
+	g.processEvents()
+
+	orderIDs := make([]int64, 0)
+	for orderID, ref := range g.orders {
+		if ref.conn == c && !ref.closed {
+			orderIDs = append(orderIDs, orderID)
+		}
+	}
+	if len(orderIDs) == 0 {
+		return
+	}
+
+	cancelled := g.engine.CancelOrders(c.userID, orderIDs)
+	for _, order := range cancelled {
+		g.closeOrder(order.ID)
+	}
+	log.Printf("Binary gateway: cancelled %d open orders of user %d on disconnect", len(cancelled), c.userID)
+}
+
+func (g *Gateway) handleNewOrder(c *connection, m *binproto.NewOrder) {
+	if c.userID == 0 {
+		c.send(&binproto.Reject{RequestType: binproto.TypeNewOrder, ClientOrderID: m.ClientOrderID, Code: binproto.CodeNotLoggedIn})
+		return
+	}
+
+	if !g.allowOrderEntry(c) {
+		c.send(&binproto.Reject{RequestType: binproto.TypeNewOrder, ClientOrderID: m.ClientOrderID, Code: binproto.CodeRateLimited})
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.processEvents()
+
+	ctx := context.Background()
+	side := fromSide(m.Side)
+	clientOrderID := engineClientOrderID(m.ClientOrderID)
+	if validationErrors := g.placeOrderService.ValidateRequest(ctx, c.userID, m.Pair, side, m.Price, m.Quantity, clientOrderID); len(validationErrors) > 0 {
+		c.send(&binproto.Reject{RequestType: binproto.TypeNewOrder, ClientOrderID: m.ClientOrderID, Code: binproto.ErrorCode(validationErrors[0].Code)})
+		return
+	}
+
+	order, _, err := g.placeOrderService.ProcessRequest(ctx, c.userID, m.Pair, side, m.Price, m.Quantity, clientOrderID)
+	if err != nil {
+		c.send(&binproto.Reject{RequestType: binproto.TypeNewOrder, ClientOrderID: m.ClientOrderID, Code: errorCode(err)})
+		return
+	}
+
+	g.orders[order.ID] = &orderRef{conn: c, clientOrderID: m.ClientOrderID, quantity: m.Quantity}
+
+	// Fills from matching are queued by OnTrade and reported after this acknowledgement
+	c.send(&binproto.Ack{
+		RequestType:   binproto.TypeNewOrder,
+		ClientOrderID: m.ClientOrderID,
+		OrderID:       order.ID,
+		Status:        binproto.StatusOpen,
+		Price:         order.Price,
+		Quantity:      m.Quantity,
+	})
+}
+
+func (g *Gateway) handleCancelOrder(c *connection, m *binproto.CancelOrder) {
+	if c.userID == 0 {
+		c.send(&binproto.Reject{RequestType: binproto.TypeCancelOrder, ClientOrderID: m.ClientOrderID, Code: binproto.CodeNotLoggedIn})
+		return
+	}
+
+	if !g.allowOrderEntry(c) {
+		c.send(&binproto.Reject{RequestType: binproto.TypeCancelOrder, ClientOrderID: m.ClientOrderID, Code: binproto.CodeRateLimited})
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.processEvents()
+
+	order, err := g.engine.CancelOrder(c.userID, m.OrderID)
+	if err != nil {
+		c.send(&binproto.Reject{RequestType: binproto.TypeCancelOrder, ClientOrderID: m.ClientOrderID, Code: errorCode(err)})
+		return
+	}
+
+	ack := &binproto.Ack{
+		RequestType:   binproto.TypeCancelOrder,
+		ClientOrderID: m.ClientOrderID,
+		OrderID:       order.ID,
+		Status:        binproto.StatusCancelled,
+		Price:         order.Price,
+		Quantity:      order.Quantity,
+		Filled:        order.Filled,
+	}
+	if ref, exists := g.orders[order.ID]; exists {
+		g.closeOrder(order.ID)
+		ack.Quantity = ref.quantity
+		ack.Filled = ref.filled
+	}
+	c.send(ack)
+}
+
+func (g *Gateway) handleAmendOrder(c *connection, m *binproto.AmendOrder) {
+	if c.userID == 0 {
+		c.send(&binproto.Reject{RequestType: binproto.TypeAmendOrder, ClientOrderID: m.ClientOrderID, Code: binproto.CodeNotLoggedIn})
+		return
+	}
+
+	if !g.allowOrderEntry(c) {
+		c.send(&binproto.Reject{RequestType: binproto.TypeAmendOrder, ClientOrderID: m.ClientOrderID, Code: binproto.CodeRateLimited})
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.processEvents()
+
+	original, exists := g.engine.GetOrder(m.OrderID)
+	if !exists || original.UserID != c.userID {
+		c.send(&binproto.Reject{RequestType: binproto.TypeAmendOrder, ClientOrderID: m.ClientOrderID, Code: binproto.CodeOrderNotFound})
+		return
+	}
+
+	ctx := context.Background()
+	if validationErrors := g.placeOrderService.ValidateRequest(ctx, c.userID, original.Pair, original.Side, m.Price, m.Quantity, ""); len(validationErrors) > 0 {
+		c.send(&binproto.Reject{RequestType: binproto.TypeAmendOrder, ClientOrderID: m.ClientOrderID, Code: binproto.ErrorCode(validationErrors[0].Code)})
+		return
+	}
+
+	// Orders amended before carry their cumulative fills in the ref
+	filled := original.Filled
+	if ref, exists := g.orders[m.OrderID]; exists {
+		filled = ref.filled
+	}
+
+	order, _, err := g.engine.ReplaceOrder(c.userID, m.OrderID, m.Price, m.Quantity)
+	if err != nil {
+		c.send(&binproto.Reject{RequestType: binproto.TypeAmendOrder, ClientOrderID: m.ClientOrderID, Code: errorCode(err)})
+		return
+	}
+
+	g.closeOrder(m.OrderID)
+	g.orders[order.ID] = &orderRef{conn: c, clientOrderID: m.ClientOrderID, quantity: m.Quantity, filled: filled}
+
+	c.send(&binproto.Ack{
+		RequestType:   binproto.TypeAmendOrder,
+		ClientOrderID: m.ClientOrderID,
+		OrderID:       order.ID,
+		OrigOrderID:   m.OrderID,
+		Status:        statusFor(m.Quantity, filled),
+		Price:         order.Price,
+		Quantity:      m.Quantity,
+		Filled:        filled,
+	})
+}
+
+// allowOrderEntry charges an order entry request to the user's and the connection IP's order buckets
+func (g *Gateway) allowOrderEntry(c *connection) bool {
+	return g.limiters.Orders.TakeAll([]string{ratelimit.UserKey(c.userID), ratelimit.IPKey(c.ip)}, 1).Allowed
+}
+
+// closeOrder stops tracking a filled, cancelled or amended order. Caller must hold g.mu.
+func (g *Gateway) closeOrder(orderID int64) {
+	if ref, exists := g.orders[orderID]; exists {
+		ref.closed = true
+		delete(g.orders, orderID)
+	}
+}
+
+// processEvents reports queued fills and cancels on gateway orders. Cancels the gateway requested
+// itself were already acknowledged and find their order closed. Caller must hold g.mu.
+func (g *Gateway) processEvents() {
+	g.eventsMu.Lock()
+	events := g.events
+	g.events = nil
+	g.eventsMu.Unlock()
+
+	for _, event := range events {
+		if event.cancelled != nil {
+			g.reportCancelled(event.cancelled)
+			continue
+		}
+
+		trade := event.trade
+		for _, orderID := range []int64{trade.BuyOrderID, trade.SellOrderID} {
+			ref, exists := g.orders[orderID]
+			if !exists || ref.closed {
+				continue
+			}
+
+			ref.filled += trade.Quantity
+			status := statusFor(ref.quantity, ref.filled)
+			if status == binproto.StatusFilled {
+				g.closeOrder(orderID)
+			}
+
+			ref.conn.send(&binproto.Execution{
+				ClientOrderID: ref.clientOrderID,
+				OrderID:       orderID,
+				TradeID:       trade.ID,
+				LastPrice:     trade.Price,
+				LastQuantity:  trade.Quantity,
+				Filled:        ref.filled,
+				Remaining:     ref.quantity - ref.filled,
+				Status:        status,
+			})
+		}
+	}
+}
+
+// reportCancelled reports an order cancelled outside its connection as an execution without a
+// trade. Caller must hold g.mu.
+func (g *Gateway) reportCancelled(order *models.Order) {
+	ref, exists := g.orders[order.ID]
+	if !exists {
+		return
+	}
+	g.closeOrder(order.ID)
+	ref.conn.send(&binproto.Execution{
+		ClientOrderID: ref.clientOrderID,
+		OrderID:       order.ID,
+		Filled:        ref.filled,
+		Status:        binproto.StatusCancelled,
+	})
+}
+
+func statusFor(quantity float64, filled float64) byte {
+	switch {
+	case filled >= quantity:
+		return binproto.StatusFilled
+	case filled > 0:
+		return binproto.StatusPartial
+	default:
+		return binproto.StatusOpen
+	}
+}
+
+func fromSide(side byte) string {
+	switch side {
+	case binproto.SideBuy:
+		return "buy"
+	case binproto.SideSell:
+		return "sell"
+	}
+	return ""
+}
+
+// engineClientOrderID returns the client order ID of a new order as the engine stores it, in
+// decimal. ID 0 places an untagged order.
+func engineClientOrderID(clientOrderID uint64) string {
+	if clientOrderID == 0 {
+		return ""
+	}
+	return strconv.FormatUint(clientOrderID, 10)
+}
+
+func errorCode(err error) uint16 {
+	var serverErr *apperrors.ServerError
+	if errors.As(err, &serverErr) {
+		return binproto.ErrorCode(serverErr.Code)
+	}
+	return binproto.CodeUnknown
+}
@@ -0,0 +1,374 @@
+package bingateway
+
+import (
+	"context"
+	"errors"
+	"math"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/ratelimit"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"mini-crypto-exchange/pkg/binproto"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testPair = "BTC/USDT"
+
+// testPlaceOrderService places orders straight into the engine, leaving validation to the engine
+type testPlaceOrderService struct {
+	engine *engine.MatchingEngine
+}
+
+func (s *testPlaceOrderService) ValidateRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) []*util.Error {
+	return nil
+}
+
+func (s *testPlaceOrderService) ProcessRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) (*models.Order, []*models.Trade, error) {
+	return s.engine.PlaceOrderWithClientID(userID, pair, side, price, quantity, clientOrderID)
+}
+
+// The API key service is a process-wide singleton, so every test shares one key store
+var (
+	testKeysOnce   sync.Once
+	testKeys       *auth.KeyStore
+	testKeyService services.APIKeyService
+)
+
+func initTestKeys() {
+	testKeysOnce.Do(func() {
+		testKeys = auth.NewKeyStore()
+		testKeyService = services.InitAPIKeyService(auth.NewAuthenticator(testKeys), auth.Config{}, &util.RouterConfig{})
+	})
+}
+
+// issueTestKey issues a key for userID in the shared key store
+func issueTestKey(t testing.TB, userID int64, permissions ...auth.Permission) *auth.APIKey {
+	t.Helper()
+	initTestKeys()
+
+	apiKey, err := testKeys.Issue(userID, permissions, nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	return apiKey
+}
+
+// unlimited is a rate limit no test reaches
+var unlimited = ratelimit.Config{OrderBurst: math.MaxInt32, OrderRate: math.MaxInt32, QueryBurst: math.MaxInt32, QueryRate: math.MaxInt32}
+
+// newTestGateway starts a gateway on a free port
+func newTestGateway(t testing.TB) *Gateway {
+	t.Helper()
+	return newLimitedTestGateway(t, unlimited)
+}
+
+// newLimitedTestGateway starts a gateway on a free port with the given rate limits
+func newLimitedTestGateway(t testing.TB, limits ratelimit.Config) *Gateway {
+	t.Helper()
+	initTestKeys()
+
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+
+	gateway := NewGateway(Config{Port: "0"}, matchingEngine, &testPlaceOrderService{engine: matchingEngine}, testKeyService, ratelimit.NewLimiters(limits))
+	matchingEngine.AddTradeListener(gateway)
+	matchingEngine.AddOrderCancelListener(gateway)
+	if err := gateway.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { gateway.Stop() })
+
+	return gateway
+}
+
+// login sends a Login frame on a raw connection and returns the response
+func login(t *testing.T, gateway *Gateway, msg *binproto.Login) interface{} {
+	t.Helper()
+	conn, err := net.Dial("tcp", gateway.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	frame, err := binproto.Encode(msg)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	payload, err := binproto.ReadFrame(conn, nil)
+	if err != nil {
+		t.Fatalf("ReadFrame: %v", err)
+	}
+	resp, err := binproto.Decode(payload)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return resp
+}
+
+func signedLogin(apiKey *auth.APIKey, nonce uint64) *binproto.Login {
+	timestamp := time.Now().UnixMilli()
+	return &binproto.Login{
+		APIKey:    apiKey.Key,
+		Timestamp: timestamp,
+		Nonce:     nonce,
+		Signature: binproto.SignLogin(apiKey.Secret, timestamp, nonce),
+	}
+}
+
+func expectReject(t *testing.T, resp interface{}, code uint16) {
+	t.Helper()
+	reject, ok := resp.(*binproto.Reject)
+	if !ok {
+		t.Fatalf("got %T %+v, want reject %s", resp, resp, binproto.CodeName(code))
+	}
+	if reject.Code != code {
+		t.Fatalf("got reject %s, want %s", binproto.CodeName(reject.Code), binproto.CodeName(code))
+	}
+}
+
+func TestLogin(t *testing.T) {
+	gateway := newTestGateway(t)
+	apiKey := issueTestKey(t, 11, auth.PermissionRead, auth.PermissionTrade)
+
+	client, err := binproto.Dial(gateway.Addr().String(), apiKey.Key, apiKey.Secret)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	ack, err := client.PlaceOrder(testPair, binproto.SideBuy, 100, 1)
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if ack.OrderID == 0 || ack.Status != binproto.StatusOpen {
+		t.Fatalf("unexpected ack %+v", ack)
+	}
+}
+
+func TestLoginWithUserIDOnlyIsRejected(t *testing.T) {
+	gateway := newTestGateway(t)
+
+	resp := login(t, gateway, &binproto.Login{Timestamp: time.Now().UnixMilli()})
+	expectReject(t, resp, binproto.CodeMissingCredentials)
+}
+
+func TestLoginWithWrongSecretIsRejected(t *testing.T) {
+	gateway := newTestGateway(t)
+	apiKey := issueTestKey(t, 12, auth.PermissionTrade)
+
+	_, err := binproto.Dial(gateway.Addr().String(), apiKey.Key, "not-the-secret")
+	var reject *binproto.RejectError
+	if !errors.As(err, &reject) || reject.Code != binproto.CodeInvalidSignature {
+		t.Fatalf("got %v, want INVALID_SIGNATURE", err)
+	}
+}
+
+func TestLoginNonceReplayIsRejected(t *testing.T) {
+	gateway := newTestGateway(t)
+	apiKey := issueTestKey(t, 13, auth.PermissionTrade)
+
+	msg := signedLogin(apiKey, 7)
+	if resp := login(t, gateway, msg); resp.(*binproto.Ack).RequestType != binproto.TypeLogin {
+		t.Fatalf("first login: got %+v", resp)
+	}
+	expectReject(t, login(t, gateway, msg), binproto.CodeNonceReused)
+}
+
+func TestLoginWithoutTradePermissionIsRejected(t *testing.T) {
+	gateway := newTestGateway(t)
+	apiKey := issueTestKey(t, 14, auth.PermissionRead)
+
+	expectReject(t, login(t, gateway, signedLogin(apiKey, 1)), binproto.CodePermissionDenied)
+}
+
+func TestLoginWithExpiredTimestampIsRejected(t *testing.T) {
+	gateway := newTestGateway(t)
+	apiKey := issueTestKey(t, 15, auth.PermissionTrade)
+
+	timestamp := time.Now().Add(-time.Hour).UnixMilli()
+	msg := &binproto.Login{APIKey: apiKey.Key, Timestamp: timestamp, Nonce: 1, Signature: binproto.SignLogin(apiKey.Secret, timestamp, 1)}
+	expectReject(t, login(t, gateway, msg), binproto.CodeRequestExpired)
+}
+
+func TestOrderEntryIsRateLimited(t *testing.T) {
+	gateway := newLimitedTestGateway(t, ratelimit.Config{OrderBurst: 2, OrderRate: 0.001, QueryBurst: 10, QueryRate: 10})
+	apiKey := issueTestKey(t, 16, auth.PermissionTrade)
+
+	client, err := binproto.Dial(gateway.Addr().String(), apiKey.Key, apiKey.Secret)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	ack, err := client.PlaceOrder(testPair, binproto.SideBuy, 100, 1)
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if _, err := client.CancelOrder(ack.OrderID); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	_, err = client.PlaceOrder(testPair, binproto.SideBuy, 100, 1)
+	var reject *binproto.RejectError
+	if !errors.As(err, &reject) || reject.Code != binproto.CodeRateLimited {
+		t.Fatalf("third request: got %v, want RATE_LIMITED", err)
+	}
+}
+
+func TestNewOrderClientOrderIDReachesEngine(t *testing.T) {
+	gateway := newTestGateway(t)
+	apiKey := issueTestKey(t, 17, auth.PermissionTrade)
+
+	client, err := binproto.Dial(gateway.Addr().String(), apiKey.Key, apiKey.Secret)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	ack, err := client.PlaceOrder(testPair, binproto.SideBuy, 100, 1)
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	order, exists := gateway.engine.GetOrderByClientID(apiKey.UserID, strconv.FormatUint(ack.ClientOrderID, 10))
+	if !exists || order.ID != ack.OrderID {
+		t.Fatalf("order %d not found by client order ID %d", ack.OrderID, ack.ClientOrderID)
+	}
+
+	// A second connection continues with new client order IDs
+	again, err := binproto.Dial(gateway.Addr().String(), apiKey.Key, apiKey.Secret)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer again.Close()
+	if _, err := again.PlaceOrder(testPair, binproto.SideBuy, 100, 1); err != nil {
+		t.Fatalf("PlaceOrder on a new connection: %v", err)
+	}
+}
+
+func BenchmarkPlaceOrder(b *testing.B) {
+	gateway := newTestGateway(b)
+	apiKey := issueTestKey(b, 21, auth.PermissionTrade)
+
+	client, err := binproto.Dial(gateway.Addr().String(), apiKey.Key, apiKey.Secret)
+	if err != nil {
+		b.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Alternate sides at non-crossing prices so every order rests
+		side, price := binproto.SideBuy, float64(100+i%50)
+		if i%2 == 1 {
+			side, price = binproto.SideSell, float64(1000+i%50)
+		}
+		if _, err := client.PlaceOrder(testPair, side, price, 1); err != nil {
+			b.Fatalf("PlaceOrder: %v", err)
+		}
+	}
+}
+
+// placeWithCancelOnDisconnect opens a connection with cancel on disconnect and places a resting order
+func placeWithCancelOnDisconnect(t *testing.T, gateway *Gateway, apiKey *auth.APIKey, price float64) (*binproto.Client, int64) {
+	t.Helper()
+	client, err := binproto.Dial(gateway.Addr().String(), apiKey.Key, apiKey.Secret)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	if err := client.SetSessionOptions(binproto.SessionOptions{CancelOnDisconnect: true, GracePeriodMs: 200}); err != nil {
+		t.Fatalf("SetSessionOptions: %v", err)
+	}
+	ack, err := client.PlaceOrder(testPair, binproto.SideBuy, price, 1)
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	return client, ack.OrderID
+}
+
+// waitForStatus waits up to a second for an engine order to reach status
+func waitForStatus(t *testing.T, gateway *Gateway, orderID int64, status string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		order, _ := gateway.engine.GetOrder(orderID)
+		if order.Status == status {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("order %d is %s, want %s", orderID, order.Status, status)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCancelOnDisconnectCancelsEverySessionOfUser(t *testing.T) {
+	gateway := newTestGateway(t)
+	apiKey := issueTestKey(t, 23, auth.PermissionTrade)
+
+	first, firstOrder := placeWithCancelOnDisconnect(t, gateway, apiKey, 100)
+	second, secondOrder := placeWithCancelOnDisconnect(t, gateway, apiKey, 99)
+	first.Close()
+	second.Close()
+
+	// Logging in again without cancel on disconnect does not take the orders over
+	again, err := binproto.Dial(gateway.Addr().String(), apiKey.Key, apiKey.Secret)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer again.Close()
+
+	waitForStatus(t, gateway, firstOrder, "cancelled")
+	waitForStatus(t, gateway, secondOrder, "cancelled")
+}
+
+func TestCancelOnDisconnectResumesEverySessionOfUser(t *testing.T) {
+	gateway := newTestGateway(t)
+	apiKey := issueTestKey(t, 24, auth.PermissionTrade)
+
+	first, firstOrder := placeWithCancelOnDisconnect(t, gateway, apiKey, 100)
+	second, secondOrder := placeWithCancelOnDisconnect(t, gateway, apiKey, 99)
+	first.Close()
+	second.Close()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		gateway.mu.Lock()
+		pending := len(gateway.pending)
+		gateway.mu.Unlock()
+		if pending == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d sessions in their grace period, want 2", pending)
+		}
+	}
+
+	again, err := binproto.Dial(gateway.Addr().String(), apiKey.Key, apiKey.Secret)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	if err := again.SetSessionOptions(binproto.SessionOptions{CancelOnDisconnect: true}); err != nil {
+		t.Fatalf("SetSessionOptions: %v", err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	for _, orderID := range []int64{firstOrder, secondOrder} {
+		if order, _ := gateway.engine.GetOrder(orderID); order.Status != "open" {
+			t.Fatalf("order %d is %s after the grace period, want open", orderID, order.Status)
+		}
+	}
+
+	// The new connection now protects both orders
+	again.Close()
+	waitForStatus(t, gateway, firstOrder, "cancelled")
+	waitForStatus(t, gateway, secondOrder, "cancelled")
+}
@@ -0,0 +1,271 @@
+package engine
+
+import (
+	"math"
+	"math/bits"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"strconv"
+	"strings"
+)
+
+// Allocation algorithms
+const (
+	AllocationFIFO       = "fifo"         // earliest order first
+	AllocationProRata    = "pro_rata"     // in proportion to resting quantity
+	AllocationProRataTop = "pro_rata_top" // top order and lead market makers first, then pro-rata
+)
+
+// MaxDecimals is the most decimal places the engine keeps in prices and quantities. Fills are
+// counted in steps of 0.00000001, so assets with more decimals, such as ETH's 18, trade in
+// multiples of that step.
+const MaxDecimals = 8
+
+// stepsPerUnit is the number of fill steps in one unit of quantity
+const stepsPerUnit = 1e8
+
+// Precision is the number of decimal places a pair accepts in prices and quantities. A pair's
+// quantity lot, the smallest amount an allocation hands out, is 10^-QuantityDecimals.
+type Precision struct {
+	PriceDecimals    int `json:"price_decimals"`
+	QuantityDecimals int `json:"quantity_decimals"`
+}
+
+// DefaultPrecision is the precision of pairs created without one
+var DefaultPrecision = Precision{PriceDecimals: MaxDecimals, QuantityDecimals: MaxDecimals}
+
+// NewPrecision returns the precision of a pair quoted with priceDecimals whose base asset has
+// quantityDecimals, each clamped to 0-MaxDecimals
+func NewPrecision(priceDecimals int, quantityDecimals int) Precision {
+	return Precision{
+		PriceDecimals:    min(max(priceDecimals, 0), MaxDecimals),
+		QuantityDecimals: min(max(quantityDecimals, 0), MaxDecimals),
+	}
+}
+
+// LotsPerUnit returns the number of quantity lots in one unit of the base asset
+func (p Precision) LotsPerUnit() float64 {
+	return math.Pow10(p.QuantityDecimals)
+}
+
+// Check rejects a price or quantity with more decimal places than the pair accepts
+func (p Precision) Check(price float64, quantity float64) error {
+	if !HasDecimals(price, p.PriceDecimals) {
+		return apperrors.ErrInvalidPriceIncrement
+	}
+	if !HasDecimals(quantity, p.QuantityDecimals) {
+		return apperrors.ErrInvalidQuantityIncrement
+	}
+	return nil
+}
+
+// HasDecimals reports whether amount has at most decimals decimal places and fits the engine's
+// step counts
+func HasDecimals(amount float64, decimals int) bool {
+	if !(amount > 0 && amount < math.MaxInt64/stepsPerUnit) {
+		return false
+	}
+	digits := strconv.FormatFloat(amount, 'f', -1, 64)
+	if point := strings.IndexByte(digits, '.'); point >= 0 {
+		return len(digits)-point-1 <= decimals
+	}
+	return true
+}
+
+// Allocator splits a quantity among the resting orders at one price level.
+//
+// level is in time priority and quantity never exceeds its total remaining quantity. lotsPerUnit
+// is the number of lots in one unit of the pair's base asset. The result holds one allocation of
+// whole lots per order, each at most that order's remaining quantity, summing to quantity.
+type Allocator interface {
+	Allocate(quantity float64, level []*models.Order, lotsPerUnit float64) []float64
+}
+
+// AllocationConfig selects a pair's allocation algorithm and its parameters
+type AllocationConfig struct {
+	Algorithm string `json:"allocation,omitempty"`
+	// TopOrderPercent is the share of the quantity offered first to the level's earliest order
+	TopOrderPercent float64 `json:"top_order_percent,omitempty"`
+	// LMMPercent is the share of what is left offered next to orders of the lead market makers
+	LMMPercent float64 `json:"lmm_percent,omitempty"`
+	LMMUserIDs []int64 `json:"lmm_user_ids,omitempty"`
+}
+
+// Validate rejects unknown algorithms and percentages outside 0-100
+func (c AllocationConfig) Validate() error {
+	switch c.Algorithm {
+	case AllocationFIFO, AllocationProRata:
+		if c.TopOrderPercent != 0 || c.LMMPercent != 0 || len(c.LMMUserIDs) > 0 {
+			return apperrors.ErrInvalidAllocation
+		}
+	case AllocationProRataTop:
+		if c.TopOrderPercent < 0 || c.TopOrderPercent > 100 || c.LMMPercent < 0 || c.LMMPercent > 100 {
+			return apperrors.ErrInvalidAllocation
+		}
+	default:
+		return apperrors.ErrInvalidAllocation
+	}
+	return nil
+}
+
+// NewAllocator returns the allocator for a validated config
+func NewAllocator(config AllocationConfig) Allocator {
+	switch config.Algorithm {
+	case AllocationProRata:
+		return ProRataAllocator{}
+	case AllocationProRataTop:
+		lmm := make(map[int64]bool, len(config.LMMUserIDs))
+		for _, userID := range config.LMMUserIDs {
+			lmm[userID] = true
+		}
+		return TopOrderProRataAllocator{TopOrderPercent: config.TopOrderPercent, LMMPercent: config.LMMPercent, LMMUsers: lmm}
+	}
+	return FIFOAllocator{}
+}
+
+// FIFOAllocator fills orders completely in time priority
+type FIFOAllocator struct{}
+
+// Allocate implements Allocator
+func (FIFOAllocator) Allocate(quantity float64, level []*models.Order, lotsPerUnit float64) []float64 {
+	lots, capacity := toLots(quantity, level, lotsPerUnit)
+	allocated := make([]int64, len(level))
+	for i := range level {
+		if lots <= 0 {
+			break
+		}
+		allocated[i] = min(lots, capacity[i])
+		lots -= allocated[i]
+	}
+	return fromLots(allocated, lotsPerUnit)
+}
+
+// ProRataAllocator gives each order a share of the quantity proportional to its remaining
+// quantity. Shares are rounded down to whole lots and the leftover lots go one at a time to
+// orders in time priority.
+type ProRataAllocator struct{}
+
+// Allocate implements Allocator
+func (ProRataAllocator) Allocate(quantity float64, level []*models.Order, lotsPerUnit float64) []float64 {
+	lots, capacity := toLots(quantity, level, lotsPerUnit)
+	allocated := make([]int64, len(level))
+	lots -= allocateProRata(lots, capacity, allocated, nil)
+	allocateFIFO(lots, capacity, allocated)
+	return fromLots(allocated, lotsPerUnit)
+}
+
+// TopOrderProRataAllocator first offers TopOrderPercent of the quantity to the earliest order at
+// the level, then LMMPercent of the rest pro-rata to orders of LMMUsers, then everything left
+// pro-rata to all orders. Every step rounds down to whole lots and the leftover lots go one at a
+// time to orders in time priority.
+type TopOrderProRataAllocator struct {
+	TopOrderPercent float64
+	LMMPercent      float64
+	LMMUsers        map[int64]bool
+}
+
+// Allocate implements Allocator
+func (a TopOrderProRataAllocator) Allocate(quantity float64, level []*models.Order, lotsPerUnit float64) []float64 {
+	lots, capacity := toLots(quantity, level, lotsPerUnit)
+	allocated := make([]int64, len(level))
+
+	if len(level) > 0 {
+		top := int64(math.Floor(float64(lots) * a.TopOrderPercent / 100))
+		if top > capacity[0] {
+			top = capacity[0]
+		}
+		allocated[0] = top
+		lots -= top
+	}
+
+	if len(a.LMMUsers) > 0 {
+		lmm := int64(math.Floor(float64(lots) * a.LMMPercent / 100))
+		lots -= allocateProRata(lmm, capacity, allocated, func(order int) bool {
+			return a.LMMUsers[level[order].UserID]
+		})
+	}
+
+	lots -= allocateProRata(lots, capacity, allocated, nil)
+	allocateFIFO(lots, capacity, allocated)
+	return fromLots(allocated, lotsPerUnit)
+}
+
+// stepsOf converts a quantity to the nearest whole number of fill steps
+func stepsOf(quantity float64) int64 {
+	return int64(math.Round(quantity * stepsPerUnit))
+}
+
+// quantityOf converts a number of fill steps back to a quantity
+func quantityOf(steps int64) float64 {
+	return float64(steps) / stepsPerUnit
+}
+
+// toLots converts the quantity and each order's remaining quantity to whole lots
+func toLots(quantity float64, level []*models.Order, lotsPerUnit float64) (int64, []int64) {
+	capacity := make([]int64, len(level))
+	for i, order := range level {
+		capacity[i] = int64(math.Round(order.Remaining() * lotsPerUnit))
+	}
+	return int64(math.Round(quantity * lotsPerUnit)), capacity
+}
+
+// fromLots converts allocations back to quantities
+func fromLots(allocated []int64, lotsPerUnit float64) []float64 {
+	allocations := make([]float64, len(allocated))
+	for i, lots := range allocated {
+		allocations[i] = float64(lots) / lotsPerUnit
+	}
+	return allocations
+}
+
+// allocateProRata splits lots among eligible orders in proportion to their unallocated capacity,
+// rounding down, and returns the number of lots allocated. A nil eligible includes every order.
+func allocateProRata(lots int64, capacity []int64, allocated []int64, eligible func(order int) bool) int64 {
+	var total int64
+	for i := range capacity {
+		if eligible == nil || eligible(i) {
+			total += capacity[i] - allocated[i]
+		}
+	}
+	if total <= 0 || lots <= 0 {
+		return 0
+	}
+	if lots > total {
+		lots = total
+	}
+
+	var given int64
+	for i := range capacity {
+		if eligible != nil && !eligible(i) {
+			continue
+		}
+		available := capacity[i] - allocated[i]
+		// available*lots can overflow 64 bits, so multiply into 128 and divide exactly
+		hi, lo := bits.Mul64(uint64(available), uint64(lots))
+		quotient, _ := bits.Div64(hi, lo, uint64(total))
+		share := int64(quotient)
+		allocated[i] += share
+		given += share
+	}
+	return given
+}
+
+// allocateFIFO hands out leftover lots one at a time to orders with capacity in time priority
+func allocateFIFO(lots int64, capacity []int64, allocated []int64) {
+	for lots > 0 {
+		progressed := false
+		for i := range capacity {
+			if lots == 0 {
+				break
+			}
+			if allocated[i] < capacity[i] {
+				allocated[i]++
+				lots--
+				progressed = true
+			}
+		}
+		if !progressed {
+			return
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"testing"
+)
+
+func newAllocationTestEngine(t *testing.T, config AllocationConfig, precision Precision) *MatchingEngine {
+	t.Helper()
+	me := NewMatchingEngine()
+	if _, err := me.CreatePairWithMatching(models.TradingPair{Base: "BTC", Quote: "USDT"}, MatchingConfig{AllocationConfig: config}, precision); err != nil {
+		t.Fatalf("CreatePairWithMatching: %v", err)
+	}
+	return me
+}
+
+// expectFills checks the quantity each resting order received, in the order they were placed
+func expectFills(t *testing.T, resting []*models.Order, want ...float64) {
+	t.Helper()
+	for i, order := range resting {
+		if order.Filled != want[i] {
+			t.Errorf("order %d of user %d filled %v, want %v", i, order.UserID, order.Filled, want[i])
+		}
+	}
+}
+
+func TestFIFOAllocationFillsInTimePriority(t *testing.T) {
+	me := newAllocationTestEngine(t, AllocationConfig{}, DefaultPrecision)
+	first, _ := mustPlace(t, me, 1, "sell", 100, 3)
+	second, _ := mustPlace(t, me, 2, "sell", 100, 1)
+
+	mustPlace(t, me, 9, "buy", 100, 2)
+	expectFills(t, []*models.Order{first, second}, 2, 0)
+}
+
+func TestProRataAllocationSplitsByRestingQuantity(t *testing.T) {
+	me := newAllocationTestEngine(t, AllocationConfig{Algorithm: AllocationProRata}, DefaultPrecision)
+	first, _ := mustPlace(t, me, 1, "sell", 100, 3)
+	second, _ := mustPlace(t, me, 2, "sell", 100, 1)
+
+	mustPlace(t, me, 9, "buy", 100, 2)
+	expectFills(t, []*models.Order{first, second}, 1.5, 0.5)
+}
+
+func TestTopOrderProRataAllocationServesTopOrderAndLMMFirst(t *testing.T) {
+	me := newAllocationTestEngine(t, AllocationConfig{
+		Algorithm:       AllocationProRataTop,
+		TopOrderPercent: 50,
+		LMMPercent:      50,
+		LMMUserIDs:      []int64{3},
+	}, DefaultPrecision)
+	top, _ := mustPlace(t, me, 1, "sell", 100, 2)
+	other, _ := mustPlace(t, me, 2, "sell", 100, 2)
+	lmm, _ := mustPlace(t, me, 3, "sell", 100, 2)
+
+	// 2 to the top order, 1 of the remaining 2 to the LMM, the last 1 pro-rata to the 2 and 1
+	// still resting with the leftover lot going to the earlier order
+	mustPlace(t, me, 9, "buy", 100, 4)
+	expectFills(t, []*models.Order{top, other, lmm}, 2, 0.66666667, 1.33333333)
+}
+
+func TestProRataFillsAreBackedByTrades(t *testing.T) {
+	me := newAllocationTestEngine(t, AllocationConfig{Algorithm: AllocationProRata}, DefaultPrecision)
+	resting := make([]*models.Order, 3)
+	for i := range resting {
+		resting[i], _ = mustPlace(t, me, int64(i+1), "sell", 100, 1)
+	}
+
+	// Thirds do not divide into whole lots, so repeated small buys leave uneven remainders
+	traded := make(map[int64]int64)
+	for _, quantity := range []float64{1, 0.1, 0.7, 1.2} {
+		_, trades := mustPlace(t, me, 9, "buy", 100, quantity)
+		for _, trade := range trades {
+			traded[trade.SellOrderID] += stepsOf(trade.Quantity)
+		}
+	}
+
+	for _, order := range resting {
+		if order.Status != "filled" || order.Filled != order.Quantity {
+			t.Errorf("order %d: status %s filled %v, want filled %v", order.ID, order.Status, order.Filled, order.Quantity)
+		}
+		if traded[order.ID] != stepsOf(order.Quantity) {
+			t.Errorf("order %d: trades hold %d steps, want %d", order.ID, traded[order.ID], stepsOf(order.Quantity))
+		}
+	}
+}
+
+func TestPlaceOrderRejectsPartialLots(t *testing.T) {
+	me := newTestEngine(t)
+
+	if _, _, err := me.PlaceOrder(1, testPair, "buy", 100.000000001, 1); err != apperrors.ErrInvalidPriceIncrement {
+		t.Fatalf("price: got %v, want %v", err, apperrors.ErrInvalidPriceIncrement)
+	}
+	if _, _, err := me.PlaceOrder(1, testPair, "buy", 100, 0.123456789); err != apperrors.ErrInvalidQuantityIncrement {
+		t.Fatalf("quantity: got %v, want %v", err, apperrors.ErrInvalidQuantityIncrement)
+	}
+	mustPlace(t, me, 1, "buy", 100.00000001, 0.12345678)
+}
+
+func TestPlaceOrderRejectsDecimalsBeyondPairPrecision(t *testing.T) {
+	me := newAllocationTestEngine(t, AllocationConfig{}, NewPrecision(2, 18))
+	if precision, _ := me.GetPairPrecision(testPair); precision != (Precision{PriceDecimals: 2, QuantityDecimals: MaxDecimals}) {
+		t.Fatalf("got precision %+v, want 2 price and %d quantity decimals", precision, MaxDecimals)
+	}
+
+	if _, _, err := me.PlaceOrder(1, testPair, "buy", 100.001, 1); err != apperrors.ErrInvalidPriceIncrement {
+		t.Fatalf("price: got %v, want %v", err, apperrors.ErrInvalidPriceIncrement)
+	}
+	order, _ := mustPlace(t, me, 1, "buy", 100.01, 1)
+	if _, _, err := me.ReplaceOrder(1, order.ID, 100.015, 1); err != apperrors.ErrInvalidPriceIncrement {
+		t.Fatalf("replace: got %v, want %v", err, apperrors.ErrInvalidPriceIncrement)
+	}
+}
+
+func TestProRataAllocatesWholeLotsOfPairPrecision(t *testing.T) {
+	me := newAllocationTestEngine(t, AllocationConfig{Algorithm: AllocationProRata}, NewPrecision(2, 2))
+	first, _ := mustPlace(t, me, 1, "sell", 100, 1)
+	second, _ := mustPlace(t, me, 2, "sell", 100, 1)
+	third, _ := mustPlace(t, me, 3, "sell", 100, 1)
+
+	// A third of 0.1 is 3 lots of 0.01 each and the leftover lot goes to the earliest order
+	mustPlace(t, me, 9, "buy", 100, 0.1)
+	expectFills(t, []*models.Order{first, second, third}, 0.04, 0.03, 0.03)
+}
+
+func TestBatchPairsRejectAllocation(t *testing.T) {
+	me := NewMatchingEngine()
+	pair := models.TradingPair{Base: "BTC", Quote: "USDT"}
+
+	config := MatchingConfig{Mode: MatchingModeBatch, AllocationConfig: AllocationConfig{Algorithm: AllocationProRata}}
+	if _, err := me.CreatePairWithMatching(pair, config, DefaultPrecision); err != apperrors.ErrAllocationNotSupported {
+		t.Fatalf("got %v, want %v", err, apperrors.ErrAllocationNotSupported)
+	}
+}
@@ -0,0 +1,313 @@
+package engine
+
+import (
+	"log"
+	"math"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"sort"
+	"time"
+)
+
+// volumeEpsilon absorbs floating point noise when comparing aggregated quantities
+const volumeEpsilon = 1e-9
+
+// AuctionListener is notified of call auction indicative and final states
+type AuctionListener interface {
+	OnAuctionUpdate(state models.AuctionState)
+}
+
+// uncrossResult is the single price a book clears at and the volumes on each side of it
+type uncrossResult struct {
+	price      float64
+	volume     float64
+	buyVolume  float64 // buy quantity priced at or above price
+	sellVolume float64 // sell quantity priced at or below price
+}
+
+// AddAuctionListener registers a listener for call auction updates
+func (me *MatchingEngine) AddAuctionListener(listener AuctionListener) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.auctionListeners = append(me.auctionListeners, listener)
+}
+
+// StartAuction moves a pair into a call auction. With a positive duration the book uncrosses and
+// the pair returns to trading when it elapses, otherwise the auction runs until an admin moves the
+// pair to another status.
+func (me *MatchingEngine) StartAuction(pair string, duration time.Duration) (models.AuctionState, error) {
+	if duration < 0 {
+		return models.AuctionState{}, apperrors.ErrInvalidAuctionDuration
+	}
+
+	me.mu.Lock()
+	ob, exists := me.orderBooks[pair]
+	if !exists {
+		me.mu.Unlock()
+		return models.AuctionState{}, apperrors.ErrPairNotFound
+	}
+	if me.pairStatus[pair] == models.PairStatusDelisted {
+		me.mu.Unlock()
+		return models.AuctionState{}, apperrors.ErrInvalidPairTransition
+	}
+	if risk := me.pairRisk[pair]; risk != nil {
+		risk.haltedAt = time.Time{}
+	}
+	me.startAuction(pair, duration)
+	state := me.auctionState(ob)
+	me.mu.Unlock()
+
+	me.notifyAuctionUpdate(state)
+
+	return state, nil
+}
+
+// GetAuctionState returns a pair's indicative auction outcome, with InAuction false outside an auction
+func (me *MatchingEngine) GetAuctionState(pair string) (models.AuctionState, error) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+
+	ob, exists := me.orderBooks[pair]
+	if !exists {
+		return models.AuctionState{}, apperrors.ErrPairNotFound
+	}
+	if me.pairStatus[pair] != models.PairStatusAuction {
+		return models.AuctionState{Pair: pair}, nil
+	}
+	return me.auctionState(ob), nil
+}
+
+// startAuction puts the pair in auction and schedules its end. Caller must hold me.mu.
+func (me *MatchingEngine) startAuction(pair string, duration time.Duration) {
+	me.pairStatus[pair] = models.PairStatusAuction
+	delete(me.auctionEnds, pair)
+	if duration <= 0 {
+		log.Printf("%s entered a call auction", pair)
+		return
+	}
+
+	endsAt := time.Now().Add(duration)
+	me.auctionEnds[pair] = endsAt
+	time.AfterFunc(duration, func() {
+		me.endAuction(pair, endsAt)
+	})
+	log.Printf("%s entered a call auction ending at %s", pair, endsAt.Format(time.RFC3339))
+}
+
+// endAuction uncrosses a pair whose auction was scheduled to end at endsAt and returns it to
+// trading, unless an admin changed its status or rescheduled the auction
+func (me *MatchingEngine) endAuction(pair string, endsAt time.Time) {
+	me.mu.Lock()
+	ob := me.orderBooks[pair]
+	if ob == nil || me.pairStatus[pair] != models.PairStatusAuction || !me.auctionEnds[pair].Equal(endsAt) {
+		me.mu.Unlock()
+		return
+	}
+	delete(me.auctionEnds, pair)
+	me.pairStatus[pair] = models.PairStatusTrading
+	trades, state := me.uncross(ob)
+	if len(trades) > 0 {
+		me.restartPriceWindow(pair, state.IndicativePrice, trades[0].CreatedAt)
+	}
+	me.mu.Unlock()
+
+	log.Printf("%s call auction ended, uncrossed %v at %v", pair, state.MatchedVolume, state.IndicativePrice)
+
+	me.notifyTrades(trades)
+	me.notifyOrderBookUpdate(ob)
+	me.notifyAuctionUpdate(state)
+}
+
+// auctionState computes the indicative outcome of the pair's running auction. Caller must hold me.mu.
+func (me *MatchingEngine) auctionState(ob *OrderBook) models.AuctionState {
+	result := computeUncross(ob, me.referencePrice(ob.Pair))
+
+	state := models.AuctionState{
+		Pair:            ob.Pair,
+		InAuction:       true,
+		IndicativePrice: result.price,
+		MatchedVolume:   result.volume,
+		Imbalance:       math.Abs(result.buyVolume - result.sellVolume),
+	}
+	if state.Imbalance > volumeEpsilon {
+		state.ImbalanceSide = "buy"
+		if result.sellVolume > result.buyVolume {
+			state.ImbalanceSide = "sell"
+		}
+	} else {
+		state.Imbalance = 0
+	}
+	if endsAt, scheduled := me.auctionEnds[ob.Pair]; scheduled {
+		state.EndsAt = &endsAt
+	}
+	return state
+}
+
+// referencePrice returns the pair's band reference price, 0 when it has none. Caller must hold me.mu.
+func (me *MatchingEngine) referencePrice(pair string) float64 {
+	if risk := me.pairRisk[pair]; risk != nil {
+		return risk.referencePrice()
+	}
+	return 0
+}
+
+// uncross executes every crossing order at the single price that maximizes matched volume and
+// returns the final auction state. Caller must hold me.mu.
+func (me *MatchingEngine) uncross(ob *OrderBook) ([]*models.Trade, models.AuctionState) {
+	result := computeUncross(ob, me.referencePrice(ob.Pair))
+	state := models.AuctionState{Pair: ob.Pair}
+	if result.volume <= 0 {
+		return nil, state
+	}
+
+	now := time.Now()
+	trades := make([]*models.Trade, 0)
+	for {
+		bestBid := ob.GetBestBid()
+		bestAsk := ob.GetBestAsk()
+		if bestBid == nil || bestAsk == nil || bestBid.Price < result.price || bestAsk.Price > result.price {
+			break
+		}
+
+		matchQty := quantityOf(min(stepsOf(bestBid.Remaining()), stepsOf(bestAsk.Remaining())))
+		if matchQty <= 0 {
+			break
+		}
+		fill(bestBid, matchQty)
+		fill(bestAsk, matchQty)
+
+		trade := &models.Trade{
+			ID:          ob.GetNextTradeID(),
+			BuyOrderID:  bestBid.ID,
+			SellOrderID: bestAsk.ID,
+			Pair:        ob.Pair,
+			Price:       result.price,
+			Quantity:    matchQty,
+			CreatedAt:   now,
+		}
+		trades = append(trades, trade)
+		me.trades = append(me.trades, trade)
+		state.MatchedVolume += matchQty
+
+		if bestBid.Remaining() == 0 {
+			ob.RemoveBestBid()
+			bestBid.Status = "filled"
+		} else {
+			bestBid.Status = "partial"
+		}
+		if bestAsk.Remaining() == 0 {
+			ob.RemoveBestAsk()
+			bestAsk.Status = "filled"
+		} else {
+			bestAsk.Status = "partial"
+		}
+		me.recordFill(bestBid)
+		me.recordFill(bestAsk)
+	}
+
+	state.IndicativePrice = result.price
+	return trades, state
+}
+
+// notifyAuctionUpdate forwards an auction state to registered listeners
+func (me *MatchingEngine) notifyAuctionUpdate(state models.AuctionState) {
+	me.mu.RLock()
+	listeners := me.auctionListeners
+	me.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener.OnAuctionUpdate(state)
+	}
+}
+
+// computeUncross finds the price at which the most volume would execute if the book were
+// matched at a single price. Ties go to the smallest imbalance, then to the price closest to
+// the reference price (or the middle of the tied prices without one), then to the lower price.
+func computeUncross(ob *OrderBook, reference float64) uncrossResult {
+	ob.mu.Lock()
+	buyLevels := make(map[float64]float64)
+	sellLevels := make(map[float64]float64)
+	for _, order := range ob.BuyHeap {
+		buyLevels[order.Price] += order.Remaining()
+	}
+	for _, order := range ob.SellHeap {
+		sellLevels[order.Price] += order.Remaining()
+	}
+	ob.mu.Unlock()
+
+	prices := make([]float64, 0, len(buyLevels)+len(sellLevels))
+	for price := range buyLevels {
+		prices = append(prices, price)
+	}
+	for price := range sellLevels {
+		if _, exists := buyLevels[price]; !exists {
+			prices = append(prices, price)
+		}
+	}
+	sort.Float64s(prices)
+
+	// Buy volume at a price counts bids at or above it, sell volume counts asks at or below it
+	candidates := make([]uncrossResult, len(prices))
+	sellVolume := 0.0
+	for i, price := range prices {
+		sellVolume += sellLevels[price]
+		candidates[i] = uncrossResult{price: price, sellVolume: sellVolume}
+	}
+	buyVolume := 0.0
+	for i := len(prices) - 1; i >= 0; i-- {
+		buyVolume += buyLevels[prices[i]]
+		candidates[i].buyVolume = buyVolume
+		candidates[i].volume = math.Min(buyVolume, candidates[i].sellVolume)
+	}
+
+	var best []uncrossResult
+	for _, candidate := range candidates {
+		if candidate.volume <= volumeEpsilon {
+			continue
+		}
+		if len(best) == 0 {
+			best = []uncrossResult{candidate}
+			continue
+		}
+		switch compareCandidates(candidate, best[0]) {
+		case 1:
+			best = []uncrossResult{candidate}
+		case 0:
+			best = append(best, candidate)
+		}
+	}
+	if len(best) == 0 {
+		return uncrossResult{}
+	}
+
+	if reference <= 0 {
+		reference = (best[0].price + best[len(best)-1].price) / 2
+	}
+	chosen := best[0]
+	for _, candidate := range best[1:] {
+		// Candidates are in ascending price order so equal distances keep the lower price
+		if math.Abs(candidate.price-reference) < math.Abs(chosen.price-reference) {
+			chosen = candidate
+		}
+	}
+	return chosen
+}
+
+// compareCandidates ranks uncrossing prices by executable volume, then by smaller imbalance
+func compareCandidates(a uncrossResult, b uncrossResult) int {
+	if math.Abs(a.volume-b.volume) > volumeEpsilon {
+		if a.volume > b.volume {
+			return 1
+		}
+		return -1
+	}
+	imbalanceA := math.Abs(a.buyVolume - a.sellVolume)
+	imbalanceB := math.Abs(b.buyVolume - b.sellVolume)
+	if math.Abs(imbalanceA-imbalanceB) > volumeEpsilon {
+		if imbalanceA < imbalanceB {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
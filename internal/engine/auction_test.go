@@ -0,0 +1,183 @@
+package engine
+
+import (
+	"mini-crypto-exchange/internal/models"
+	"testing"
+	"time"
+)
+
+// level is a resting order placed before the book is uncrossed
+type level struct {
+	side     string
+	price    float64
+	quantity float64
+}
+
+// newAuctionTestEngine returns an engine whose test pair is in an open-ended call auction
+func newAuctionTestEngine(t *testing.T, levels ...level) *MatchingEngine {
+	t.Helper()
+	me := newTestEngine(t)
+	if _, err := me.StartAuction(testPair, 0); err != nil {
+		t.Fatalf("StartAuction: %v", err)
+	}
+	for i, l := range levels {
+		mustPlace(t, me, int64(i+1), l.side, l.price, l.quantity)
+	}
+	return me
+}
+
+func TestComputeUncross(t *testing.T) {
+	tests := []struct {
+		name       string
+		levels     []level
+		reference  float64
+		wantPrice  float64
+		wantVolume float64
+	}{
+		{
+			name:       "maximum volume beats a smaller imbalance",
+			levels:     []level{{"buy", 101, 3}, {"sell", 100, 1}, {"sell", 101, 5}},
+			wantPrice:  101,
+			wantVolume: 3,
+		},
+		{
+			name:       "minimum imbalance beats the reference price",
+			levels:     []level{{"buy", 101, 2}, {"buy", 99, 1}, {"sell", 99, 2}, {"sell", 101, 2}},
+			reference:  101,
+			wantPrice:  99,
+			wantVolume: 2,
+		},
+		{
+			name:       "closest to a higher reference price",
+			levels:     []level{{"buy", 102, 2}, {"sell", 100, 2}},
+			reference:  101.8,
+			wantPrice:  102,
+			wantVolume: 2,
+		},
+		{
+			name:       "closest to a lower reference price",
+			levels:     []level{{"buy", 102, 2}, {"sell", 100, 2}},
+			reference:  100.2,
+			wantPrice:  100,
+			wantVolume: 2,
+		},
+		{
+			name:       "lower price when equidistant from the reference",
+			levels:     []level{{"buy", 102, 2}, {"sell", 100, 2}},
+			reference:  101,
+			wantPrice:  100,
+			wantVolume: 2,
+		},
+		{
+			name:       "lower price without a reference",
+			levels:     []level{{"buy", 102, 2}, {"sell", 100, 2}},
+			wantPrice:  100,
+			wantVolume: 2,
+		},
+		{
+			name:   "no crossing orders",
+			levels: []level{{"buy", 99, 1}, {"sell", 100, 1}},
+		},
+	}
+	for _, tt := range tests {
+		me := newAuctionTestEngine(t, tt.levels...)
+		result := computeUncross(me.orderBooks[testPair], tt.reference)
+		if result.price != tt.wantPrice || result.volume != tt.wantVolume {
+			t.Errorf("%s: uncross %v@%v, want %v@%v", tt.name, result.volume, result.price, tt.wantVolume, tt.wantPrice)
+		}
+	}
+}
+
+func TestUncrossFillsAtTheClearingPrice(t *testing.T) {
+	me := newAuctionTestEngine(t)
+	bid, _ := mustPlace(t, me, 1, "buy", 101, 3)
+	low, _ := mustPlace(t, me, 2, "sell", 100, 1)
+	high, _ := mustPlace(t, me, 3, "sell", 101, 5)
+
+	state, err := me.GetAuctionState(testPair)
+	if err != nil {
+		t.Fatalf("GetAuctionState: %v", err)
+	}
+	if state.IndicativePrice != 101 || state.MatchedVolume != 3 || state.Imbalance != 3 || state.ImbalanceSide != "sell" {
+		t.Fatalf("indicative state %+v, want 3@101 with a sell imbalance of 3", state)
+	}
+
+	mustSetStatus(t, me, models.PairStatusTrading)
+
+	trades := me.GetTrades()
+	if len(trades) != 2 {
+		t.Fatalf("got %d trades, want 2", len(trades))
+	}
+	for _, trade := range trades {
+		if trade.Price != 101 {
+			t.Errorf("trade %d at %v, want the clearing price 101", trade.ID, trade.Price)
+		}
+	}
+	for _, want := range []struct {
+		order     *models.Order
+		status    string
+		remaining float64
+	}{
+		{bid, "filled", 0},
+		{low, "filled", 0},
+		{high, "partial", 3},
+	} {
+		order, _ := me.GetOrder(want.order.ID)
+		if order.Status != want.status || order.Remaining() != want.remaining {
+			t.Errorf("order %d is %s with %v left, want %s with %v", order.ID, order.Status, order.Remaining(), want.status, want.remaining)
+		}
+	}
+	if bestAsk := me.orderBooks[testPair].GetBestAsk(); bestAsk == nil || bestAsk.ID != high.ID {
+		t.Fatalf("best ask %v, want the rest of order %d", bestAsk, high.ID)
+	}
+}
+
+func TestEndAuctionOnlyEndsTheScheduledAuction(t *testing.T) {
+	me := newTestEngine(t)
+	mustPlace(t, me, 1, "buy", 101, 1)
+	first, err := me.StartAuction(testPair, time.Hour)
+	if err != nil {
+		t.Fatalf("StartAuction: %v", err)
+	}
+	mustPlace(t, me, 2, "sell", 100, 1)
+	second, err := me.StartAuction(testPair, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("StartAuction: %v", err)
+	}
+
+	// The timer of the first schedule still fires but must not end the rescheduled auction
+	me.endAuction(testPair, *first.EndsAt)
+	if status, _ := me.GetPairStatus(testPair); status != models.PairStatusAuction {
+		t.Fatalf("stale schedule moved the pair to %s", status)
+	}
+	if trades := me.GetTrades(); len(trades) != 0 {
+		t.Fatalf("stale schedule uncrossed %d trades", len(trades))
+	}
+
+	me.endAuction(testPair, *second.EndsAt)
+	if status, _ := me.GetPairStatus(testPair); status != models.PairStatusTrading {
+		t.Fatalf("pair is %s after its auction ended, want trading", status)
+	}
+	if trades := me.GetTrades(); len(trades) != 1 || trades[0].Price != 100 {
+		t.Fatalf("trades %v, want one uncrossing trade at 100", trades)
+	}
+	if state, _ := me.GetAuctionState(testPair); state.InAuction {
+		t.Fatal("auction state still reports an auction")
+	}
+}
+
+func TestEndAuctionLeavesAdminStatusAlone(t *testing.T) {
+	me := newTestEngine(t)
+	mustPlace(t, me, 1, "buy", 101, 1)
+	mustPlace(t, me, 2, "sell", 102, 1)
+	state, err := me.StartAuction(testPair, time.Hour)
+	if err != nil {
+		t.Fatalf("StartAuction: %v", err)
+	}
+	mustSetStatus(t, me, models.PairStatusHalted)
+
+	me.endAuction(testPair, *state.EndsAt)
+	if status, _ := me.GetPairStatus(testPair); status != models.PairStatusHalted {
+		t.Fatalf("ended auction moved a halted pair to %s", status)
+	}
+}
@@ -0,0 +1,164 @@
+package engine
+
+import (
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"time"
+)
+
+// Matching modes
+const (
+	MatchingModeContinuous = "continuous" // incoming orders match the book immediately
+	MatchingModeBatch      = "batch"      // orders collected over an interval match together at one price
+)
+
+// DefaultBatchInterval is used for batch pairs created without an interval
+const DefaultBatchInterval = 100 * time.Millisecond
+
+// MatchingConfig selects how a pair matches orders and allocates fills within a price level.
+// Allocation applies to continuous matching; auctions and batches fill in time priority.
+type MatchingConfig struct {
+	Mode            string `json:"matching_mode"`
+	BatchIntervalMs int64  `json:"batch_interval_ms,omitempty"`
+	AllocationConfig
+}
+
+// BatchInterval returns the batch interval as a duration
+func (c MatchingConfig) BatchInterval() time.Duration {
+	return time.Duration(c.BatchIntervalMs) * time.Millisecond
+}
+
+// Validate rejects unknown modes, negative intervals, invalid allocation settings and allocation
+// settings other than fifo for batch pairs
+func (c MatchingConfig) Validate() error {
+	if c.Mode != MatchingModeContinuous && c.Mode != MatchingModeBatch {
+		return apperrors.ErrInvalidMatchingMode
+	}
+	if c.BatchIntervalMs < 0 {
+		return apperrors.ErrInvalidBatchInterval
+	}
+	if err := c.AllocationConfig.Validate(); err != nil {
+		return err
+	}
+	if c.Mode == MatchingModeBatch && c.Algorithm != AllocationFIFO {
+		return apperrors.ErrAllocationNotSupported
+	}
+	return nil
+}
+
+// CreatePairWithMatching creates a trading pair with the given matching mode. An empty mode means
+// continuous, which ignores the interval; batch pairs default to DefaultBatchInterval. An empty
+// allocation means fifo. Prices and quantities are limited to precision, clamped to MaxDecimals.
+// Creating an existing pair fails with ErrPairExists and leaves it unchanged.
+func (me *MatchingEngine) CreatePairWithMatching(tradingPair models.TradingPair, config MatchingConfig, precision Precision) (MatchingConfig, error) {
+	if config.Mode == "" {
+		config.Mode = MatchingModeContinuous
+	}
+	if config.Algorithm == "" {
+		config.Algorithm = AllocationFIFO
+	}
+	if err := config.Validate(); err != nil {
+		return MatchingConfig{}, err
+	}
+	switch {
+	case config.Mode == MatchingModeContinuous:
+		config.BatchIntervalMs = 0
+	case config.BatchIntervalMs == 0:
+		config.BatchIntervalMs = int64(DefaultBatchInterval / time.Millisecond)
+	}
+
+	pair := tradingPair.String()
+
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	if _, exists := me.orderBooks[pair]; exists {
+		return MatchingConfig{}, apperrors.ErrPairExists
+	}
+	ob := NewOrderBook(pair)
+	me.orderBooks[pair] = ob
+	me.pairs[pair] = tradingPair
+	me.pairStatus[pair] = models.PairStatusTrading
+	me.pairRisk[pair] = &pairRisk{config: me.defaultRisk}
+	me.matching[pair] = config
+	me.allocators[pair] = NewAllocator(config.AllocationConfig)
+	me.precision[pair] = NewPrecision(precision.PriceDecimals, precision.QuantityDecimals)
+
+	if config.Mode == MatchingModeBatch {
+		go me.runBatches(ob, config.BatchInterval())
+	}
+	return config, nil
+}
+
+// GetMatchingConfig returns a pair's matching mode
+func (me *MatchingEngine) GetMatchingConfig(pair string) (MatchingConfig, bool) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+	config, exists := me.matching[pair]
+	return config, exists
+}
+
+// GetPairPrecision returns the decimal places a pair accepts in prices and quantities
+func (me *MatchingEngine) GetPairPrecision(pair string) (Precision, bool) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+	precision, exists := me.precision[pair]
+	return precision, exists
+}
+
+// pairPrecision returns a pair's precision, DefaultPrecision if it has none. Caller must hold me.mu.
+func (me *MatchingEngine) pairPrecision(pair string) Precision {
+	if precision, exists := me.precision[pair]; exists {
+		return precision
+	}
+	return DefaultPrecision
+}
+
+// matchesContinuously reports whether orders for pair match on arrival, which they do not in
+// batch mode or during a call auction. Caller must hold me.mu.
+func (me *MatchingEngine) matchesContinuously(pair string) bool {
+	return me.matching[pair].Mode != MatchingModeBatch && me.pairStatus[pair] != models.PairStatusAuction
+}
+
+// runBatches matches a batch pair's book every interval until the pair is delisted
+func (me *MatchingEngine) runBatches(ob *OrderBook, interval time.Duration) {
+	log.Printf("%s matching in batches every %s", ob.Pair, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !me.matchBatch(ob) {
+			return
+		}
+	}
+}
+
+// matchBatch uncrosses the orders collected since the last batch at a single price, reporting
+// false once the pair is delisted. Batches are skipped while the pair is not trading.
+func (me *MatchingEngine) matchBatch(ob *OrderBook) bool {
+	me.mu.Lock()
+	switch me.pairStatus[ob.Pair] {
+	case models.PairStatusDelisted:
+		me.mu.Unlock()
+		return false
+	case models.PairStatusTrading:
+	default:
+		me.mu.Unlock()
+		return true
+	}
+
+	trades, state := me.uncross(ob)
+	if len(trades) > 0 {
+		// Each batch is one price for the circuit breaker
+		me.recordTradePrice(ob.Pair, state.IndicativePrice, trades[0].CreatedAt)
+	}
+	me.mu.Unlock()
+
+	if len(trades) > 0 {
+		me.notifyTrades(trades)
+		me.notifyOrderBookUpdate(ob)
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"testing"
+	"time"
+)
+
+// newBatchTestEngine creates the test pair in batch mode with an interval no test waits for, so
+// tests run the batches themselves
+func newBatchTestEngine(t *testing.T) *MatchingEngine {
+	t.Helper()
+	me := NewMatchingEngine()
+	config := MatchingConfig{Mode: MatchingModeBatch, BatchIntervalMs: int64(time.Hour / time.Millisecond)}
+	if _, err := me.CreatePairWithMatching(models.TradingPair{Base: "BTC", Quote: "USDT"}, config, DefaultPrecision); err != nil {
+		t.Fatalf("CreatePairWithMatching: %v", err)
+	}
+	t.Cleanup(func() { me.SetPairStatus(testPair, models.PairStatusDelisted) })
+	return me
+}
+
+func TestCreatePairWithMatchingDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		config MatchingConfig
+		want   MatchingConfig
+	}{
+		{"empty", MatchingConfig{}, MatchingConfig{Mode: MatchingModeContinuous, AllocationConfig: AllocationConfig{Algorithm: AllocationFIFO}}},
+		{"continuous ignores the interval", MatchingConfig{Mode: MatchingModeContinuous, BatchIntervalMs: 50}, MatchingConfig{Mode: MatchingModeContinuous, AllocationConfig: AllocationConfig{Algorithm: AllocationFIFO}}},
+		{"batch without an interval", MatchingConfig{Mode: MatchingModeBatch}, MatchingConfig{Mode: MatchingModeBatch, BatchIntervalMs: int64(DefaultBatchInterval / time.Millisecond), AllocationConfig: AllocationConfig{Algorithm: AllocationFIFO}}},
+		{"batch with an interval", MatchingConfig{Mode: MatchingModeBatch, BatchIntervalMs: 250}, MatchingConfig{Mode: MatchingModeBatch, BatchIntervalMs: 250, AllocationConfig: AllocationConfig{Algorithm: AllocationFIFO}}},
+	}
+	for _, tt := range tests {
+		me := NewMatchingEngine()
+		got, err := me.CreatePairWithMatching(models.TradingPair{Base: "BTC", Quote: "USDT"}, tt.config, DefaultPrecision)
+		if err != nil {
+			t.Fatalf("%s: CreatePairWithMatching: %v", tt.name, err)
+		}
+		stored, _ := me.GetMatchingConfig(testPair)
+		if got.Mode != tt.want.Mode || got.BatchIntervalMs != tt.want.BatchIntervalMs || got.Algorithm != tt.want.Algorithm {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+		if stored.Mode != got.Mode || stored.BatchIntervalMs != got.BatchIntervalMs || stored.Algorithm != got.Algorithm {
+			t.Errorf("%s: stored %+v, returned %+v", tt.name, stored, got)
+		}
+		me.SetPairStatus(testPair, models.PairStatusDelisted)
+	}
+}
+
+func TestMatchingConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config MatchingConfig
+		want   error
+	}{
+		{"continuous", MatchingConfig{Mode: MatchingModeContinuous, AllocationConfig: AllocationConfig{Algorithm: AllocationFIFO}}, nil},
+		{"continuous pro-rata", MatchingConfig{Mode: MatchingModeContinuous, AllocationConfig: AllocationConfig{Algorithm: AllocationProRata}}, nil},
+		{"batch fifo", MatchingConfig{Mode: MatchingModeBatch, BatchIntervalMs: 100, AllocationConfig: AllocationConfig{Algorithm: AllocationFIFO}}, nil},
+		{"unknown mode", MatchingConfig{Mode: "hybrid", AllocationConfig: AllocationConfig{Algorithm: AllocationFIFO}}, apperrors.ErrInvalidMatchingMode},
+		{"negative interval", MatchingConfig{Mode: MatchingModeBatch, BatchIntervalMs: -1, AllocationConfig: AllocationConfig{Algorithm: AllocationFIFO}}, apperrors.ErrInvalidBatchInterval},
+		{"batch pro-rata", MatchingConfig{Mode: MatchingModeBatch, AllocationConfig: AllocationConfig{Algorithm: AllocationProRata}}, apperrors.ErrAllocationNotSupported},
+		{"batch pro-rata with top order", MatchingConfig{Mode: MatchingModeBatch, AllocationConfig: AllocationConfig{Algorithm: AllocationProRataTop, TopOrderPercent: 20}}, apperrors.ErrAllocationNotSupported},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestBatchExecutesAtASinglePrice(t *testing.T) {
+	me := newBatchTestEngine(t)
+	cheap, _ := mustPlace(t, me, 1, "sell", 99, 1)
+	dear, _ := mustPlace(t, me, 2, "sell", 100, 1)
+	bid, trades := mustPlace(t, me, 3, "buy", 101, 2)
+	if len(trades) != 0 || bid.Status != "open" {
+		t.Fatalf("crossing order traded %d times on arrival, want it to wait for the batch", len(trades))
+	}
+
+	if !me.matchBatch(me.orderBooks[testPair]) {
+		t.Fatal("matchBatch reported the pair delisted")
+	}
+
+	trades = me.GetTrades()
+	if len(trades) != 2 {
+		t.Fatalf("got %d trades, want 2", len(trades))
+	}
+	for _, trade := range trades {
+		if trade.Price != 100 {
+			t.Errorf("trade %d at %v, want every trade at 100", trade.ID, trade.Price)
+		}
+	}
+	for _, order := range []*models.Order{cheap, dear, bid} {
+		if snapshot, _ := me.GetOrder(order.ID); snapshot.Status != "filled" {
+			t.Errorf("order %d is %s, want filled", order.ID, snapshot.Status)
+		}
+	}
+}
+
+func TestBatchesAreSkippedWhileNotTrading(t *testing.T) {
+	me := newBatchTestEngine(t)
+	ob := me.orderBooks[testPair]
+	mustPlace(t, me, 1, "sell", 100, 1)
+	mustPlace(t, me, 2, "buy", 100, 1)
+
+	for _, status := range []models.PairStatus{models.PairStatusHalted, models.PairStatusCancelOnly, models.PairStatusAuction} {
+		mustSetStatus(t, me, status)
+		if !me.matchBatch(ob) {
+			t.Fatalf("%s: matchBatch reported the pair delisted", status)
+		}
+		if trades := me.GetTrades(); len(trades) != 0 {
+			t.Fatalf("%s: batch matched %d trades", status, len(trades))
+		}
+	}
+
+	mustSetStatus(t, me, models.PairStatusTrading)
+	me.matchBatch(ob)
+	if trades := me.GetTrades(); len(trades) != 1 {
+		t.Fatalf("got %d trades once trading resumed, want 1", len(trades))
+	}
+}
+
+func TestBatchesStopOnDelisting(t *testing.T) {
+	me := newBatchTestEngine(t)
+	ob := me.orderBooks[testPair]
+	mustSetStatus(t, me, models.PairStatusDelisted)
+
+	if me.matchBatch(ob) {
+		t.Fatal("matchBatch kept going after delisting")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		me.runBatches(ob, time.Millisecond)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("batch goroutine still running after delisting")
+	}
+}
+
+func TestBreakerRecordsOnePricePerBatch(t *testing.T) {
+	me := newBatchTestEngine(t)
+	ob := me.orderBooks[testPair]
+	if err := me.SetRiskConfig(testPair, RiskConfig{BreakerPercent: 50, BreakerWindowSeconds: 60, CoolDownSeconds: 60}, 0); err != nil {
+		t.Fatalf("SetRiskConfig: %v", err)
+	}
+
+	for batch := 1; batch <= 2; batch++ {
+		mustPlace(t, me, 1, "sell", 100, 1)
+		mustPlace(t, me, 2, "sell", 100, 1)
+		mustPlace(t, me, 3, "buy", 100, 2)
+		me.matchBatch(ob)
+
+		me.mu.RLock()
+		prices := len(me.pairRisk[testPair].prices)
+		me.mu.RUnlock()
+		if prices != batch {
+			t.Fatalf("breaker window holds %d prices after %d batches, want %d", prices, batch, batch)
+		}
+	}
+	if trades := me.GetTrades(); len(trades) != 4 {
+		t.Fatalf("got %d trades, want 4", len(trades))
+	}
+}
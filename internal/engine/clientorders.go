@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"time"
+)
+
+// ClientOrderIDRetention is how long a client order ID stays reserved after its order was placed
+// once the order is no longer open
+const ClientOrderIDRetention = 24 * time.Hour
+
+// PlaceOrderWithClientID places an order tagged with a client order ID, which must be unique among
+// the user's open orders and orders placed within ClientOrderIDRetention. A duplicate is rejected
+// with ErrDuplicateClientOrderID and a snapshot of the original order. An empty ID places an
+// untagged order.
+func (me *MatchingEngine) PlaceOrderWithClientID(userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) (*models.Order, []*models.Trade, error) {
+	me.mu.Lock()
+	if original := me.clientOrder(userID, clientOrderID); original != nil {
+		snapshot := *original
+		me.mu.Unlock()
+		return &snapshot, nil, apperrors.ErrDuplicateClientOrderID
+	}
+	ob, exists := me.orderBooks[pair]
+	if !exists {
+		me.mu.Unlock()
+		return nil, nil, apperrors.ErrPairNotFound
+	}
+	if err := me.pairPrecision(pair).Check(price, quantity); err != nil {
+		me.mu.Unlock()
+		return nil, nil, err
+	}
+
+	price, err := me.applyPriceBand(pair, side, price)
+	if err != nil {
+		me.mu.Unlock()
+		return nil, nil, err
+	}
+	if err := me.checkCanPlace(ob, side, price); err != nil {
+		me.mu.Unlock()
+		return nil, nil, err
+	}
+	if err := me.checkLimits(userID, pair, false); err != nil {
+		me.mu.Unlock()
+		return nil, nil, err
+	}
+
+	// Create order
+	order := &models.Order{
+		ID:            me.getNextOrderID(),
+		UserID:        userID,
+		ClientOrderID: clientOrderID,
+		Pair:          pair,
+		Side:          side,
+		Price:         price,
+		Quantity:      quantity,
+		Filled:        0,
+		Status:        "open",
+		CreatedAt:     time.Now(),
+	}
+	me.reserveClientOrderID(order)
+
+	trades := me.executeOrder(ob, order)
+	me.mu.Unlock()
+
+	me.notifyTrades(trades)
+	me.notifyOrderBookUpdate(ob)
+
+	return order, trades, nil
+}
+
+// GetOrderByClientID returns a snapshot of the user's order with the given client order ID
+func (me *MatchingEngine) GetOrderByClientID(userID int64, clientOrderID string) (models.Order, bool) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+
+	order := me.clientOrder(userID, clientOrderID)
+	if order == nil {
+		return models.Order{}, false
+	}
+	return *order, true
+}
+
+// CancelOrderByClientID cancels the user's open order with the given client order ID
+func (me *MatchingEngine) CancelOrderByClientID(userID int64, clientOrderID string) (*models.Order, error) {
+	me.mu.RLock()
+	order := me.clientOrder(userID, clientOrderID)
+	me.mu.RUnlock()
+
+	if order == nil {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	return me.CancelOrder(userID, order.ID)
+}
+
+// clientOrder returns the order holding a reserved client order ID, nil when the ID is free.
+// Caller must hold me.mu.
+func (me *MatchingEngine) clientOrder(userID int64, clientOrderID string) *models.Order {
+	if clientOrderID == "" {
+		return nil
+	}
+	order, exists := me.orders[me.clientOrderIDs[userID][clientOrderID]]
+	if !exists {
+		return nil
+	}
+	open := order.Status == "open" || order.Status == "partial"
+	if !open && time.Since(order.CreatedAt) > ClientOrderIDRetention {
+		return nil
+	}
+	return order
+}
+
+// reserveClientOrderID points the order's client order ID at it. Caller must hold me.mu.
+func (me *MatchingEngine) reserveClientOrderID(order *models.Order) {
+	if order.ClientOrderID == "" {
+		return
+	}
+	ids, exists := me.clientOrderIDs[order.UserID]
+	if !exists {
+		ids = make(map[string]int64)
+		me.clientOrderIDs[order.UserID] = ids
+	}
+	ids[order.ClientOrderID] = order.ID
+}
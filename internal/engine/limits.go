@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"os"
+	"strconv"
+	"time"
+)
+
+// OrderLimits protect the books from a single user. Zero values disable a limit.
+type OrderLimits struct {
+	// MaxOpenOrdersPerPair caps the user's resting orders in one pair
+	MaxOpenOrdersPerPair int `json:"max_open_orders_per_pair"`
+	// MaxOrderToTradeRatio caps orders placed or replaced per trade within the ratio window
+	MaxOrderToTradeRatio float64 `json:"max_order_to_trade_ratio"`
+	// RatioMinOrders is how many orders a window needs before the ratio is enforced
+	RatioMinOrders int `json:"ratio_min_orders"`
+}
+
+// Validate rejects negative limits
+func (l OrderLimits) Validate() error {
+	if l.MaxOpenOrdersPerPair < 0 || l.MaxOrderToTradeRatio < 0 || l.RatioMinOrders < 0 {
+		return apperrors.ErrInvalidOrderLimits
+	}
+	return nil
+}
+
+// RatioWindow is the period over which the order-to-trade ratio is measured
+const RatioWindow = 24 * time.Hour
+
+// LimitsFromEnv builds the default limits from ORDER_LIMIT_MAX_OPEN_PER_PAIR,
+// ORDER_LIMIT_MAX_ORDER_TO_TRADE_RATIO and ORDER_LIMIT_RATIO_MIN_ORDERS
+func LimitsFromEnv() OrderLimits {
+	limits := OrderLimits{
+		MaxOpenOrdersPerPair: 200,
+		MaxOrderToTradeRatio: 1000,
+		RatioMinOrders:       1000,
+	}
+	if value := os.Getenv("ORDER_LIMIT_MAX_OPEN_PER_PAIR"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			limits.MaxOpenOrdersPerPair = parsed
+		} else {
+			log.Printf("Ignoring invalid ORDER_LIMIT_MAX_OPEN_PER_PAIR %q", value)
+		}
+	}
+	if value := os.Getenv("ORDER_LIMIT_MAX_ORDER_TO_TRADE_RATIO"); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed >= 0 {
+			limits.MaxOrderToTradeRatio = parsed
+		} else {
+			log.Printf("Ignoring invalid ORDER_LIMIT_MAX_ORDER_TO_TRADE_RATIO %q", value)
+		}
+	}
+	if value := os.Getenv("ORDER_LIMIT_RATIO_MIN_ORDERS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			limits.RatioMinOrders = parsed
+		} else {
+			log.Printf("Ignoring invalid ORDER_LIMIT_RATIO_MIN_ORDERS %q", value)
+		}
+	}
+	return limits
+}
+
+// userActivity tracks what the limits are checked against
+type userActivity struct {
+	openOrders  map[string]int // pair -> resting orders
+	windowStart time.Time
+	orders      int // orders placed or replaced in the window
+	trades      int // fills in the window
+}
+
+// SetDefaultLimits sets the limits applied to users without an override
+func (me *MatchingEngine) SetDefaultLimits(limits OrderLimits) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.defaultLimits = limits
+}
+
+// SetUserLimits overrides the limits for one user, e.g. a market maker
+func (me *MatchingEngine) SetUserLimits(userID int64, limits OrderLimits) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.userLimits[userID] = limits
+}
+
+// ClearUserLimits removes a user's override so the defaults apply again
+func (me *MatchingEngine) ClearUserLimits(userID int64) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	delete(me.userLimits, userID)
+}
+
+// GetUserLimits returns the limits in force for a user and whether they are an override
+func (me *MatchingEngine) GetUserLimits(userID int64) (OrderLimits, bool) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+	limits, overridden := me.userLimits[userID]
+	if !overridden {
+		return me.defaultLimits, false
+	}
+	return limits, true
+}
+
+// activity returns the user's activity, starting a new ratio window when the last one ended. Caller must hold me.mu.
+func (me *MatchingEngine) activity(userID int64, now time.Time) *userActivity {
+	activity, exists := me.userActivity[userID]
+	if !exists {
+		activity = &userActivity{openOrders: make(map[string]int), windowStart: now}
+		me.userActivity[userID] = activity
+	}
+	if now.Sub(activity.windowStart) >= RatioWindow {
+		activity.windowStart = now
+		activity.orders = 0
+		activity.trades = 0
+	}
+	return activity
+}
+
+// checkLimits verifies a user may submit another order to pair. A replacement keeps the number
+// of open orders unchanged so only the ratio applies. Caller must hold me.mu.
+func (me *MatchingEngine) checkLimits(userID int64, pair string, replacement bool) error {
+	limits, overridden := me.userLimits[userID]
+	if !overridden {
+		limits = me.defaultLimits
+	}
+	activity := me.activity(userID, time.Now())
+
+	if !replacement && limits.MaxOpenOrdersPerPair > 0 && activity.openOrders[pair] >= limits.MaxOpenOrdersPerPair {
+		return apperrors.ErrTooManyOpenOrders
+	}
+
+	if limits.MaxOrderToTradeRatio > 0 && activity.orders+1 > limits.RatioMinOrders {
+		trades := activity.trades
+		if trades == 0 {
+			trades = 1
+		}
+		if float64(activity.orders+1)/float64(trades) > limits.MaxOrderToTradeRatio {
+			return apperrors.ErrOrderToTradeRatioExceeded
+		}
+	}
+
+	return nil
+}
+
+// recordOrder counts an accepted order and its fills, and whether it rests. Caller must hold me.mu.
+func (me *MatchingEngine) recordOrder(order *models.Order, trades []*models.Trade) {
+	activity := me.activity(order.UserID, time.Now())
+	activity.orders++
+	if order.Remaining() > 0 {
+		activity.openOrders[order.Pair]++
+	}
+
+	for _, trade := range trades {
+		activity.trades++
+		maker := trade.SellOrderID
+		if maker == order.ID {
+			maker = trade.BuyOrderID
+		}
+		if makerOrder, exists := me.orders[maker]; exists {
+			makerActivity := me.activity(makerOrder.UserID, time.Now())
+			makerActivity.trades++
+			if makerOrder.Remaining() <= 0 && makerActivity.openOrders[makerOrder.Pair] > 0 {
+				makerActivity.openOrders[makerOrder.Pair]--
+			}
+		}
+	}
+}
+
+// recordFill counts a fill on a resting order, releasing its open order slot once it is filled.
+// Caller must hold me.mu.
+func (me *MatchingEngine) recordFill(order *models.Order) {
+	activity := me.activity(order.UserID, time.Now())
+	activity.trades++
+	if order.Remaining() <= 0 && activity.openOrders[order.Pair] > 0 {
+		activity.openOrders[order.Pair]--
+	}
+}
+
+// recordRemoval counts a resting order leaving the book without being filled. Caller must hold me.mu.
+func (me *MatchingEngine) recordRemoval(order *models.Order) {
+	activity := me.activity(order.UserID, time.Now())
+	if activity.openOrders[order.Pair] > 0 {
+		activity.openOrders[order.Pair]--
+	}
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"testing"
+)
+
+func TestOpenOrderLimit(t *testing.T) {
+	me := newTestEngine(t)
+	if err := me.CreatePair(models.TradingPair{Base: "ETH", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	me.SetUserLimits(1, OrderLimits{MaxOpenOrdersPerPair: 2})
+
+	first, _ := mustPlace(t, me, 1, "buy", 100, 1)
+	second, _ := mustPlace(t, me, 1, "buy", 99, 1)
+	if _, _, err := me.PlaceOrder(1, testPair, "buy", 98, 1); err != apperrors.ErrTooManyOpenOrders {
+		t.Fatalf("third open order: got %v, want %v", err, apperrors.ErrTooManyOpenOrders)
+	}
+	if _, _, err := me.PlaceOrder(1, "ETH/USDT", "buy", 10, 1); err != nil {
+		t.Fatalf("order in another pair: %v", err)
+	}
+	if _, _, err := me.ReplaceOrder(1, first.ID, 97, 1); err != nil {
+		t.Fatalf("replacement at the limit: %v", err)
+	}
+	if _, _, err := me.PlaceOrder(2, testPair, "buy", 98, 1); err != nil {
+		t.Fatalf("order of another user: %v", err)
+	}
+
+	if _, err := me.CancelOrder(1, second.ID); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	mustPlace(t, me, 1, "buy", 96, 1)
+}
+
+func TestFilledOrdersReleaseOpenOrderSlots(t *testing.T) {
+	me := newTestEngine(t)
+	me.SetUserLimits(1, OrderLimits{MaxOpenOrdersPerPair: 1})
+
+	// A resting order filled by a taker frees its slot
+	mustPlace(t, me, 1, "sell", 100, 1)
+	mustPlace(t, me, 2, "buy", 100, 1)
+	mustPlace(t, me, 1, "sell", 101, 2)
+
+	// A partial fill keeps it
+	mustPlace(t, me, 2, "buy", 101, 1)
+	if _, _, err := me.PlaceOrder(1, testPair, "sell", 102, 1); err != apperrors.ErrTooManyOpenOrders {
+		t.Fatalf("after a partial fill: got %v, want %v", err, apperrors.ErrTooManyOpenOrders)
+	}
+
+	// A taker that fills completely never rests
+	mustPlace(t, me, 2, "buy", 101, 1)
+	mustPlace(t, me, 2, "sell", 90, 1)
+	mustPlace(t, me, 1, "buy", 90, 1)
+	mustPlace(t, me, 1, "sell", 103, 1)
+
+	me.mu.RLock()
+	open := me.userActivity[1].openOrders[testPair]
+	me.mu.RUnlock()
+	if open != 1 {
+		t.Fatalf("user has %d open orders counted, want 1", open)
+	}
+}
+
+func TestOrderToTradeRatioLimit(t *testing.T) {
+	me := newTestEngine(t)
+	me.SetUserLimits(1, OrderLimits{MaxOrderToTradeRatio: 2, RatioMinOrders: 3})
+
+	var resting []*models.Order
+	for i := 0; i < 3; i++ {
+		order, _ := mustPlace(t, me, 1, "sell", float64(100+i), 1)
+		resting = append(resting, order)
+	}
+	// The ratio applies from the fourth order, counting a window without trades as one trade
+	if _, _, err := me.PlaceOrder(1, testPair, "sell", 110, 1); err != apperrors.ErrOrderToTradeRatioExceeded {
+		t.Fatalf("fourth order without trades: got %v, want %v", err, apperrors.ErrOrderToTradeRatioExceeded)
+	}
+	if _, _, err := me.ReplaceOrder(1, resting[2].ID, 110, 1); err != apperrors.ErrOrderToTradeRatioExceeded {
+		t.Fatalf("replacement without trades: got %v, want %v", err, apperrors.ErrOrderToTradeRatioExceeded)
+	}
+
+	// Two fills as maker bring 4 orders per 2 trades within the ratio
+	mustPlace(t, me, 2, "buy", 101, 2)
+	mustPlace(t, me, 1, "sell", 110, 1)
+	if _, _, err := me.PlaceOrder(1, testPair, "sell", 111, 1); err != apperrors.ErrOrderToTradeRatioExceeded {
+		t.Fatalf("fifth order with two trades: got %v, want %v", err, apperrors.ErrOrderToTradeRatioExceeded)
+	}
+
+	// Other users keep the default limits
+	mustPlace(t, me, 2, "sell", 120, 1)
+}
+
+func TestUserLimitsOverrideTheDefaults(t *testing.T) {
+	me := newTestEngine(t)
+	defaults := OrderLimits{MaxOpenOrdersPerPair: 1}
+	me.SetDefaultLimits(defaults)
+	me.SetUserLimits(1, OrderLimits{MaxOpenOrdersPerPair: 5})
+
+	if limits, overridden := me.GetUserLimits(1); !overridden || limits.MaxOpenOrdersPerPair != 5 {
+		t.Fatalf("user limits %+v overridden %v, want the override", limits, overridden)
+	}
+	mustPlace(t, me, 1, "buy", 100, 1)
+	mustPlace(t, me, 1, "buy", 99, 1)
+
+	me.ClearUserLimits(1)
+	if limits, overridden := me.GetUserLimits(1); overridden || limits != defaults {
+		t.Fatalf("user limits %+v overridden %v, want the defaults", limits, overridden)
+	}
+	if _, _, err := me.PlaceOrder(1, testPair, "buy", 98, 1); err != apperrors.ErrTooManyOpenOrders {
+		t.Fatalf("back on the defaults: got %v, want %v", err, apperrors.ErrTooManyOpenOrders)
+	}
+
+	if err := (OrderLimits{RatioMinOrders: -1}).Validate(); err != apperrors.ErrInvalidOrderLimits {
+		t.Fatalf("negative limit: got %v, want %v", err, apperrors.ErrInvalidOrderLimits)
+	}
+}
@@ -2,8 +2,10 @@ package engine
 
 import (
 	"errors"
+	"math"
 	"mini-crypto-exchange/internal/apperrors"
 	"mini-crypto-exchange/internal/models"
+	"sort"
 	"sync"
 	"time"
 )
@@ -13,32 +15,100 @@ var (
 	ErrPairNotFound = errors.New("trading pair not found")
 )
 
+// TradeListener is notified of every trade executed by the engine
+type TradeListener interface {
+	OnTrade(trade *models.Trade)
+}
+
+// OrderBookListener is notified whenever an order book changes
+type OrderBookListener interface {
+	OnOrderBookUpdate(ob *OrderBook)
+}
+
+// OrderCancelListener is notified of every order the engine cancels, whoever requested it.
+// Orders replaced by ReplaceOrder are not reported.
+type OrderCancelListener interface {
+	OnOrderCancelled(order *models.Order)
+}
+
 // MatchingEngine manages order books and matching logic
 type MatchingEngine struct {
-	orderBooks  map[string]*OrderBook
-	mu          sync.RWMutex
-	nextOrderID int64
-	orders      map[int64]*models.Order
-	trades      []*models.Trade
+	orderBooks    map[string]*OrderBook
+	pairs         map[string]models.TradingPair
+	pairStatus    map[string]models.PairStatus
+	matching      map[string]MatchingConfig
+	allocators    map[string]Allocator
+	precision     map[string]Precision
+	auctionEnds   map[string]time.Time // scheduled ends of running call auctions
+	pairRisk      map[string]*pairRisk
+	defaultRisk   RiskConfig
+	breakerEvents []models.CircuitBreakerEvent
+	mu            sync.RWMutex
+	nextOrderID   int64
+	orders        map[int64]*models.Order
+	// userOrders indexes each user's orders in ID order
+	userOrders map[int64][]*models.Order
+	// clientOrderIDs maps each user's client order IDs to the engine order holding them
+	clientOrderIDs map[int64]map[string]int64
+	trades         []*models.Trade
+	listeners      []TradeListener
+	bookListeners  []OrderBookListener
+	// cancelListeners are notified of cancelled orders
+	cancelListeners []OrderCancelListener
+
+	auctionListeners []AuctionListener
+
+	defaultLimits OrderLimits
+	userLimits    map[int64]OrderLimits
+	userActivity  map[int64]*userActivity
 }
 
 // NewMatchingEngine creates a new matching engine
 func NewMatchingEngine() *MatchingEngine {
 	return &MatchingEngine{
-		orderBooks:  make(map[string]*OrderBook),
-		nextOrderID: 1,
-		orders:      make(map[int64]*models.Order),
-		trades:      make([]*models.Trade, 0),
+		orderBooks:     make(map[string]*OrderBook),
+		pairs:          make(map[string]models.TradingPair),
+		pairStatus:     make(map[string]models.PairStatus),
+		matching:       make(map[string]MatchingConfig),
+		allocators:     make(map[string]Allocator),
+		precision:      make(map[string]Precision),
+		auctionEnds:    make(map[string]time.Time),
+		pairRisk:       make(map[string]*pairRisk),
+		nextOrderID:    1,
+		orders:         make(map[int64]*models.Order),
+		userOrders:     make(map[int64][]*models.Order),
+		clientOrderIDs: make(map[int64]map[string]int64),
+		trades:         make([]*models.Trade, 0),
+		userLimits:     make(map[int64]OrderLimits),
+		userActivity:   make(map[int64]*userActivity),
 	}
 }
 
-// CreatePair creates a new trading pair
-func (me *MatchingEngine) CreatePair(pair string) {
+// CreatePair creates a new continuously matched trading pair with DefaultPrecision
+func (me *MatchingEngine) CreatePair(pair models.TradingPair) error {
+	_, err := me.CreatePairWithMatching(pair, MatchingConfig{Mode: MatchingModeContinuous}, DefaultPrecision)
+	return err
+}
+
+// AddTradeListener registers a listener for executed trades
+func (me *MatchingEngine) AddTradeListener(listener TradeListener) {
 	me.mu.Lock()
 	defer me.mu.Unlock()
-	if _, exists := me.orderBooks[pair]; !exists {
-		me.orderBooks[pair] = NewOrderBook(pair)
-	}
+	me.listeners = append(me.listeners, listener)
+}
+
+// AddOrderBookListener registers a listener for order book changes
+func (me *MatchingEngine) AddOrderBookListener(listener OrderBookListener) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.bookListeners = append(me.bookListeners, listener)
+}
+
+// AddOrderCancelListener registers a listener for cancelled orders
+func (me *MatchingEngine) AddOrderCancelListener(listener OrderCancelListener) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.cancelListeners = append(me.cancelListeners, listener)
 }
 
 // GetOrderBook returns the order book for a pair
@@ -48,36 +118,197 @@ func (me *MatchingEngine) GetOrderBook(pair string) *OrderBook {
 	return me.orderBooks[pair]
 }
 
+// GetPairs returns the names of all trading pairs in sorted order
+func (me *MatchingEngine) GetPairs() []string {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+
+	pairs := make([]string, 0, len(me.orderBooks))
+	for pair := range me.orderBooks {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+
+	return pairs
+}
+
+// GetTradingPair returns the base and quote of a pair
+func (me *MatchingEngine) GetTradingPair(pair string) (models.TradingPair, bool) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+	tradingPair, exists := me.pairs[pair]
+	return tradingPair, exists
+}
+
 // PlaceOrder places an order and attempts to match it
 func (me *MatchingEngine) PlaceOrder(userID int64, pair string, side string, price float64, quantity float64) (*models.Order, []*models.Trade, error) {
+	return me.PlaceOrderWithClientID(userID, pair, side, price, quantity, "")
+}
+
+// CancelOrder removes an open order owned by userID from its order book
+func (me *MatchingEngine) CancelOrder(userID int64, orderID int64) (*models.Order, error) {
 	me.mu.Lock()
-	ob, exists := me.orderBooks[pair]
+	order, ob, err := me.cancelOrder(userID, orderID)
 	me.mu.Unlock()
 
-	if !exists {
-		return nil, nil, apperrors.ErrPairNotFound
+	if err != nil {
+		return nil, err
 	}
 
-	// Create order
-	order := &models.Order{
-		ID:        me.getNextOrderID(),
-		UserID:    userID,
-		Pair:      pair,
-		Side:      side,
-		Price:     price,
-		Quantity:  quantity,
-		Filled:    0,
-		Status:    "open",
-		CreatedAt: time.Now(),
+	me.notifyCancelled([]*models.Order{order})
+	me.notifyOrderBookUpdate(ob)
+
+	return order, nil
+}
+
+// CancelOrders cancels the listed orders owned by userID, skipping orders that are no longer open,
+// and returns the cancelled orders
+func (me *MatchingEngine) CancelOrders(userID int64, orderIDs []int64) []*models.Order {
+	me.mu.Lock()
+	cancelled, books := me.cancelOrders(userID, orderIDs)
+	me.mu.Unlock()
+
+	me.notifyCancelled(cancelled)
+	for _, ob := range books {
+		me.notifyOrderBookUpdate(ob)
 	}
 
-	// Match order
-	trades := me.matchOrder(ob, order)
+	return cancelled
+}
 
+// CancelAllOrders cancels every open order of userID, limited to pair and side when they are not
+// empty, and returns the cancelled orders. Halted pairs accept cancels, so no open order is kept.
+func (me *MatchingEngine) CancelAllOrders(userID int64, pair string, side string) []*models.Order {
 	me.mu.Lock()
-	me.orders[order.ID] = order
+	orderIDs := make([]int64, 0)
+	for _, order := range me.userOrders[userID] {
+		if order.Remaining() <= 0 || order.Status == "cancelled" {
+			continue
+		}
+		if (pair != "" && order.Pair != pair) || (side != "" && order.Side != side) {
+			continue
+		}
+		orderIDs = append(orderIDs, order.ID)
+	}
+	cancelled, books := me.cancelOrders(userID, orderIDs)
+	me.mu.Unlock()
+
+	me.notifyCancelled(cancelled)
+	for _, ob := range books {
+		me.notifyOrderBookUpdate(ob)
+	}
+
+	return cancelled
+}
+
+// cancelOrders cancels each listed order that can be cancelled and returns them with the books
+// they left. Caller must hold me.mu.
+func (me *MatchingEngine) cancelOrders(userID int64, orderIDs []int64) ([]*models.Order, []*OrderBook) {
+	cancelled := make([]*models.Order, 0, len(orderIDs))
+	books := make([]*OrderBook, 0)
+	seen := make(map[*OrderBook]bool)
+	for _, orderID := range orderIDs {
+		order, ob, err := me.cancelOrder(userID, orderID)
+		if err != nil {
+			continue
+		}
+		cancelled = append(cancelled, order)
+		if !seen[ob] {
+			seen[ob] = true
+			books = append(books, ob)
+		}
+	}
+	return cancelled, books
+}
+
+// ReplaceOrder cancels an open order and places a new one with the given price and total quantity.
+// The quantity already filled on the original order counts towards the new total, and the
+// replacement loses the original order's time priority.
+func (me *MatchingEngine) ReplaceOrder(userID int64, orderID int64, price float64, quantity float64) (*models.Order, []*models.Trade, error) {
+	me.mu.Lock()
+	original, exists := me.orders[orderID]
+	if !exists || original.UserID != userID {
+		me.mu.Unlock()
+		return nil, nil, apperrors.ErrOrderNotFound
+	}
+	if err := me.pairPrecision(original.Pair).Check(price, quantity); err != nil {
+		me.mu.Unlock()
+		return nil, nil, err
+	}
+	if quantity <= original.Filled {
+		me.mu.Unlock()
+		return nil, nil, apperrors.ErrInvalidReplaceQuantity
+	}
+	if err := me.checkLimits(userID, original.Pair, true); err != nil {
+		me.mu.Unlock()
+		return nil, nil, err
+	}
+	price, err := me.applyPriceBand(original.Pair, original.Side, price)
+	if err != nil {
+		me.mu.Unlock()
+		return nil, nil, err
+	}
+	if ob := me.orderBooks[original.Pair]; ob != nil {
+		if err := me.checkCanPlace(ob, original.Side, price); err != nil {
+			me.mu.Unlock()
+			return nil, nil, err
+		}
+	}
+
+	_, ob, err := me.cancelOrder(userID, orderID)
+	if err != nil {
+		me.mu.Unlock()
+		return nil, nil, err
+	}
+
+	// The replacement takes over the original's client order ID
+	order := &models.Order{
+		ID:            me.getNextOrderID(),
+		UserID:        userID,
+		ClientOrderID: original.ClientOrderID,
+		Pair:          original.Pair,
+		Side:          original.Side,
+		Price:         price,
+		Quantity:      quantityOf(stepsOf(quantity) - stepsOf(original.Filled)),
+		Filled:        0,
+		Status:        "open",
+		CreatedAt:     time.Now(),
+	}
+	me.reserveClientOrderID(order)
+
+	trades := me.executeOrder(ob, order)
 	me.mu.Unlock()
 
+	me.notifyTrades(trades)
+	me.notifyOrderBookUpdate(ob)
+
+	return order, trades, nil
+}
+
+// GetOrder returns a snapshot of an order by ID
+func (me *MatchingEngine) GetOrder(orderID int64) (models.Order, bool) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+
+	order, exists := me.orders[orderID]
+	if !exists {
+		return models.Order{}, false
+	}
+	return *order, true
+}
+
+// executeOrder matches a new order, records it and rests any remainder. Caller must hold me.mu.
+func (me *MatchingEngine) executeOrder(ob *OrderBook, order *models.Order) []*models.Trade {
+	// Match order. In batch mode and during a call auction orders only rest until the book uncrosses.
+	trades := make([]*models.Trade, 0)
+	if me.matchesContinuously(ob.Pair) {
+		trades = me.matchOrder(ob, order)
+	}
+
+	me.orders[order.ID] = order
+	me.userOrders[order.UserID] = append(me.userOrders[order.UserID], order)
+	me.recordOrder(order, trades)
+
 	// Update order status
 	if order.Filled == order.Quantity {
 		order.Status = "filled"
@@ -87,106 +318,198 @@ func (me *MatchingEngine) PlaceOrder(userID int64, pair string, side string, pri
 
 	// Add remaining order to book if not fully filled
 	if order.Remaining() > 0 {
-		if side == "buy" {
+		if order.Side == "buy" {
 			ob.AddBuyOrder(order)
 		} else {
 			ob.AddSellOrder(order)
 		}
 	}
 
-	return order, trades, nil
+	return trades
 }
 
-// matchOrder matches an incoming order against the order book
+// cancelOrder removes an open order from its book and marks it cancelled. Caller must hold me.mu.
+func (me *MatchingEngine) cancelOrder(userID int64, orderID int64) (*models.Order, *OrderBook, error) {
+	order, exists := me.orders[orderID]
+	if !exists || order.UserID != userID {
+		return nil, nil, apperrors.ErrOrderNotFound
+	}
+
+	if err := me.checkCanCancel(order.Pair); err != nil {
+		return nil, nil, err
+	}
+
+	ob := me.orderBooks[order.Pair]
+	if order.Remaining() <= 0 || order.Status == "cancelled" || ob == nil || !ob.RemoveOrder(order) {
+		return nil, nil, apperrors.ErrOrderNotOpen
+	}
+	order.Status = "cancelled"
+	me.recordRemoval(order)
+
+	return order, ob, nil
+}
+
+// notifyTrades forwards executed trades to registered listeners
+func (me *MatchingEngine) notifyTrades(trades []*models.Trade) {
+	me.mu.RLock()
+	listeners := me.listeners
+	me.mu.RUnlock()
+
+	for _, trade := range trades {
+		for _, listener := range listeners {
+			listener.OnTrade(trade)
+		}
+	}
+}
+
+// notifyCancelled forwards cancelled orders to registered listeners
+func (me *MatchingEngine) notifyCancelled(orders []*models.Order) {
+	if len(orders) == 0 {
+		return
+	}
+	me.mu.RLock()
+	listeners := me.cancelListeners
+	me.mu.RUnlock()
+
+	for _, order := range orders {
+		for _, listener := range listeners {
+			listener.OnOrderCancelled(order)
+		}
+	}
+}
+
+// notifyOrderBookUpdate forwards an order book change to registered listeners, followed by
+// the new indicative auction state when the pair is in a call auction
+func (me *MatchingEngine) notifyOrderBookUpdate(ob *OrderBook) {
+	me.mu.RLock()
+	listeners := me.bookListeners
+	inAuction := me.pairStatus[ob.Pair] == models.PairStatusAuction
+	var state models.AuctionState
+	if inAuction {
+		state = me.auctionState(ob)
+	}
+	me.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener.OnOrderBookUpdate(ob)
+	}
+	if inAuction {
+		me.notifyAuctionUpdate(state)
+	}
+}
+
+// matchOrder matches an incoming order against the order book, one price level at a time.
+// The pair's allocator splits each level's fill among its resting orders.
 func (me *MatchingEngine) matchOrder(ob *OrderBook, incomingOrder *models.Order) []*models.Trade {
 	trades := make([]*models.Trade, 0)
+	allocator := me.allocators[ob.Pair]
+	if allocator == nil {
+		allocator = FIFOAllocator{}
+	}
+	lotsPerUnit := me.pairPrecision(ob.Pair).LotsPerUnit()
+
+	restingSide := "sell"
+	if incomingOrder.Side == "sell" {
+		restingSide = "buy"
+	}
 
-	if incomingOrder.Side == "buy" {
-		// Match buy order against sell orders
-		for incomingOrder.Remaining() > 0 {
+	for incomingOrder.Remaining() > 0 {
+		// Buy orders match the lowest priced sells, sell orders the highest priced buys
+		if incomingOrder.Side == "buy" {
 			bestAsk := ob.GetBestAsk()
 			if bestAsk == nil || bestAsk.Price > incomingOrder.Price {
 				break
 			}
-
-			// Match quantity
-			matchQty := incomingOrder.Remaining()
-			if bestAsk.Remaining() < matchQty {
-				matchQty = bestAsk.Remaining()
-			}
-
-			// Update filled amounts
-			incomingOrder.Filled += matchQty
-			bestAsk.Filled += matchQty
-
-			// Create trade
-			trade := &models.Trade{
-				ID:          ob.GetNextTradeID(),
-				BuyOrderID:  incomingOrder.ID,
-				SellOrderID: bestAsk.ID,
-				Pair:        ob.Pair,
-				Price:       bestAsk.Price,
-				Quantity:    matchQty,
-				CreatedAt:   time.Now(),
-			}
-			trades = append(trades, trade)
-			me.trades = append(me.trades, trade)
-
-			// Remove filled sell order
-			if bestAsk.Remaining() == 0 {
-				ob.RemoveBestAsk()
-				bestAsk.Status = "filled"
-			} else {
-				bestAsk.Status = "partial"
-			}
-		}
-	} else {
-		// Match sell order against buy orders
-		for incomingOrder.Remaining() > 0 {
+		} else {
 			bestBid := ob.GetBestBid()
 			if bestBid == nil || bestBid.Price < incomingOrder.Price {
 				break
 			}
+		}
 
-			// Match quantity
-			matchQty := incomingOrder.Remaining()
-			if bestBid.Remaining() < matchQty {
-				matchQty = bestBid.Remaining()
+		level := ob.RemoveBestLevel(restingSide)
+		levelQty := 0.0
+		for _, resting := range level {
+			levelQty += resting.Remaining()
+		}
+
+		// Match quantity
+		matchQty := math.Min(incomingOrder.Remaining(), levelQty)
+		allocations := allocator.Allocate(matchQty, level, lotsPerUnit)
+
+		matched := false
+		for i, resting := range level {
+			if allocations[i] > 0 {
+				matched = true
+
+				// Update filled amounts
+				fill(incomingOrder, allocations[i])
+				fill(resting, allocations[i])
+
+				// Create trade at the resting order's price
+				trade := &models.Trade{
+					ID:          ob.GetNextTradeID(),
+					BuyOrderID:  incomingOrder.ID,
+					SellOrderID: resting.ID,
+					Pair:        ob.Pair,
+					Price:       resting.Price,
+					Quantity:    allocations[i],
+					CreatedAt:   time.Now(),
+				}
+				if restingSide == "buy" {
+					trade.BuyOrderID, trade.SellOrderID = resting.ID, incomingOrder.ID
+				}
+				trades = append(trades, trade)
+				me.trades = append(me.trades, trade)
+
+				if resting.Remaining() == 0 {
+					resting.Status = "filled"
+				} else {
+					resting.Status = "partial"
+				}
 			}
 
-			// Update filled amounts
-			incomingOrder.Filled += matchQty
-			bestBid.Filled += matchQty
-
-			// Create trade
-			trade := &models.Trade{
-				ID:          ob.GetNextTradeID(),
-				BuyOrderID:  bestBid.ID,
-				SellOrderID: incomingOrder.ID,
-				Pair:        ob.Pair,
-				Price:       bestBid.Price,
-				Quantity:    matchQty,
-				CreatedAt:   time.Now(),
+			// Put back orders that were not filled, keeping their time priority
+			if resting.Remaining() > 0 {
+				if restingSide == "buy" {
+					ob.AddBuyOrder(resting)
+				} else {
+					ob.AddSellOrder(resting)
+				}
 			}
-			trades = append(trades, trade)
-			me.trades = append(me.trades, trade)
-
-			// Remove filled buy order
-			if bestBid.Remaining() == 0 {
-				ob.RemoveBestBid()
-				bestBid.Status = "filled"
-			} else {
-				bestBid.Status = "partial"
+		}
+
+		// Nothing left to allocate at lot precision
+		if !matched {
+			break
+		}
+
+		// Stop sweeping once the circuit breaker halts the pair. The remainder rests at the trigger
+		// price instead of its limit, so it cannot sweep the levels beyond it when the pair reopens.
+		if me.recordTradePrice(ob.Pair, level[0].Price, time.Now()) {
+			if incomingOrder.Remaining() > 0 {
+				incomingOrder.Price = level[0].Price
 			}
+			break
 		}
 	}
 
 	return trades
 }
 
+// fill adds a traded quantity to an order's filled quantity. Fills are counted in steps of
+// 1/stepsPerUnit so the trades of an order add up to exactly its quantity once all of it traded.
+func fill(order *models.Order, quantity float64) {
+	filled := stepsOf(order.Filled) + stepsOf(quantity)
+	if filled == stepsOf(order.Quantity) {
+		order.Filled = order.Quantity
+	} else {
+		order.Filled = quantityOf(filled)
+	}
+}
+
+// getNextOrderID allocates an order ID. Caller must hold me.mu.
 func (me *MatchingEngine) getNextOrderID() int64 {
-	me.mu.Lock()
-	defer me.mu.Unlock()
 	id := me.nextOrderID
 	me.nextOrderID++
 	return id
@@ -198,18 +521,17 @@ func (me *MatchingEngine) GetTrades() []*models.Trade {
 	return me.trades
 }
 
-// GetOrdersByUser returns all orders for a specific user across all trading pairs
+// GetOrdersByUser returns all orders for a specific user across all trading pairs in ID order
 func (me *MatchingEngine) GetOrdersByUser(userID int64) []*models.Order {
 	me.mu.RLock()
 	defer me.mu.RUnlock()
 
-	var userOrders []*models.Order
-
-	for _, o := range me.orders {
-		if o.UserID == userID {
-			userOrders = append(userOrders, o)
-		}
+	index := me.userOrders[userID]
+	if len(index) == 0 {
+		return nil
 	}
+	userOrders := make([]*models.Order, len(index))
+	copy(userOrders, index)
 
 	return userOrders
 }
@@ -79,6 +79,49 @@ func (ob *OrderBook) RemoveBestAsk() *models.Order {
 	return heap.Pop(&ob.SellHeap).(*models.Order)
 }
 
+// RemoveBestLevel removes and returns every order at the best price on one side, in time priority
+func (ob *OrderBook) RemoveBestLevel(side string) []*models.Order {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	var level []*models.Order
+	if side == "buy" {
+		for len(ob.BuyHeap) > 0 && (len(level) == 0 || ob.BuyHeap[0].Price == level[0].Price) {
+			level = append(level, heap.Pop(&ob.BuyHeap).(*models.Order))
+		}
+		return level
+	}
+
+	for len(ob.SellHeap) > 0 && (len(level) == 0 || ob.SellHeap[0].Price == level[0].Price) {
+		level = append(level, heap.Pop(&ob.SellHeap).(*models.Order))
+	}
+	return level
+}
+
+// RemoveOrder removes a specific resting order, reporting whether it was found
+func (ob *OrderBook) RemoveOrder(order *models.Order) bool {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if order.Side == "buy" {
+		for i, o := range ob.BuyHeap {
+			if o == order {
+				heap.Remove(&ob.BuyHeap, i)
+				return true
+			}
+		}
+		return false
+	}
+
+	for i, o := range ob.SellHeap {
+		if o == order {
+			heap.Remove(&ob.SellHeap, i)
+			return true
+		}
+	}
+	return false
+}
+
 // GetDepth returns the order book depth (aggregated by price level)
 func (ob *OrderBook) GetDepth(depth int) (buys []map[string]interface{}, sells []map[string]interface{}) {
 	ob.mu.Lock()
@@ -130,3 +173,16 @@ func (ob *OrderBook) GetNextTradeID() int64 {
 	ob.nextTradeID++
 	return id
 }
+
+// RemoveAll removes and returns every resting order
+func (ob *OrderBook) RemoveAll() []*models.Order {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	orders := make([]*models.Order, 0, len(ob.BuyHeap)+len(ob.SellHeap))
+	orders = append(orders, ob.BuyHeap...)
+	orders = append(orders, ob.SellHeap...)
+	ob.BuyHeap = make(BuyHeap, 0)
+	ob.SellHeap = make(SellHeap, 0)
+	return orders
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"mini-crypto-exchange/internal/models"
+	"sort"
+	"time"
+)
+
+// OrderQuery filters and pages a user's orders. Empty filters and zero times match everything.
+type OrderQuery struct {
+	Pair       string
+	Side       string
+	Status     string
+	Start      time.Time // orders created at or after
+	End        time.Time // orders created before
+	Descending bool      // newest first
+	AfterID    int64     // continue after this order ID in the query's order, 0 to start at the beginning
+	Limit      int
+}
+
+// matches reports whether an order passes the query's filters
+func (q OrderQuery) matches(order *models.Order) bool {
+	switch {
+	case q.Pair != "" && order.Pair != q.Pair:
+		return false
+	case q.Side != "" && order.Side != q.Side:
+		return false
+	case q.Status != "" && order.Status != q.Status:
+		return false
+	case !q.Start.IsZero() && order.CreatedAt.Before(q.Start):
+		return false
+	case !q.End.IsZero() && !order.CreatedAt.Before(q.End):
+		return false
+	}
+	return true
+}
+
+// QueryOrders returns snapshots of up to query.Limit of the user's orders matching the query,
+// ordered by ID, and whether more matching orders follow
+func (me *MatchingEngine) QueryOrders(userID int64, query OrderQuery) ([]models.Order, bool) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+
+	index := me.userOrders[userID]
+	orders := make([]models.Order, 0)
+
+	// Start after the cursor, walking the ID ordered index in the requested direction
+	step := 1
+	i := 0
+	if query.AfterID > 0 {
+		i = sort.Search(len(index), func(n int) bool { return index[n].ID > query.AfterID })
+	}
+	if query.Descending {
+		step = -1
+		i = len(index) - 1
+		if query.AfterID > 0 {
+			i = sort.Search(len(index), func(n int) bool { return index[n].ID >= query.AfterID }) - 1
+		}
+	}
+
+	for ; i >= 0 && i < len(index); i += step {
+		if !query.matches(index[i]) {
+			continue
+		}
+		if len(orders) == query.Limit {
+			return orders, true
+		}
+		orders = append(orders, *index[i])
+	}
+	return orders, false
+}
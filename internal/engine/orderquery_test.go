@@ -0,0 +1,210 @@
+package engine
+
+import (
+	"mini-crypto-exchange/internal/models"
+	"testing"
+	"time"
+)
+
+// newOrderQueryTestEngine places a fixed set of user 1 orders over two pairs, interleaved with
+// orders of user 2, and returns user 1's orders in placement order
+func newOrderQueryTestEngine(t *testing.T) (*MatchingEngine, []*models.Order) {
+	t.Helper()
+	me := newTestEngine(t)
+	if err := me.CreatePair(models.TradingPair{Base: "ETH", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	place := func(userID int64, pair string, side string, price float64, quantity float64) *models.Order {
+		order, _, err := me.PlaceOrder(userID, pair, side, price, quantity)
+		if err != nil {
+			t.Fatalf("PlaceOrder: %v", err)
+		}
+		return order
+	}
+
+	orders := []*models.Order{
+		place(1, testPair, "buy", 100, 1),  // open
+		place(1, testPair, "sell", 110, 1), // open
+		place(1, "ETH/USDT", "buy", 10, 1), // open
+		place(1, testPair, "buy", 101, 2),  // partial
+	}
+	place(2, testPair, "sell", 101, 1)
+	orders = append(orders, place(1, "ETH/USDT", "sell", 11, 1)) // filled
+	place(2, "ETH/USDT", "buy", 11, 1)
+	orders = append(orders,
+		place(1, testPair, "buy", 99, 1),    // cancelled
+		place(1, "ETH/USDT", "buy", 9, 1),   // open
+		place(1, testPair, "sell", 120, 1),  // open
+		place(1, "ETH/USDT", "sell", 12, 1), // open
+		place(1, testPair, "buy", 98, 1),    // open
+	)
+	if _, err := me.CancelOrder(1, orders[5].ID); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	return me, orders
+}
+
+// ids returns the IDs of query results
+func ids(orders []models.Order) []int64 {
+	result := make([]int64, len(orders))
+	for i, order := range orders {
+		result[i] = order.ID
+	}
+	return result
+}
+
+// placedIDs returns the IDs of placed orders
+func placedIDs(orders []*models.Order) []int64 {
+	result := make([]int64, len(orders))
+	for i, order := range orders {
+		result[i] = order.ID
+	}
+	return result
+}
+
+func expectIDs(t *testing.T, what string, got []int64, want []int64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got orders %v, want %v", what, got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%s: got orders %v, want %v", what, got, want)
+		}
+	}
+}
+
+// pageThrough collects every page of a query, checking each but the last reports more
+func pageThrough(t *testing.T, me *MatchingEngine, query OrderQuery) ([]int64, int) {
+	t.Helper()
+	var all []int64
+	pages := 0
+	for {
+		page, more := me.QueryOrders(1, query)
+		pages++
+		all = append(all, ids(page)...)
+		if !more {
+			return all, pages
+		}
+		if len(page) != query.Limit {
+			t.Fatalf("page %d has %d orders and more follow, want a full page of %d", pages, len(page), query.Limit)
+		}
+		query.AfterID = page[len(page)-1].ID
+		if pages > 20 {
+			t.Fatal("paging does not terminate")
+		}
+	}
+}
+
+func TestQueryOrdersPagesInBothDirections(t *testing.T) {
+	me, orders := newOrderQueryTestEngine(t)
+	ascending := placedIDs(orders)
+	descending := reversed(ascending)
+
+	for _, limit := range []int{1, 3, 5, 10, 11} {
+		all, pages := pageThrough(t, me, OrderQuery{Limit: limit})
+		expectIDs(t, "ascending", all, ascending)
+		if want := (len(orders) + limit - 1) / limit; pages != want {
+			t.Errorf("ascending by %d took %d pages, want %d", limit, pages, want)
+		}
+
+		all, _ = pageThrough(t, me, OrderQuery{Limit: limit, Descending: true})
+		expectIDs(t, "descending", all, descending)
+	}
+}
+
+func TestQueryOrdersBoundaryCursors(t *testing.T) {
+	me, orders := newOrderQueryTestEngine(t)
+	first, last := orders[0].ID, orders[len(orders)-1].ID
+	all := placedIDs(orders)
+
+	tests := []struct {
+		name     string
+		query    OrderQuery
+		want     []int64
+		wantMore bool
+	}{
+		{"ascending after the first", OrderQuery{AfterID: first, Limit: 100}, all[1:], false},
+		{"ascending after the last", OrderQuery{AfterID: last, Limit: 100}, []int64{}, false},
+		{"ascending after an ID past the end", OrderQuery{AfterID: last + 100, Limit: 100}, []int64{}, false},
+		{"descending after the last", OrderQuery{AfterID: last, Descending: true, Limit: 100}, reversed(all[:len(all)-1]), false},
+		{"descending after the first", OrderQuery{AfterID: first, Descending: true, Limit: 100}, []int64{}, false},
+		{"descending after an ID past the end", OrderQuery{AfterID: last + 100, Descending: true, Limit: 2}, reversed(all[len(all)-2:]), true},
+		{"limit equal to what is left", OrderQuery{AfterID: all[6], Limit: 3}, all[7:], false},
+	}
+	for _, tt := range tests {
+		got, more := me.QueryOrders(1, tt.query)
+		expectIDs(t, tt.name, ids(got), tt.want)
+		if more != tt.wantMore {
+			t.Errorf("%s: more %v, want %v", tt.name, more, tt.wantMore)
+		}
+	}
+}
+
+// reversed returns a reversed copy of ids
+func reversed(ids []int64) []int64 {
+	result := make([]int64, len(ids))
+	for i, id := range ids {
+		result[len(ids)-1-i] = id
+	}
+	return result
+}
+
+func TestQueryOrdersFilters(t *testing.T) {
+	me, orders := newOrderQueryTestEngine(t)
+	pick := func(indexes ...int) []int64 {
+		result := make([]int64, len(indexes))
+		for i, index := range indexes {
+			result[i] = orders[index].ID
+		}
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		query OrderQuery
+		want  []int64
+	}{
+		{"pair", OrderQuery{Pair: "ETH/USDT"}, pick(2, 4, 6, 8)},
+		{"side", OrderQuery{Side: "sell"}, pick(1, 4, 7, 8)},
+		{"open", OrderQuery{Status: "open"}, pick(0, 1, 2, 6, 7, 8, 9)},
+		{"partial", OrderQuery{Status: "partial"}, pick(3)},
+		{"filled", OrderQuery{Status: "filled"}, pick(4)},
+		{"cancelled", OrderQuery{Status: "cancelled"}, pick(5)},
+		{"pair and side", OrderQuery{Pair: testPair, Side: "buy"}, pick(0, 3, 5, 9)},
+		{"pair, side and status", OrderQuery{Pair: testPair, Side: "buy", Status: "open"}, pick(0, 9)},
+		{"no match", OrderQuery{Pair: "ETH/USDT", Status: "partial"}, []int64{}},
+	}
+	for _, tt := range tests {
+		tt.query.Limit = 100
+		got, more := me.QueryOrders(1, tt.query)
+		expectIDs(t, tt.name, ids(got), tt.want)
+		if more {
+			t.Errorf("%s: more reported past the last match", tt.name)
+		}
+	}
+
+	// Filtered pages only count matching orders
+	all, _ := pageThrough(t, me, OrderQuery{Status: "open", Limit: 2, Descending: true})
+	expectIDs(t, "open orders by twos", all, reversed(pick(0, 1, 2, 6, 7, 8, 9)))
+}
+
+func TestQueryOrdersTimeRange(t *testing.T) {
+	me := newTestEngine(t)
+	before, _ := mustPlace(t, me, 1, "buy", 100, 1)
+	time.Sleep(2 * time.Millisecond)
+	start := time.Now()
+	inside, _ := mustPlace(t, me, 1, "buy", 99, 1)
+	time.Sleep(2 * time.Millisecond)
+	end := time.Now()
+	after, _ := mustPlace(t, me, 1, "buy", 98, 1)
+
+	got, _ := me.QueryOrders(1, OrderQuery{Start: start, End: end, Limit: 100})
+	expectIDs(t, "between start and end", ids(got), []int64{inside.ID})
+	got, _ = me.QueryOrders(1, OrderQuery{Start: start, Limit: 100})
+	expectIDs(t, "from start", ids(got), []int64{inside.ID, after.ID})
+	got, _ = me.QueryOrders(1, OrderQuery{End: end, Limit: 100})
+	expectIDs(t, "until end", ids(got), []int64{before.ID, inside.ID})
+	got, _ = me.QueryOrders(1, OrderQuery{End: inside.CreatedAt, Limit: 100})
+	expectIDs(t, "end is exclusive", ids(got), []int64{before.ID})
+}
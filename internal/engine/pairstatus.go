@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"time"
+)
+
+// GetPairStatus returns a pair's lifecycle status
+func (me *MatchingEngine) GetPairStatus(pair string) (models.PairStatus, bool) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+	status, exists := me.pairStatus[pair]
+	return status, exists
+}
+
+// SetPairStatus moves a pair to a new status. Delisting cancels every resting order
+// in the pair's book and returns them; a delisted pair cannot change status again.
+// Moving to trading or post_only uncrosses a book left crossed by a call auction first.
+func (me *MatchingEngine) SetPairStatus(pair string, status models.PairStatus) ([]*models.Order, error) {
+	if !status.IsValid() {
+		return nil, apperrors.ErrInvalidPairStatus
+	}
+
+	me.mu.Lock()
+	ob, exists := me.orderBooks[pair]
+	if !exists {
+		me.mu.Unlock()
+		return nil, apperrors.ErrPairNotFound
+	}
+	current := me.pairStatus[pair]
+	if current == models.PairStatusDelisted && status != models.PairStatusDelisted {
+		me.mu.Unlock()
+		return nil, apperrors.ErrInvalidPairTransition
+	}
+	me.pairStatus[pair] = status
+	if risk := me.pairRisk[pair]; risk != nil {
+		// An admin decision overrides any pending breaker resume
+		risk.haltedAt = time.Time{}
+	}
+	// and any scheduled auction end
+	delete(me.auctionEnds, pair)
+
+	var cancelled []*models.Order
+	var trades []*models.Trade
+	var auctionState models.AuctionState
+	switch status {
+	case models.PairStatusDelisted:
+		cancelled = ob.RemoveAll()
+		for _, order := range cancelled {
+			order.Status = "cancelled"
+			me.recordRemoval(order)
+		}
+	case models.PairStatusTrading, models.PairStatusPostOnly:
+		trades, auctionState = me.uncross(ob)
+		if len(trades) > 0 {
+			me.restartPriceWindow(pair, auctionState.IndicativePrice, trades[0].CreatedAt)
+		}
+	case models.PairStatusAuction:
+		auctionState = me.auctionState(ob)
+	}
+	me.mu.Unlock()
+
+	me.notifyCancelled(cancelled)
+	me.notifyTrades(trades)
+	if len(cancelled) > 0 || len(trades) > 0 {
+		me.notifyOrderBookUpdate(ob)
+	}
+	if status == models.PairStatusAuction || current == models.PairStatusAuction {
+		me.notifyAuctionUpdate(auctionState)
+	}
+
+	return cancelled, nil
+}
+
+// checkCanPlace verifies the pair's status accepts a new or amended order. Caller must hold me.mu.
+func (me *MatchingEngine) checkCanPlace(ob *OrderBook, side string, price float64) error {
+	switch me.pairStatus[ob.Pair] {
+	case models.PairStatusHalted:
+		return apperrors.ErrPairHalted
+	case models.PairStatusCancelOnly:
+		return apperrors.ErrPairCancelOnly
+	case models.PairStatusDelisted:
+		return apperrors.ErrPairDelisted
+	case models.PairStatusPostOnly:
+		if side == "buy" {
+			if bestAsk := ob.GetBestAsk(); bestAsk != nil && price >= bestAsk.Price {
+				return apperrors.ErrPostOnlyWouldCross
+			}
+		} else if bestBid := ob.GetBestBid(); bestBid != nil && price <= bestBid.Price {
+			return apperrors.ErrPostOnlyWouldCross
+		}
+	}
+	return nil
+}
+
+// checkCanCancel verifies the pair's status accepts cancels. Every status but delisted does, so
+// users can pull their orders while a pair is halted. Caller must hold me.mu.
+func (me *MatchingEngine) checkCanCancel(pair string) error {
+	if me.pairStatus[pair] == models.PairStatusDelisted {
+		return apperrors.ErrPairDelisted
+	}
+	return nil
+}
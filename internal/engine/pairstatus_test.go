@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"testing"
+)
+
+func mustSetStatus(t *testing.T, me *MatchingEngine, status models.PairStatus) []*models.Order {
+	t.Helper()
+	cancelled, err := me.SetPairStatus(testPair, status)
+	if err != nil {
+		t.Fatalf("SetPairStatus %s: %v", status, err)
+	}
+	return cancelled
+}
+
+func TestPairStatusTransitions(t *testing.T) {
+	me := newTestEngine(t)
+
+	for _, status := range []models.PairStatus{models.PairStatusHalted, models.PairStatusCancelOnly, models.PairStatusPostOnly, models.PairStatusAuction, models.PairStatusTrading} {
+		mustSetStatus(t, me, status)
+		if current, _ := me.GetPairStatus(testPair); current != status {
+			t.Fatalf("got status %s, want %s", current, status)
+		}
+	}
+
+	if _, err := me.SetPairStatus(testPair, "closed"); err != apperrors.ErrInvalidPairStatus {
+		t.Fatalf("unknown status: got %v, want %v", err, apperrors.ErrInvalidPairStatus)
+	}
+	if _, err := me.SetPairStatus("ETH/USDT", models.PairStatusHalted); err != apperrors.ErrPairNotFound {
+		t.Fatalf("unknown pair: got %v, want %v", err, apperrors.ErrPairNotFound)
+	}
+
+	mustSetStatus(t, me, models.PairStatusDelisted)
+	if _, err := me.SetPairStatus(testPair, models.PairStatusTrading); err != apperrors.ErrInvalidPairTransition {
+		t.Fatalf("relisting: got %v, want %v", err, apperrors.ErrInvalidPairTransition)
+	}
+	if _, err := me.SetPairStatus(testPair, models.PairStatusDelisted); err != nil {
+		t.Fatalf("delisting again: %v", err)
+	}
+}
+
+func TestOrderEntryFollowsPairStatus(t *testing.T) {
+	tests := []struct {
+		status models.PairStatus
+		want   error
+	}{
+		{models.PairStatusTrading, nil},
+		{models.PairStatusAuction, nil},
+		{models.PairStatusHalted, apperrors.ErrPairHalted},
+		{models.PairStatusCancelOnly, apperrors.ErrPairCancelOnly},
+		{models.PairStatusDelisted, apperrors.ErrPairDelisted},
+	}
+	for _, tt := range tests {
+		me := newTestEngine(t)
+		resting, _ := mustPlace(t, me, 1, "buy", 100, 1)
+		mustSetStatus(t, me, tt.status)
+
+		if _, _, err := me.PlaceOrder(1, testPair, "buy", 99, 1); err != tt.want {
+			t.Errorf("%s: place got %v, want %v", tt.status, err, tt.want)
+		}
+		if tt.status == models.PairStatusDelisted {
+			continue
+		}
+		if _, _, err := me.ReplaceOrder(1, resting.ID, 98, 1); err != tt.want {
+			t.Errorf("%s: amend got %v, want %v", tt.status, err, tt.want)
+		}
+	}
+}
+
+func TestPostOnlyRejectsCrossingOrders(t *testing.T) {
+	me := newTestEngine(t)
+	mustPlace(t, me, 1, "sell", 100, 1)
+	mustSetStatus(t, me, models.PairStatusPostOnly)
+
+	if _, _, err := me.PlaceOrder(2, testPair, "buy", 100, 1); err != apperrors.ErrPostOnlyWouldCross {
+		t.Fatalf("crossing buy: got %v, want %v", err, apperrors.ErrPostOnlyWouldCross)
+	}
+	mustPlace(t, me, 2, "buy", 99, 1)
+}
+
+func TestCancelsAreAcceptedWhileHalted(t *testing.T) {
+	me := newTestEngine(t)
+	first, _ := mustPlace(t, me, 1, "buy", 100, 1)
+	second, _ := mustPlace(t, me, 1, "sell", 110, 1)
+	third, _ := mustPlace(t, me, 1, "sell", 120, 1)
+	mustSetStatus(t, me, models.PairStatusHalted)
+
+	if _, err := me.CancelOrder(1, first.ID); err != nil {
+		t.Fatalf("CancelOrder while halted: %v", err)
+	}
+	if cancelled := me.CancelOrders(1, []int64{second.ID}); len(cancelled) != 1 {
+		t.Fatalf("CancelOrders while halted cancelled %d orders, want 1", len(cancelled))
+	}
+	if cancelled := me.CancelAllOrders(1, "", ""); len(cancelled) != 1 || cancelled[0].ID != third.ID {
+		t.Fatalf("CancelAllOrders while halted cancelled %v, want order %d", cancelled, third.ID)
+	}
+}
+
+func TestCancelsAreAcceptedDuringBreakerHalt(t *testing.T) {
+	me := newTestEngine(t)
+	if err := me.SetRiskConfig(testPair, RiskConfig{BreakerPercent: 5, BreakerWindowSeconds: 60, CoolDownSeconds: 3600}, 0); err != nil {
+		t.Fatalf("SetRiskConfig: %v", err)
+	}
+	resting, _ := mustPlace(t, me, 3, "buy", 90, 1)
+	mustPlace(t, me, 1, "sell", 100, 1)
+	mustPlace(t, me, 2, "buy", 100, 1)
+	mustPlace(t, me, 1, "sell", 110, 1)
+	mustPlace(t, me, 2, "buy", 110, 1)
+	if status, _ := me.GetPairStatus(testPair); status != models.PairStatusHalted {
+		t.Fatalf("pair status %s, want halted by the breaker", status)
+	}
+
+	if _, err := me.CancelOrder(3, resting.ID); err != nil {
+		t.Fatalf("CancelOrder during a breaker halt: %v", err)
+	}
+}
+
+func TestDelistingCancelsRestingOrders(t *testing.T) {
+	me := newTestEngine(t)
+	bid, _ := mustPlace(t, me, 1, "buy", 100, 1)
+	ask, _ := mustPlace(t, me, 2, "sell", 110, 1)
+
+	if cancelled := mustSetStatus(t, me, models.PairStatusDelisted); len(cancelled) != 2 {
+		t.Fatalf("delisting cancelled %d orders, want 2", len(cancelled))
+	}
+	for _, order := range []*models.Order{bid, ask} {
+		if order.Status != "cancelled" {
+			t.Errorf("order %d is %s, want cancelled", order.ID, order.Status)
+		}
+	}
+	if _, err := me.CancelOrder(1, bid.ID); err != apperrors.ErrPairDelisted {
+		t.Fatalf("cancel after delisting: got %v, want %v", err, apperrors.ErrPairDelisted)
+	}
+}
@@ -0,0 +1,325 @@
+package engine
+
+import (
+	"log"
+	"math"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Price band modes
+const (
+	BandModeReject = "reject" // orders priced outside the band are rejected
+	BandModeCap    = "cap"    // orders priced outside the band are repriced to its edge
+)
+
+// RiskConfig holds a pair's price band and circuit breaker settings. Zero percentages disable them.
+type RiskConfig struct {
+	// PriceBandPercent is how far from the reference price orders may be priced
+	PriceBandPercent float64 `json:"price_band_percent"`
+	BandMode         string  `json:"band_mode"`
+	// BreakerPercent is the price move within the breaker window that halts the pair for the cool-down
+	BreakerPercent       float64 `json:"breaker_percent"`
+	BreakerWindowSeconds int64   `json:"breaker_window_seconds"`
+	CoolDownSeconds      int64   `json:"cool_down_seconds"`
+	// ReopeningAuctionSeconds is how long the call auction after a breaker cool-down runs
+	// before continuous trading resumes, 0 resumes trading directly
+	ReopeningAuctionSeconds int64 `json:"reopening_auction_seconds"`
+}
+
+// BreakerWindow returns the breaker window as a duration
+func (c RiskConfig) BreakerWindow() time.Duration {
+	return time.Duration(c.BreakerWindowSeconds) * time.Second
+}
+
+// CoolDown returns the breaker cool-down as a duration
+func (c RiskConfig) CoolDown() time.Duration {
+	return time.Duration(c.CoolDownSeconds) * time.Second
+}
+
+// ReopeningAuction returns the re-opening auction length as a duration
+func (c RiskConfig) ReopeningAuction() time.Duration {
+	return time.Duration(c.ReopeningAuctionSeconds) * time.Second
+}
+
+// Validate rejects negative settings and unknown band modes
+func (c RiskConfig) Validate() error {
+	if c.PriceBandPercent < 0 || c.BreakerPercent < 0 || c.BreakerWindowSeconds < 0 || c.CoolDownSeconds < 0 || c.ReopeningAuctionSeconds < 0 {
+		return apperrors.ErrInvalidRiskConfig
+	}
+	if c.BandMode != BandModeReject && c.BandMode != BandModeCap {
+		return apperrors.ErrInvalidRiskConfig
+	}
+	return nil
+}
+
+// RiskConfigFromEnv builds the default risk settings for new pairs from RISK_PRICE_BAND_PERCENT,
+// RISK_BAND_MODE, RISK_BREAKER_PERCENT, and the Go durations RISK_BREAKER_WINDOW, RISK_COOL_DOWN
+// and RISK_REOPENING_AUCTION. Bands and the breaker are off unless their percentage is set here or
+// per pair; the window, cool-down and auction defaults apply once the breaker is enabled.
+func RiskConfigFromEnv() RiskConfig {
+	config := RiskConfig{
+		BandMode:             BandModeReject,
+		BreakerWindowSeconds: 300,
+		CoolDownSeconds:      120,
+
+		ReopeningAuctionSeconds: 30,
+	}
+	if value := os.Getenv("RISK_PRICE_BAND_PERCENT"); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed >= 0 {
+			config.PriceBandPercent = parsed
+		} else {
+			log.Printf("Ignoring invalid RISK_PRICE_BAND_PERCENT %q", value)
+		}
+	}
+	if value := os.Getenv("RISK_BAND_MODE"); value == BandModeReject || value == BandModeCap {
+		config.BandMode = value
+	} else if value != "" {
+		log.Printf("Ignoring invalid RISK_BAND_MODE %q", value)
+	}
+	if value := os.Getenv("RISK_BREAKER_PERCENT"); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed >= 0 {
+			config.BreakerPercent = parsed
+		} else {
+			log.Printf("Ignoring invalid RISK_BREAKER_PERCENT %q", value)
+		}
+	}
+	for name, target := range map[string]*int64{
+		"RISK_BREAKER_WINDOW":    &config.BreakerWindowSeconds,
+		"RISK_COOL_DOWN":         &config.CoolDownSeconds,
+		"RISK_REOPENING_AUCTION": &config.ReopeningAuctionSeconds,
+	} {
+		if value := os.Getenv(name); value != "" {
+			if parsed, err := time.ParseDuration(value); err == nil && parsed >= 0 {
+				*target = int64(parsed / time.Second)
+			} else {
+				log.Printf("Ignoring invalid %s %q", name, value)
+			}
+		}
+	}
+	return config
+}
+
+type pricePoint struct {
+	price float64
+	at    time.Time
+}
+
+// pairRisk is a pair's risk settings and the state they are checked against
+type pairRisk struct {
+	config     RiskConfig
+	lastPrice  float64
+	indexPrice float64      // set by admins, takes precedence over the last trade
+	prices     []pricePoint // trades within the breaker window, oldest first
+	haltedAt   time.Time    // when the breaker last halted the pair, zero when not halted by it
+}
+
+// referencePrice is the index price when set, otherwise the last trade price, 0 when neither exists
+func (r *pairRisk) referencePrice() float64 {
+	if r.indexPrice > 0 {
+		return r.indexPrice
+	}
+	return r.lastPrice
+}
+
+// SetDefaultRiskConfig sets the risk settings given to pairs created afterwards
+func (me *MatchingEngine) SetDefaultRiskConfig(config RiskConfig) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.defaultRisk = config
+}
+
+// GetRiskConfig returns a pair's risk settings and current reference price
+func (me *MatchingEngine) GetRiskConfig(pair string) (RiskConfig, float64, error) {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+
+	risk, exists := me.pairRisk[pair]
+	if !exists {
+		return RiskConfig{}, 0, apperrors.ErrPairNotFound
+	}
+	return risk.config, risk.referencePrice(), nil
+}
+
+// SetRiskConfig replaces a pair's risk settings. A positive indexPrice becomes the reference
+// price for bands; 0 falls back to the last trade price. An empty band mode means reject.
+func (me *MatchingEngine) SetRiskConfig(pair string, config RiskConfig, indexPrice float64) error {
+	if config.BandMode == "" {
+		config.BandMode = BandModeReject
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if indexPrice < 0 {
+		return apperrors.ErrInvalidRiskConfig
+	}
+
+	me.mu.Lock()
+	defer me.mu.Unlock()
+
+	risk, exists := me.pairRisk[pair]
+	if !exists {
+		return apperrors.ErrPairNotFound
+	}
+	risk.config = config
+	risk.indexPrice = indexPrice
+	return nil
+}
+
+// GetCircuitBreakerEvents returns the recorded breaker events for a pair, or all pairs when empty
+func (me *MatchingEngine) GetCircuitBreakerEvents(pair string) []models.CircuitBreakerEvent {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+
+	events := make([]models.CircuitBreakerEvent, 0)
+	for _, event := range me.breakerEvents {
+		if pair == "" || event.Pair == pair {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// applyPriceBand checks an order price against the pair's band, returning the price to use.
+// Caller must hold me.mu.
+func (me *MatchingEngine) applyPriceBand(pair string, side string, price float64) (float64, error) {
+	risk := me.pairRisk[pair]
+	if risk == nil || risk.config.PriceBandPercent <= 0 {
+		return price, nil
+	}
+	reference := risk.referencePrice()
+	if reference <= 0 {
+		return price, nil
+	}
+
+	band := risk.config.PriceBandPercent / 100
+	upper := roundPrice(reference * (1 + band))
+	lower := roundPrice(reference * (1 - band))
+
+	// Only the aggressive side can sweep the book, so buys are bounded above and sells below
+	switch {
+	case side == "buy" && price > upper:
+		if risk.config.BandMode == BandModeCap {
+			return upper, nil
+		}
+		return 0, apperrors.ErrPriceOutsideBand
+	case side == "sell" && price < lower:
+		if risk.config.BandMode == BandModeCap {
+			return lower, nil
+		}
+		return 0, apperrors.ErrPriceOutsideBand
+	}
+	return price, nil
+}
+
+// roundPrice trims floating point noise from computed band edges
+func roundPrice(price float64) float64 {
+	return math.Round(price*1e8) / 1e8
+}
+
+// recordTradePrice updates the reference price and checks the circuit breaker, halting the
+// pair and reporting true when the trade moved the price too far. Caller must hold me.mu.
+func (me *MatchingEngine) recordTradePrice(pair string, price float64, at time.Time) bool {
+	risk := me.pairRisk[pair]
+	if risk == nil {
+		return false
+	}
+	risk.lastPrice = price
+
+	config := risk.config
+	window := config.BreakerWindow()
+	if config.BreakerPercent <= 0 || window <= 0 {
+		return false
+	}
+
+	// Drop prices that fell out of the window
+	cutoff := at.Add(-window)
+	keep := 0
+	for keep < len(risk.prices) && risk.prices[keep].at.Before(cutoff) {
+		keep++
+	}
+	risk.prices = append(risk.prices[keep:], pricePoint{price: price, at: at})
+
+	// Measure the move from the furthest price in the window
+	from := price
+	move := 0.0
+	for _, point := range risk.prices {
+		if change := math.Abs(price-point.price) / point.price * 100; change > move {
+			move = change
+			from = point.price
+		}
+	}
+	if move <= config.BreakerPercent {
+		return false
+	}
+
+	event := models.CircuitBreakerEvent{
+		Pair:           pair,
+		ReferencePrice: from,
+		TriggerPrice:   price,
+		MovePercent:    move,
+		Window:         window.String(),
+		HaltedAt:       at,
+		ResumesAt:      at.Add(config.CoolDown()),
+	}
+	me.breakerEvents = append(me.breakerEvents, event)
+	me.pairStatus[pair] = models.PairStatusHalted
+	risk.haltedAt = at
+	risk.prices = nil
+	log.Printf("Circuit breaker halted %s: price moved %.2f%% from %v to %v within %s, resuming at %s",
+		pair, move, from, price, window, event.ResumesAt.Format(time.RFC3339))
+
+	time.AfterFunc(config.CoolDown(), func() {
+		me.resumeAfterBreaker(pair, at)
+	})
+	return true
+}
+
+// restartPriceWindow makes an auction's uncrossing price the last trade price and starts a fresh
+// breaker window from it rather than measuring it against the prices before the auction.
+// Caller must hold me.mu.
+func (me *MatchingEngine) restartPriceWindow(pair string, price float64, at time.Time) {
+	if risk := me.pairRisk[pair]; risk != nil {
+		risk.lastPrice = price
+		risk.prices = []pricePoint{{price: price, at: at}}
+	}
+}
+
+// resumeAfterBreaker reopens a pair halted by the breaker at haltedAt, through a call auction
+// when one is configured, unless an admin changed its status or a later breaker took over.
+// Without an auction any crossing orders are uncrossed at a single price before trading resumes.
+func (me *MatchingEngine) resumeAfterBreaker(pair string, haltedAt time.Time) {
+	me.mu.Lock()
+	risk := me.pairRisk[pair]
+	if risk == nil || !risk.haltedAt.Equal(haltedAt) || me.pairStatus[pair] != models.PairStatusHalted {
+		me.mu.Unlock()
+		return
+	}
+	risk.haltedAt = time.Time{}
+
+	if auction := risk.config.ReopeningAuction(); auction > 0 {
+		me.startAuction(pair, auction)
+		state := me.auctionState(me.orderBooks[pair])
+		me.mu.Unlock()
+		me.notifyAuctionUpdate(state)
+		return
+	}
+
+	ob := me.orderBooks[pair]
+	me.pairStatus[pair] = models.PairStatusTrading
+	trades, state := me.uncross(ob)
+	if len(trades) > 0 {
+		me.restartPriceWindow(pair, state.IndicativePrice, trades[0].CreatedAt)
+	}
+	me.mu.Unlock()
+	log.Printf("Circuit breaker cool-down ended, %s resumed trading", pair)
+
+	if len(trades) > 0 {
+		log.Printf("%s uncrossed %v at %v on resuming", pair, state.MatchedVolume, state.IndicativePrice)
+		me.notifyTrades(trades)
+		me.notifyOrderBookUpdate(ob)
+	}
+}
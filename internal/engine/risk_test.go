@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"mini-crypto-exchange/internal/models"
+	"testing"
+	"time"
+)
+
+const testPair = "BTC/USDT"
+
+func newTestEngine(t *testing.T) *MatchingEngine {
+	t.Helper()
+	me := NewMatchingEngine()
+	if err := me.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	return me
+}
+
+func mustPlace(t *testing.T, me *MatchingEngine, userID int64, side string, price float64, quantity float64) (*models.Order, []*models.Trade) {
+	t.Helper()
+	order, trades, err := me.PlaceOrder(userID, testPair, side, price, quantity)
+	if err != nil {
+		t.Fatalf("PlaceOrder %s %v@%v: %v", side, quantity, price, err)
+	}
+	return order, trades
+}
+
+func TestRiskConfigFromEnvIsOffByDefault(t *testing.T) {
+	t.Setenv("RISK_PRICE_BAND_PERCENT", "")
+	t.Setenv("RISK_BREAKER_PERCENT", "")
+
+	config := RiskConfigFromEnv()
+	if config.PriceBandPercent != 0 || config.BreakerPercent != 0 {
+		t.Fatalf("default band %v%% and breaker %v%%, want both off", config.PriceBandPercent, config.BreakerPercent)
+	}
+
+	// A fresh pair accepts any price and never halts
+	me := newTestEngine(t)
+	mustPlace(t, me, 1, "sell", 100, 1)
+	mustPlace(t, me, 2, "buy", 100, 1)
+	mustPlace(t, me, 1, "sell", 1000, 1)
+	if _, trades := mustPlace(t, me, 2, "buy", 1000, 1); len(trades) != 1 {
+		t.Fatalf("got %d trades, want 1", len(trades))
+	}
+	if status, _ := me.GetPairStatus(testPair); status != models.PairStatusTrading {
+		t.Fatalf("pair status %s, want trading", status)
+	}
+}
+
+func TestBreakerCapsSweepRemainderAtTriggerPrice(t *testing.T) {
+	me := newTestEngine(t)
+	if err := me.SetRiskConfig(testPair, RiskConfig{BreakerPercent: 5, BreakerWindowSeconds: 60, CoolDownSeconds: 3600}, 0); err != nil {
+		t.Fatalf("SetRiskConfig: %v", err)
+	}
+
+	mustPlace(t, me, 1, "sell", 100, 1)
+	mustPlace(t, me, 2, "buy", 100, 1)
+
+	mustPlace(t, me, 1, "sell", 102, 1)
+	mustPlace(t, me, 1, "sell", 110, 1)
+	mustPlace(t, me, 1, "sell", 130, 1)
+
+	// The fill at 110 trips the breaker, the remainder must not rest at 200 above the 130 ask
+	order, trades := mustPlace(t, me, 2, "buy", 200, 3)
+	if len(trades) != 2 {
+		t.Fatalf("got %d trades, want 2 before the breaker", len(trades))
+	}
+	if status, _ := me.GetPairStatus(testPair); status != models.PairStatusHalted {
+		t.Fatalf("pair status %s, want halted", status)
+	}
+	if order.Price != 110 || order.Remaining() != 1 {
+		t.Fatalf("remainder %v at %v, want 1 at the trigger price 110", order.Remaining(), order.Price)
+	}
+	ob := me.GetOrderBook(testPair)
+	if bestBid, bestAsk := ob.GetBestBid(), ob.GetBestAsk(); bestBid.Price >= bestAsk.Price {
+		t.Fatalf("book crossed after the breaker: bid %v, ask %v", bestBid.Price, bestAsk.Price)
+	}
+}
+
+func TestResumeAfterBreakerUncrosses(t *testing.T) {
+	me := newTestEngine(t)
+	if err := me.SetRiskConfig(testPair, RiskConfig{BreakerPercent: 5, BreakerWindowSeconds: 60, CoolDownSeconds: 3600}, 0); err != nil {
+		t.Fatalf("SetRiskConfig: %v", err)
+	}
+
+	// Orders entered during an auction rest crossed
+	if _, err := me.StartAuction(testPair, 0); err != nil {
+		t.Fatalf("StartAuction: %v", err)
+	}
+	buy, _ := mustPlace(t, me, 2, "buy", 105, 1)
+	sell, _ := mustPlace(t, me, 1, "sell", 95, 1)
+
+	// The breaker halted the crossed book and its cool-down ends without a re-opening auction
+	haltedAt := time.Now()
+	me.mu.Lock()
+	me.pairStatus[testPair] = models.PairStatusHalted
+	me.pairRisk[testPair].haltedAt = haltedAt
+	me.mu.Unlock()
+	me.resumeAfterBreaker(testPair, haltedAt)
+
+	if status, _ := me.GetPairStatus(testPair); status != models.PairStatusTrading {
+		t.Fatalf("pair status %s, want trading", status)
+	}
+	if buy.Status != "filled" || sell.Status != "filled" {
+		t.Fatalf("crossing orders were not uncrossed: buy %s, sell %s", buy.Status, sell.Status)
+	}
+	ob := me.GetOrderBook(testPair)
+	if ob.GetBestBid() != nil || ob.GetBestAsk() != nil {
+		t.Fatal("book still holds the uncrossed orders")
+	}
+}
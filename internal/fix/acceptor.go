@@ -0,0 +1,892 @@
+package fix
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/ratelimit"
+	"mini-crypto-exchange/internal/services"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPort         = "9878"
+	defaultSenderCompID = "MINIEX"
+	defaultHeartBtInt   = 30 * time.Second
+	logonTimeout        = 10 * time.Second
+)
+
+// Config configures the FIX acceptor
+type Config struct {
+	Port         string
+	SenderCompID string
+	HeartBtInt   time.Duration    // used when the counterparty does not send one
+	DataDir      string           // directory for session stores, in memory when empty
+	Sessions     map[string]int64 // counterparty CompID -> exchange user ID
+	// CancelOnDisconnect lists the counterparties whose open orders are cancelled when they
+	// disconnect or stop heartbeating, with the grace period they have to log on again
+	CancelOnDisconnect map[string]time.Duration
+}
+
+// ConfigFromEnv builds a Config from FIX_PORT, FIX_SENDER_COMP_ID, FIX_DATA_DIR,
+// FIX_SESSIONS ("CLIENT1=101,CLIENT2=102") and FIX_CANCEL_ON_DISCONNECT ("CLIENT1=5s,CLIENT2=0s")
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		Port:               os.Getenv("FIX_PORT"),
+		SenderCompID:       os.Getenv("FIX_SENDER_COMP_ID"),
+		HeartBtInt:         defaultHeartBtInt,
+		DataDir:            os.Getenv("FIX_DATA_DIR"),
+		Sessions:           make(map[string]int64),
+		CancelOnDisconnect: make(map[string]time.Duration),
+	}
+	if config.Port == "" {
+		config.Port = defaultPort
+	}
+	if config.SenderCompID == "" {
+		config.SenderCompID = defaultSenderCompID
+	}
+
+	if sessions := os.Getenv("FIX_SESSIONS"); sessions != "" {
+		for _, entry := range strings.Split(sessions, ",") {
+			compID, userIDStr, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok {
+				return config, fmt.Errorf("invalid FIX_SESSIONS entry %q", entry)
+			}
+			userID, err := strconv.ParseInt(userIDStr, 10, 64)
+			if err != nil || userID <= 0 {
+				return config, fmt.Errorf("invalid user ID in FIX_SESSIONS entry %q", entry)
+			}
+			config.Sessions[compID] = userID
+		}
+	}
+
+	if cancelOnDisconnect := os.Getenv("FIX_CANCEL_ON_DISCONNECT"); cancelOnDisconnect != "" {
+		for _, entry := range strings.Split(cancelOnDisconnect, ",") {
+			compID, graceStr, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok {
+				return config, fmt.Errorf("invalid FIX_CANCEL_ON_DISCONNECT entry %q", entry)
+			}
+			grace, err := time.ParseDuration(graceStr)
+			if err != nil || grace < 0 {
+				return config, fmt.Errorf("invalid grace period in FIX_CANCEL_ON_DISCONNECT entry %q", entry)
+			}
+			config.CancelOnDisconnect[compID] = grace
+		}
+	}
+
+	return config, nil
+}
+
+// orderRef tracks an engine order placed through a FIX session
+type orderRef struct {
+	session  *Session
+	orderID  int64
+	clOrdID  string
+	symbol   string
+	side     string
+	price    float64
+	orderQty float64 // total quantity as seen by the client, across replaces
+	cumQty   float64
+	notional float64 // sum of fill price * quantity, for AvgPx
+	closed   bool    // filled, cancelled or replaced
+}
+
+// engineEvent is a trade or a cancelled order queued by the engine callbacks
+type engineEvent struct {
+	trade     *models.Trade
+	cancelled *models.Order
+}
+
+// Acceptor accepts FIX 4.4 sessions and routes their orders into the matching engine
+type Acceptor struct {
+	config            Config
+	engine            *engine.MatchingEngine
+	placeOrderService services.PlaceOrderService
+	apiKeyService     services.APIKeyService
+	limiters          *ratelimit.Limiters
+	listener          net.Listener
+
+	// mu guards sessions and order tracking, and serializes order entry with execution reports
+	mu        sync.Mutex
+	sessions  map[string]*Session // counterparty CompID -> session
+	orders    map[int64]*orderRef // engine order ID -> ref, removed once the order is closed
+	clOrdIDs  map[string]int64    // session ID + ClOrdID -> engine order ID
+	nextExecN int64
+
+	// Trades and cancels are queued by the engine callbacks and reported under mu
+	eventsMu sync.Mutex
+	events   []engineEvent
+	signal   chan struct{}
+	done     chan struct{}
+}
+
+// NewAcceptor creates a FIX acceptor. Logons are authenticated with apiKeyService and draw from the
+// query rate limiter, order entry from the order rate limiter, shared with the REST API.
+func NewAcceptor(config Config, matchingEngine *engine.MatchingEngine, placeOrderService services.PlaceOrderService, apiKeyService services.APIKeyService, limiters *ratelimit.Limiters) *Acceptor {
+	if config.HeartBtInt <= 0 {
+		config.HeartBtInt = defaultHeartBtInt
+	}
+	return &Acceptor{
+		config:            config,
+		engine:            matchingEngine,
+		placeOrderService: placeOrderService,
+		apiKeyService:     apiKeyService,
+		limiters:          limiters,
+		sessions:          make(map[string]*Session),
+		orders:            make(map[int64]*orderRef),
+		clOrdIDs:          make(map[string]int64),
+		signal:            make(chan struct{}, 1),
+		done:              make(chan struct{}),
+	}
+}
+
+// Start listens on the configured port and begins accepting sessions
+func (a *Acceptor) Start() error {
+	listener, err := net.Listen("tcp", ":"+a.config.Port)
+	if err != nil {
+		return err
+	}
+	a.listener = listener
+
+	go a.reportLoop()
+	go a.heartbeatLoop()
+	go a.acceptLoop()
+
+	log.Printf("Starting FIX acceptor %s on port %s", a.config.SenderCompID, a.config.Port)
+	return nil
+}
+
+// Addr returns the listening address
+func (a *Acceptor) Addr() net.Addr {
+	return a.listener.Addr()
+}
+
+// Stop logs out every session and stops accepting connections
+func (a *Acceptor) Stop() error {
+	close(a.done)
+	err := a.listener.Close()
+
+	a.mu.Lock()
+	sessions := make([]*Session, 0, len(a.sessions))
+	for _, session := range a.sessions {
+		sessions = append(sessions, session)
+	}
+	a.mu.Unlock()
+
+	for _, session := range sessions {
+		session.logout("Acceptor shutting down")
+	}
+	return err
+}
+
+// OnTrade queues a trade so fills on FIX orders are reported as execution reports
+func (a *Acceptor) OnTrade(trade *models.Trade) {
+	a.queue(engineEvent{trade: trade})
+}
+
+// OnOrderCancelled queues a cancelled order so FIX orders cancelled outside their session, e.g. by
+// a REST mass cancel, the dead man's switch or a delisting, are reported as execution reports
+func (a *Acceptor) OnOrderCancelled(order *models.Order) {
+	a.queue(engineEvent{cancelled: order})
+}
+
+func (a *Acceptor) queue(event engineEvent) {
+	a.eventsMu.Lock()
+	a.events = append(a.events, event)
+	a.eventsMu.Unlock()
+
+	select {
+	case a.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (a *Acceptor) acceptLoop() {
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			select {
+			case <-a.done:
+				return
+			default:
+			}
+			log.Printf("FIX: accept failed: %v", err)
+			continue
+		}
+		go a.handleConnection(conn)
+	}
+}
+
+func (a *Acceptor) reportLoop() {
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-a.signal:
+			a.mu.Lock()
+			a.processEvents()
+			a.mu.Unlock()
+		}
+	}
+}
+
+func (a *Acceptor) heartbeatLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case now := <-ticker.C:
+			a.mu.Lock()
+			sessions := make([]*Session, 0, len(a.sessions))
+			for _, session := range a.sessions {
+				sessions = append(sessions, session)
+			}
+			a.mu.Unlock()
+
+			for _, session := range sessions {
+				if session.IsLoggedOn() && !session.checkHeartbeat(now) {
+					log.Printf("FIX %s: heartbeat timeout", session.ID)
+					session.logout("Heartbeat timeout")
+				}
+			}
+		}
+	}
+}
+
+// handleConnection performs the logon handshake and then serves the session
+func (a *Acceptor) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(logonTimeout))
+	reader := bufio.NewReader(conn)
+	raw, err := readMessage(reader)
+	if err != nil {
+		log.Printf("FIX: no logon from %s: %v", conn.RemoteAddr(), err)
+		return
+	}
+	logon, err := ParseMessage(raw)
+	if err != nil || logon.MsgType() != MsgTypeLogon {
+		log.Printf("FIX: first message from %s is not a valid logon", conn.RemoteAddr())
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if !a.limiters.Queries.Take(ratelimit.IPKey(auth.RemoteIP(conn.RemoteAddr().String())), 1).Allowed {
+		log.Printf("FIX: logon from %s rejected: rate limited", conn.RemoteAddr())
+		return
+	}
+
+	session, err := a.logon(conn, logon)
+	if err != nil {
+		log.Printf("FIX: logon from %s rejected: %v", conn.RemoteAddr(), err)
+		return
+	}
+	defer func() {
+		session.detach(conn)
+		a.sessionDisconnected(session)
+	}()
+
+	err = readLoop(&bufferedConn{Conn: conn, reader: reader}, func(msg *Message) error {
+		return a.handleMessage(session, msg)
+	})
+	if err != nil && !errors.Is(err, errLoggedOut) && !errors.Is(err, net.ErrClosed) {
+		log.Printf("FIX %s: connection closed: %v", session.ID, err)
+	}
+}
+
+var errLoggedOut = errors.New("logged out")
+
+// Logon signatures are computed like signed REST requests, over timestamp + nonce + LogonMethod +
+// LogonPath with an empty body. The SendingTime in unix milliseconds serves as both timestamp and
+// nonce, so each logon of a key needs its own SendingTime.
+const (
+	LogonMethod = "POST"
+	LogonPath   = "fix.Logon"
+)
+
+// SignLogon computes the Password of a Logon sent at sendingTime with the API key's secret
+func SignLogon(secret string, sendingTime time.Time) string {
+	timestamp := strconv.FormatInt(sendingTime.UnixMilli(), 10)
+	return auth.Sign(secret, timestamp, timestamp, LogonMethod, LogonPath, nil)
+}
+
+// authenticate checks the API key in Username and the signature in Password of a Logon. The key
+// must belong to the session's user and allow trading from the connection's IP.
+func (a *Acceptor) authenticate(conn net.Conn, logon *Message, userID int64) error {
+	sendingTime, err := time.Parse(sendingTimeLayout, logon.Get(TagSendingTime))
+	if err != nil {
+		return fmt.Errorf("invalid SendingTime %q", logon.Get(TagSendingTime))
+	}
+	timestamp := strconv.FormatInt(sendingTime.UnixMilli(), 10)
+	credentials := auth.Credentials{
+		APIKey:    logon.Get(TagUsername),
+		Timestamp: timestamp,
+		Nonce:     timestamp,
+		Signature: logon.Get(TagPassword),
+	}
+	ip := auth.RemoteIP(conn.RemoteAddr().String())
+	apiKey, err := a.apiKeyService.Authenticate(context.Background(), credentials, LogonMethod, LogonPath, nil, ip, auth.PermissionTrade)
+	if err != nil {
+		return err
+	}
+	if apiKey.UserID != userID {
+		return fmt.Errorf("API key %s does not belong to user %d", apiKey.Key, userID)
+	}
+	return nil
+}
+
+// logon validates a Logon, binds the connection to its session and answers it
+func (a *Acceptor) logon(conn net.Conn, logon *Message) (*Session, error) {
+	if logon.Get(TagTargetCompID) != a.config.SenderCompID {
+		return nil, fmt.Errorf("unknown TargetCompID %q", logon.Get(TagTargetCompID))
+	}
+	compID := logon.Get(TagSenderCompID)
+	userID, known := a.config.Sessions[compID]
+	if !known {
+		return nil, fmt.Errorf("unknown SenderCompID %q", compID)
+	}
+	if err := a.authenticate(conn, logon, userID); err != nil {
+		return nil, err
+	}
+
+	heartBtInt := a.config.HeartBtInt
+	if secs, err := logon.GetInt(TagHeartBtInt); err == nil && secs > 0 {
+		heartBtInt = time.Duration(secs) * time.Second
+	}
+
+	session, err := a.getSession(compID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	reset := logon.Get(TagResetSeqNumFlag) == "Y"
+	if reset {
+		if err := session.store.Reset(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := session.attach(conn, heartBtInt); err != nil {
+		return nil, err
+	}
+
+	seqNum, err := logon.GetInt(TagMsgSeqNum)
+	expected := session.store.NextTargetSeqNum()
+	if err != nil || seqNum < expected {
+		session.logout(fmt.Sprintf("MsgSeqNum too low, expecting %d", expected))
+		a.sessionDisconnected(session)
+		return nil, fmt.Errorf("logon MsgSeqNum %d below expected %d", seqNum, expected)
+	}
+
+	response := NewMessage(MsgTypeLogon).
+		SetInt(TagEncryptMethod, 0).
+		SetInt(TagHeartBtInt, int(heartBtInt/time.Second))
+	if reset {
+		response.Set(TagResetSeqNumFlag, "Y")
+	}
+	if err := session.Send(response); err != nil {
+		session.detach(conn)
+		a.sessionDisconnected(session)
+		return nil, err
+	}
+
+	// The logon itself consumes a sequence number; a higher one means we missed messages
+	if _, err := session.verifySeqNum(logon); err != nil {
+		session.logout(err.Error())
+		a.sessionDisconnected(session)
+		return nil, err
+	}
+
+	log.Printf("FIX %s: logged on (user %d)", session.ID, userID)
+	return session, nil
+}
+
+func (a *Acceptor) getSession(compID string, userID int64) (*Session, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if session, exists := a.sessions[compID]; exists {
+		return session, nil
+	}
+
+	var store MessageStore
+	sessionID := a.config.SenderCompID + "-" + compID
+	if a.config.DataDir == "" {
+		store = NewMemoryStore()
+	} else {
+		var err error
+		if store, err = NewFileStore(a.config.DataDir, sessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	session := newSession(a.config.SenderCompID, compID, userID, store)
+	a.sessions[compID] = session
+	return session, nil
+}
+
+// handleMessage applies session level processing and dispatches application messages
+func (a *Acceptor) handleMessage(session *Session, msg *Message) error {
+	session.received()
+
+	if msg.MsgType() == MsgTypeSequenceReset {
+		return a.handleSequenceReset(session, msg)
+	}
+
+	process, err := session.verifySeqNum(msg)
+	if err != nil {
+		session.logout(err.Error())
+		return errLoggedOut
+	}
+	// Logout and ResendRequest are honoured even when they arrive ahead of a gap
+	if !process && msg.MsgType() != MsgTypeLogout && msg.MsgType() != MsgTypeResendRequest {
+		return nil
+	}
+
+	switch msg.MsgType() {
+	case MsgTypeHeartbeat, MsgTypeReject:
+		return nil
+	case MsgTypeTestRequest:
+		return session.Send(NewMessage(MsgTypeHeartbeat).Set(TagTestReqID, msg.Get(TagTestReqID)))
+	case MsgTypeResendRequest:
+		begin, _ := msg.GetInt(TagBeginSeqNo)
+		end, _ := msg.GetInt(TagEndSeqNo)
+		return session.resend(begin, end)
+	case MsgTypeLogout:
+		session.logout("")
+		return errLoggedOut
+	case MsgTypeLogon:
+		return a.sendReject(session, msg, "Session already logged on")
+	case MsgTypeNewOrderSingle:
+		a.handleNewOrderSingle(session, msg)
+	case MsgTypeOrderCancelRequest:
+		a.handleOrderCancelRequest(session, msg)
+	case MsgTypeOrderCancelReplaceRequest:
+		a.handleOrderCancelReplaceRequest(session, msg)
+	default:
+		reject := NewMessage(MsgTypeBusinessMessageReject).
+			Set(TagRefSeqNum, msg.Get(TagMsgSeqNum)).
+			Set(TagRefMsgType, msg.MsgType()).
+			SetInt(TagBusinessRejectReason, 3). // unsupported message type
+			Set(TagText, "Unsupported message type")
+		return session.Send(reject)
+	}
+	return nil
+}
+
+// handleSequenceReset moves the expected inbound sequence number forward
+func (a *Acceptor) handleSequenceReset(session *Session, msg *Message) error {
+	newSeqNo, err := msg.GetInt(TagNewSeqNo)
+	if err != nil {
+		return a.sendReject(session, msg, "Missing NewSeqNo")
+	}
+	if newSeqNo > session.store.NextTargetSeqNum() {
+		return session.store.SetNextTargetSeqNum(newSeqNo)
+	}
+	return nil
+}
+
+func (a *Acceptor) sendReject(session *Session, msg *Message, text string) error {
+	return session.Send(NewMessage(MsgTypeReject).
+		Set(TagRefSeqNum, msg.Get(TagMsgSeqNum)).
+		Set(TagRefMsgType, msg.MsgType()).
+		Set(TagText, text))
+}
+
+func (a *Acceptor) handleNewOrderSingle(session *Session, msg *Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.processEvents()
+
+	clOrdID := msg.Get(TagClOrdID)
+	symbol := msg.Get(TagSymbol)
+	side, sideOK := fromFIXSide(msg.Get(TagSide))
+	price, _ := msg.GetFloat(TagPrice)
+	quantity, _ := msg.GetFloat(TagOrderQty)
+
+	reject := func(reason int, text string) {
+		report := a.newExecutionReport(ExecTypeRejected, OrdStatusRejected).
+			Set(TagOrderID, "NONE").
+			Set(TagClOrdID, clOrdID).
+			Set(TagSymbol, symbol).
+			Set(TagSide, msg.Get(TagSide)).
+			SetFloat(TagOrderQty, quantity).
+			SetFloat(TagLeavesQty, 0).
+			SetFloat(TagCumQty, 0).
+			SetFloat(TagAvgPx, 0).
+			SetInt(TagOrdRejReason, reason).
+			Set(TagText, text)
+		a.send(session, report)
+	}
+
+	if !a.allowOrderEntry(session) {
+		reject(ordRejReasonOther, apperrors.ErrRateLimited.Error())
+		return
+	}
+	if clOrdID == "" {
+		reject(ordRejReasonOther, "ClOrdID is required")
+		return
+	}
+	if _, exists := a.clOrdIDs[session.ID+"|"+clOrdID]; exists {
+		reject(ordRejReasonDuplicate, "Duplicate ClOrdID")
+		return
+	}
+	if msg.Get(TagOrdType) != OrdTypeLimit {
+		reject(ordRejReasonOther, "Only limit orders are supported")
+		return
+	}
+	if !sideOK {
+		side = msg.Get(TagSide)
+	}
+
+	ctx := context.Background()
+	if validationErrors := a.placeOrderService.ValidateRequest(ctx, session.UserID, symbol, side, price, quantity, clOrdID); len(validationErrors) > 0 {
+		messages := make([]string, 0, len(validationErrors))
+		for _, validationErr := range validationErrors {
+			messages = append(messages, validationErr.Code+": "+validationErr.Message)
+		}
+		reject(ordRejReasonOther, strings.Join(messages, "; "))
+		return
+	}
+
+	// The ClOrdID becomes the order's client order ID, shared with REST, gRPC and the binary protocol
+	order, _, err := a.placeOrderService.ProcessRequest(ctx, session.UserID, symbol, side, price, quantity, clOrdID)
+	if err != nil {
+		reason := ordRejReasonOther
+		switch {
+		case errors.Is(err, apperrors.ErrDuplicateClientOrderID):
+			reason = ordRejReasonDuplicate
+		case errors.Is(err, apperrors.ErrPairNotFound):
+			reason = ordRejReasonUnknownSymbol
+		case errors.Is(err, apperrors.ErrTooManyOpenOrders), errors.Is(err, apperrors.ErrOrderToTradeRatioExceeded):
+			reason = ordRejReasonExceedsLimit
+		case errors.Is(err, apperrors.ErrPairHalted), errors.Is(err, apperrors.ErrPairCancelOnly), errors.Is(err, apperrors.ErrPairDelisted):
+			reason = ordRejReasonExchangeClosed
+		}
+		reject(reason, err.Error())
+		return
+	}
+
+	ref := &orderRef{
+		session:  session,
+		orderID:  order.ID,
+		clOrdID:  clOrdID,
+		symbol:   symbol,
+		side:     side,
+		price:    order.Price,
+		orderQty: quantity,
+	}
+	a.orders[order.ID] = ref
+	a.clOrdIDs[session.ID+"|"+clOrdID] = order.ID
+
+	// Fills from matching are queued by OnTrade and reported after this acknowledgement
+	a.send(session, a.orderReport(ref, ExecTypeNew, OrdStatusNew))
+}
+
+func (a *Acceptor) handleOrderCancelRequest(session *Session, msg *Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.processEvents()
+
+	if !a.allowOrderEntry(session) {
+		a.send(session, a.cancelReject(msg, cxlRejResponseToCancel, apperrors.ErrRateLimited.Error()))
+		return
+	}
+	ref, rejectText := a.lookupOrigOrder(session, msg)
+	if ref == nil {
+		a.send(session, a.cancelReject(msg, cxlRejResponseToCancel, rejectText))
+		return
+	}
+
+	if _, err := a.engine.CancelOrder(session.UserID, ref.orderID); err != nil {
+		a.send(session, a.cancelReject(msg, cxlRejResponseToCancel, err.Error()))
+		return
+	}
+
+	a.closeOrder(ref)
+	ref.clOrdID = msg.Get(TagClOrdID)
+	a.clOrdIDs[session.ID+"|"+ref.clOrdID] = ref.orderID
+
+	report := a.orderReport(ref, ExecTypeCanceled, OrdStatusCanceled).
+		Set(TagOrigClOrdID, msg.Get(TagOrigClOrdID)).
+		SetFloat(TagLeavesQty, 0)
+	a.send(session, report)
+}
+
+func (a *Acceptor) handleOrderCancelReplaceRequest(session *Session, msg *Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.processEvents()
+
+	if !a.allowOrderEntry(session) {
+		a.send(session, a.cancelReject(msg, cxlRejResponseToReplace, apperrors.ErrRateLimited.Error()))
+		return
+	}
+	ref, rejectText := a.lookupOrigOrder(session, msg)
+	if ref == nil {
+		a.send(session, a.cancelReject(msg, cxlRejResponseToReplace, rejectText))
+		return
+	}
+
+	price := ref.price
+	if msg.Has(TagPrice) {
+		var err error
+		if price, err = msg.GetFloat(TagPrice); err != nil {
+			a.send(session, a.cancelReject(msg, cxlRejResponseToReplace, "Invalid price"))
+			return
+		}
+	}
+	quantity := ref.orderQty
+	if msg.Has(TagOrderQty) {
+		var err error
+		if quantity, err = msg.GetFloat(TagOrderQty); err != nil {
+			a.send(session, a.cancelReject(msg, cxlRejResponseToReplace, "Invalid quantity"))
+			return
+		}
+	}
+	if validationErrors := a.placeOrderService.ValidateRequest(context.Background(), session.UserID, ref.symbol, ref.side, price, quantity, ""); len(validationErrors) > 0 {
+		a.send(session, a.cancelReject(msg, cxlRejResponseToReplace, validationErrors[0].Code+": "+validationErrors[0].Message))
+		return
+	}
+
+	order, _, err := a.engine.ReplaceOrder(session.UserID, ref.orderID, price, quantity)
+	if err != nil {
+		a.send(session, a.cancelReject(msg, cxlRejResponseToReplace, err.Error()))
+		return
+	}
+
+	a.closeOrder(ref)
+	replacement := &orderRef{
+		session:  session,
+		orderID:  order.ID,
+		clOrdID:  msg.Get(TagClOrdID),
+		symbol:   ref.symbol,
+		side:     ref.side,
+		price:    order.Price,
+		orderQty: quantity,
+		cumQty:   ref.cumQty,
+		notional: ref.notional,
+	}
+	a.orders[order.ID] = replacement
+	a.clOrdIDs[session.ID+"|"+replacement.clOrdID] = order.ID
+
+	report := a.orderReport(replacement, ExecTypeReplaced, ordStatusFor(replacement)).
+		Set(TagOrigClOrdID, msg.Get(TagOrigClOrdID))
+	a.send(session, report)
+}
+
+// allowOrderEntry charges an order entry message to the user's and the session IP's order buckets
+func (a *Acceptor) allowOrderEntry(session *Session) bool {
+	return a.limiters.Orders.TakeAll([]string{ratelimit.UserKey(session.UserID), ratelimit.IPKey(session.remoteIP())}, 1).Allowed
+}
+
+// sessionDisconnected schedules the cancellation of a cancel-on-disconnect session's open orders,
+// which a logon within the grace period aborts
+func (a *Acceptor) sessionDisconnected(session *Session) {
+	grace, enabled := a.config.CancelOnDisconnect[session.TargetCompID]
+	if !enabled {
+		return
+	}
+	generation, disconnected := session.disconnectedGeneration()
+	if !disconnected {
+		return
+	}
+
+	if grace == 0 {
+		a.cancelSessionOrders(session, generation)
+		return
+	}
+	time.AfterFunc(grace, func() {
+		a.cancelSessionOrders(session, generation)
+	})
+}
+
+// cancelSessionOrders cancels the open orders of a session that has not logged on again since
+// generation. The execution reports are stored and recovered by resend after the next logon.
+func (a *Acceptor) cancelSessionOrders(session *Session, generation int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !session.stillDisconnected(generation) {
+		return
+	}
+	a.processEvents()
+
+	orderIDs := make([]int64, 0)
+	for orderID, ref := range a.orders {
+		if ref.session == session && !ref.closed {
+			orderIDs = append(orderIDs, orderID)
+		}
+	}
+	if len(orderIDs) == 0 {
+		return
+	}
+	sort.Slice(orderIDs, func(i, j int) bool { return orderIDs[i] < orderIDs[j] })
+
+	cancelled := a.engine.CancelOrders(session.UserID, orderIDs)
+	for _, order := range cancelled {
+		ref := a.orders[order.ID]
+		a.closeOrder(ref)
+		report := a.orderReport(ref, ExecTypeCanceled, OrdStatusCanceled).
+			Set(TagText, "Cancel on disconnect")
+		a.send(session, report)
+	}
+	log.Printf("FIX %s: cancelled %d open orders on disconnect", session.ID, len(cancelled))
+}
+
+// lookupOrigOrder finds the open order referenced by OrigClOrdID. Caller must hold a.mu.
+func (a *Acceptor) lookupOrigOrder(session *Session, msg *Message) (*orderRef, string) {
+	if msg.Get(TagClOrdID) == "" {
+		return nil, "ClOrdID is required"
+	}
+	if _, exists := a.clOrdIDs[session.ID+"|"+msg.Get(TagClOrdID)]; exists {
+		return nil, "Duplicate ClOrdID"
+	}
+	orderID, exists := a.clOrdIDs[session.ID+"|"+msg.Get(TagOrigClOrdID)]
+	if !exists {
+		return nil, "Unknown order"
+	}
+	ref, open := a.orders[orderID]
+	if !open {
+		return nil, "Order is already filled or cancelled"
+	}
+	return ref, ""
+}
+
+// closeOrder marks a FIX order filled, cancelled or replaced and stops tracking it. Its ClOrdID stays
+// reserved. Caller must hold a.mu.
+func (a *Acceptor) closeOrder(ref *orderRef) {
+	ref.closed = true
+	delete(a.orders, ref.orderID)
+}
+
+// processEvents reports queued fills and cancels on FIX orders. Cancels the acceptor requested
+// itself were already reported and find their order closed. Caller must hold a.mu.
+func (a *Acceptor) processEvents() {
+	a.eventsMu.Lock()
+	events := a.events
+	a.events = nil
+	a.eventsMu.Unlock()
+
+	for _, event := range events {
+		if event.cancelled != nil {
+			a.reportCancelled(event.cancelled)
+			continue
+		}
+
+		trade := event.trade
+		for _, orderID := range []int64{trade.BuyOrderID, trade.SellOrderID} {
+			ref, exists := a.orders[orderID]
+			if !exists || ref.closed {
+				continue
+			}
+
+			ref.cumQty += trade.Quantity
+			ref.notional += trade.Price * trade.Quantity
+			status := ordStatusFor(ref)
+			if status == OrdStatusFilled {
+				a.closeOrder(ref)
+			}
+
+			report := a.orderReport(ref, ExecTypeTrade, status).
+				SetFloat(TagLastQty, trade.Quantity).
+				SetFloat(TagLastPx, trade.Price)
+			a.send(ref.session, report)
+		}
+	}
+}
+
+// reportCancelled reports an order cancelled outside its FIX session. Caller must hold a.mu.
+func (a *Acceptor) reportCancelled(order *models.Order) {
+	ref, exists := a.orders[order.ID]
+	if !exists {
+		return
+	}
+	a.closeOrder(ref)
+	a.send(ref.session, a.orderReport(ref, ExecTypeCanceled, OrdStatusCanceled))
+}
+
+// orderReport builds an execution report describing the current state of a FIX order
+func (a *Acceptor) orderReport(ref *orderRef, execType string, ordStatus string) *Message {
+	leaves := ref.orderQty - ref.cumQty
+	if leaves < 0 || ref.closed {
+		leaves = 0
+	}
+	avgPx := 0.0
+	if ref.cumQty > 0 {
+		avgPx = ref.notional / ref.cumQty
+	}
+
+	return a.newExecutionReport(execType, ordStatus).
+		Set(TagOrderID, strconv.FormatInt(ref.orderID, 10)).
+		Set(TagClOrdID, ref.clOrdID).
+		Set(TagSymbol, ref.symbol).
+		Set(TagSide, toFIXSide(ref.side)).
+		Set(TagOrdType, OrdTypeLimit).
+		SetFloat(TagPrice, ref.price).
+		SetFloat(TagOrderQty, ref.orderQty).
+		SetFloat(TagLeavesQty, leaves).
+		SetFloat(TagCumQty, ref.cumQty).
+		SetFloat(TagAvgPx, avgPx)
+}
+
+// newExecutionReport creates an execution report with a fresh ExecID. Caller must hold a.mu.
+func (a *Acceptor) newExecutionReport(execType string, ordStatus string) *Message {
+	a.nextExecN++
+	return NewMessage(MsgTypeExecutionReport).
+		Set(TagExecID, fmt.Sprintf("%d-%d", time.Now().UnixNano(), a.nextExecN)).
+		Set(TagExecType, execType).
+		Set(TagOrdStatus, ordStatus).
+		SetTime(TagTransactTime, time.Now())
+}
+
+func (a *Acceptor) cancelReject(msg *Message, responseTo string, text string) *Message {
+	return NewMessage(MsgTypeOrderCancelReject).
+		Set(TagOrderID, "NONE").
+		Set(TagClOrdID, msg.Get(TagClOrdID)).
+		Set(TagOrigClOrdID, msg.Get(TagOrigClOrdID)).
+		Set(TagOrdStatus, OrdStatusRejected).
+		Set(TagCxlRejResponseTo, responseTo).
+		Set(TagText, text)
+}
+
+func (a *Acceptor) send(session *Session, msg *Message) {
+	if err := session.Send(msg); err != nil {
+		log.Printf("FIX %s: failed to send %s: %v", session.ID, msg.MsgType(), err)
+	}
+}
+
+func ordStatusFor(ref *orderRef) string {
+	switch {
+	case ref.cumQty >= ref.orderQty:
+		return OrdStatusFilled
+	case ref.cumQty > 0:
+		return OrdStatusPartiallyFilled
+	default:
+		return OrdStatusNew
+	}
+}
+
+// bufferedConn reads through the reader used for the logon so no buffered bytes are lost
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
@@ -0,0 +1,506 @@
+package fix
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/ratelimit"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testCompID = "CLIENT1"
+	testUserID = int64(101)
+	testPair   = "BTC/USDT"
+)
+
+// testPlaceOrderService places orders straight into the engine, leaving validation to the engine
+type testPlaceOrderService struct {
+	engine *engine.MatchingEngine
+}
+
+func (s *testPlaceOrderService) ValidateRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) []*util.Error {
+	return nil
+}
+
+func (s *testPlaceOrderService) ProcessRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) (*models.Order, []*models.Trade, error) {
+	return s.engine.PlaceOrderWithClientID(userID, pair, side, price, quantity, clientOrderID)
+}
+
+// The API key service is a process-wide singleton, so every test shares one key store
+var (
+	testKeysOnce   sync.Once
+	testKeys       *auth.KeyStore
+	testKeyService services.APIKeyService
+	testAPIKey     *auth.APIKey
+)
+
+func initTestKeys(t *testing.T) {
+	t.Helper()
+	testKeysOnce.Do(func() {
+		testKeys = auth.NewKeyStore()
+		testKeyService = services.InitAPIKeyService(auth.NewAuthenticator(testKeys), auth.Config{}, &util.RouterConfig{})
+		testAPIKey = issueTestKey(t, testUserID, auth.PermissionTrade)
+	})
+}
+
+// issueTestKey issues a key for userID in the shared key store
+func issueTestKey(t *testing.T, userID int64, permissions ...auth.Permission) *auth.APIKey {
+	t.Helper()
+	apiKey, err := testKeys.Issue(userID, permissions, nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	return apiKey
+}
+
+// lastSendingTime keeps logon SendingTimes, which double as nonces, distinct across tests
+var (
+	sendingTimeMu   sync.Mutex
+	lastSendingTime time.Time
+)
+
+func nextSendingTime() time.Time {
+	sendingTimeMu.Lock()
+	defer sendingTimeMu.Unlock()
+	now := time.Now().Truncate(time.Millisecond)
+	if !now.After(lastSendingTime) {
+		now = lastSendingTime.Add(time.Millisecond)
+	}
+	lastSendingTime = now
+	return now
+}
+
+// signedLogon builds a Logon for compID signed with apiKey
+func signedLogon(compID string, seqNum int, apiKey *auth.APIKey) *Message {
+	sendingTime := nextSendingTime()
+	return NewMessage(MsgTypeLogon).
+		Set(TagSenderCompID, compID).
+		Set(TagTargetCompID, "MINIEX").
+		SetInt(TagMsgSeqNum, seqNum).
+		SetTime(TagSendingTime, sendingTime).
+		SetInt(TagEncryptMethod, 0).
+		SetInt(TagHeartBtInt, 30).
+		Set(TagUsername, apiKey.Key).
+		Set(TagPassword, SignLogon(apiKey.Secret, sendingTime))
+}
+
+// unlimited is a rate limit no test reaches
+var unlimited = ratelimit.Config{OrderBurst: math.MaxInt32, OrderRate: math.MaxInt32, QueryBurst: math.MaxInt32, QueryRate: math.MaxInt32}
+
+// newTestAcceptor starts an acceptor on a free port with one session for testCompID
+func newTestAcceptor(t *testing.T) (*Acceptor, *engine.MatchingEngine) {
+	t.Helper()
+	return newLimitedTestAcceptor(t, unlimited)
+}
+
+// newLimitedTestAcceptor starts an acceptor like newTestAcceptor with the given rate limits
+func newLimitedTestAcceptor(t *testing.T, limits ratelimit.Config) (*Acceptor, *engine.MatchingEngine) {
+	t.Helper()
+	initTestKeys(t)
+
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+
+	acceptor := NewAcceptor(Config{
+		Port:         "0",
+		SenderCompID: "MINIEX",
+		Sessions:     map[string]int64{testCompID: testUserID},
+	}, matchingEngine, &testPlaceOrderService{engine: matchingEngine}, testKeyService, ratelimit.NewLimiters(limits))
+	matchingEngine.AddTradeListener(acceptor)
+	matchingEngine.AddOrderCancelListener(acceptor)
+	if err := acceptor.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { acceptor.Stop() })
+
+	return acceptor, matchingEngine
+}
+
+// testClient is a minimal FIX initiator
+type testClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	seqNum int
+}
+
+func dialTestClient(t *testing.T, acceptor *Acceptor) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", acceptor.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testClient{t: t, conn: conn, reader: bufio.NewReader(conn), seqNum: 1}
+}
+
+// sendSeq sends msg with the given MsgSeqNum
+func (c *testClient) sendSeq(msg *Message, seqNum int) {
+	c.t.Helper()
+	msg.Set(TagSenderCompID, testCompID).
+		Set(TagTargetCompID, "MINIEX").
+		SetInt(TagMsgSeqNum, seqNum).
+		SetTime(TagSendingTime, time.Now())
+	if _, err := c.conn.Write(msg.Bytes()); err != nil {
+		c.t.Fatalf("write: %v", err)
+	}
+}
+
+// send sends msg with the next MsgSeqNum
+func (c *testClient) send(msg *Message) {
+	c.t.Helper()
+	c.sendSeq(msg, c.seqNum)
+	c.seqNum++
+}
+
+// expect reads the next message, skipping heartbeats, and checks its type
+func (c *testClient) expect(msgType string) *Message {
+	c.t.Helper()
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		raw, err := readMessage(c.reader)
+		if err != nil {
+			c.t.Fatalf("waiting for MsgType %s: %v", msgType, err)
+		}
+		msg, err := ParseMessage(raw)
+		if err != nil {
+			c.t.Fatalf("ParseMessage: %v", err)
+		}
+		if msg.MsgType() == MsgTypeHeartbeat && msgType != MsgTypeHeartbeat {
+			continue
+		}
+		if msg.MsgType() != msgType {
+			c.t.Fatalf("got MsgType %s, want %s: %s", msg.MsgType(), msgType, msg)
+		}
+		return msg
+	}
+}
+
+func (c *testClient) logon() *Message {
+	c.t.Helper()
+	c.write(signedLogon(testCompID, c.seqNum, testAPIKey))
+	c.seqNum++
+	return c.expect(MsgTypeLogon)
+}
+
+// write sends msg as is
+func (c *testClient) write(msg *Message) {
+	c.t.Helper()
+	if _, err := c.conn.Write(msg.Bytes()); err != nil {
+		c.t.Fatalf("write: %v", err)
+	}
+}
+
+// expectClosed checks the acceptor closes the connection without answering
+func (c *testClient) expectClosed(what string) {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := readMessage(c.reader); err == nil {
+		c.t.Fatalf("%s was answered", what)
+	}
+}
+
+func newOrderSingle(clOrdID string, side string, price float64, quantity float64) *Message {
+	return NewMessage(MsgTypeNewOrderSingle).
+		Set(TagClOrdID, clOrdID).
+		Set(TagSymbol, testPair).
+		Set(TagSide, side).
+		Set(TagOrdType, OrdTypeLimit).
+		SetFloat(TagPrice, price).
+		SetFloat(TagOrderQty, quantity)
+}
+
+func expectField(t *testing.T, msg *Message, tag int, want string) {
+	t.Helper()
+	if got := msg.Get(tag); got != want {
+		t.Fatalf("tag %d = %q, want %q in %s", tag, got, want, msg)
+	}
+}
+
+func TestLogon(t *testing.T) {
+	acceptor, _ := newTestAcceptor(t)
+	client := dialTestClient(t, acceptor)
+
+	response := client.logon()
+	expectField(t, response, TagHeartBtInt, "30")
+	expectField(t, response, TagMsgSeqNum, "1")
+	expectField(t, response, TagTargetCompID, testCompID)
+}
+
+func TestLogonUnknownCompIDIsRejected(t *testing.T) {
+	acceptor, _ := newTestAcceptor(t)
+	client := dialTestClient(t, acceptor)
+
+	client.write(signedLogon("UNKNOWN", 1, testAPIKey))
+	client.expectClosed("logon with an unknown SenderCompID")
+}
+
+func TestLogonWithoutValidCredentialsIsRejected(t *testing.T) {
+	acceptor, _ := newTestAcceptor(t)
+
+	otherUser := issueTestKey(t, testUserID+1, auth.PermissionTrade)
+	readOnly := issueTestKey(t, testUserID, auth.PermissionRead)
+	tests := []struct {
+		name  string
+		logon func() *Message
+	}{
+		{"no credentials", func() *Message {
+			logon := signedLogon(testCompID, 1, testAPIKey)
+			logon.Set(TagUsername, "").Set(TagPassword, "")
+			return logon
+		}},
+		{"wrong signature", func() *Message {
+			return signedLogon(testCompID, 1, testAPIKey).Set(TagPassword, SignLogon("not the secret", time.Now()))
+		}},
+		{"replayed logon", func() *Message {
+			logon := signedLogon(testCompID, 1, testAPIKey)
+			replayed := dialTestClient(t, acceptor)
+			replayed.write(logon)
+			replayed.expect(MsgTypeLogon)
+			replayed.conn.Close()
+			return logon
+		}},
+		{"key of another user", func() *Message { return signedLogon(testCompID, 1, otherUser) }},
+		{"key without trade permission", func() *Message { return signedLogon(testCompID, 1, readOnly) }},
+	}
+	for _, tt := range tests {
+		client := dialTestClient(t, acceptor)
+		client.write(tt.logon())
+		client.expectClosed("logon with " + tt.name)
+	}
+}
+
+func TestNewOrderSingleIsFilled(t *testing.T) {
+	acceptor, matchingEngine := newTestAcceptor(t)
+	client := dialTestClient(t, acceptor)
+	client.logon()
+
+	if _, _, err := matchingEngine.PlaceOrder(202, testPair, "sell", 100, 1); err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	client.send(newOrderSingle("order-1", SideBuy, 100, 1))
+
+	ack := client.expect(MsgTypeExecutionReport)
+	expectField(t, ack, TagExecType, ExecTypeNew)
+	expectField(t, ack, TagClOrdID, "order-1")
+
+	fill := client.expect(MsgTypeExecutionReport)
+	expectField(t, fill, TagExecType, ExecTypeTrade)
+	expectField(t, fill, TagOrdStatus, OrdStatusFilled)
+	expectField(t, fill, TagLastQty, "1")
+	expectField(t, fill, TagLastPx, "100")
+	expectField(t, fill, TagLeavesQty, "0")
+}
+
+func TestNewOrderSingleDuplicateClOrdIDIsRejected(t *testing.T) {
+	acceptor, _ := newTestAcceptor(t)
+	client := dialTestClient(t, acceptor)
+	client.logon()
+
+	client.send(newOrderSingle("order-1", SideBuy, 100, 1))
+	client.expect(MsgTypeExecutionReport)
+
+	client.send(newOrderSingle("order-1", SideBuy, 100, 1))
+	reject := client.expect(MsgTypeExecutionReport)
+	expectField(t, reject, TagExecType, ExecTypeRejected)
+	expectField(t, reject, TagOrdRejReason, "6")
+}
+
+func TestNewOrderSingleClOrdIDIsClientOrderID(t *testing.T) {
+	acceptor, matchingEngine := newTestAcceptor(t)
+	client := dialTestClient(t, acceptor)
+	client.logon()
+
+	client.send(newOrderSingle("order-1", SideBuy, 100, 1))
+	client.expect(MsgTypeExecutionReport)
+	if _, exists := matchingEngine.GetOrderByClientID(testUserID, "order-1"); !exists {
+		t.Fatal("order not found by its ClOrdID")
+	}
+
+	// Client order IDs are shared with orders placed over the other APIs
+	if _, _, err := matchingEngine.PlaceOrderWithClientID(testUserID, testPair, "buy", 99, 1, "rest-1"); err != nil {
+		t.Fatalf("PlaceOrderWithClientID: %v", err)
+	}
+	client.send(newOrderSingle("rest-1", SideBuy, 100, 1))
+	reject := client.expect(MsgTypeExecutionReport)
+	expectField(t, reject, TagExecType, ExecTypeRejected)
+	expectField(t, reject, TagOrdRejReason, "6")
+}
+
+func TestNewOrderSingleIsRateLimited(t *testing.T) {
+	acceptor, _ := newLimitedTestAcceptor(t, ratelimit.Config{OrderBurst: 1, OrderRate: 0.001, QueryBurst: 10, QueryRate: 10})
+	client := dialTestClient(t, acceptor)
+	client.logon()
+
+	client.send(newOrderSingle("order-1", SideBuy, 100, 1))
+	expectField(t, client.expect(MsgTypeExecutionReport), TagExecType, ExecTypeNew)
+
+	client.send(newOrderSingle("order-2", SideBuy, 100, 1))
+	reject := client.expect(MsgTypeExecutionReport)
+	expectField(t, reject, TagExecType, ExecTypeRejected)
+	expectField(t, reject, TagText, apperrors.ErrRateLimited.Error())
+}
+
+func TestOrderCancelRequest(t *testing.T) {
+	acceptor, matchingEngine := newTestAcceptor(t)
+	client := dialTestClient(t, acceptor)
+	client.logon()
+
+	client.send(newOrderSingle("order-1", SideBuy, 100, 1))
+	client.expect(MsgTypeExecutionReport)
+
+	client.send(NewMessage(MsgTypeOrderCancelRequest).
+		Set(TagClOrdID, "cancel-1").
+		Set(TagOrigClOrdID, "order-1").
+		Set(TagSymbol, testPair).
+		Set(TagSide, SideBuy))
+	report := client.expect(MsgTypeExecutionReport)
+	expectField(t, report, TagExecType, ExecTypeCanceled)
+	expectField(t, report, TagOrigClOrdID, "order-1")
+	expectField(t, report, TagClOrdID, "cancel-1")
+
+	if bid := matchingEngine.GetOrderBook(testPair).GetBestBid(); bid != nil {
+		t.Fatalf("cancelled order still rests at %v", bid.Price)
+	}
+
+	client.send(NewMessage(MsgTypeOrderCancelRequest).
+		Set(TagClOrdID, "cancel-2").
+		Set(TagOrigClOrdID, "order-1"))
+	reject := client.expect(MsgTypeOrderCancelReject)
+	expectField(t, reject, TagCxlRejResponseTo, cxlRejResponseToCancel)
+}
+
+func TestCancelOutsideSessionIsReported(t *testing.T) {
+	acceptor, matchingEngine := newTestAcceptor(t)
+	client := dialTestClient(t, acceptor)
+	client.logon()
+
+	client.send(newOrderSingle("order-1", SideBuy, 100, 1))
+	client.expect(MsgTypeExecutionReport)
+
+	// e.g. a REST mass cancel or the dead man's switch
+	if cancelled := matchingEngine.CancelAllOrders(testUserID, "", ""); len(cancelled) != 1 {
+		t.Fatalf("cancelled %d orders, want 1", len(cancelled))
+	}
+
+	report := client.expect(MsgTypeExecutionReport)
+	expectField(t, report, TagExecType, ExecTypeCanceled)
+	expectField(t, report, TagOrdStatus, OrdStatusCanceled)
+	expectField(t, report, TagClOrdID, "order-1")
+	expectField(t, report, TagLeavesQty, "0")
+
+	acceptor.mu.Lock()
+	tracked := len(acceptor.orders)
+	acceptor.mu.Unlock()
+	if tracked != 0 {
+		t.Fatalf("acceptor still tracks %d orders", tracked)
+	}
+}
+
+func TestSequenceGapSendsResendRequest(t *testing.T) {
+	acceptor, _ := newTestAcceptor(t)
+	client := dialTestClient(t, acceptor)
+	client.logon()
+
+	// Skip sequence number 2
+	client.sendSeq(newOrderSingle("order-1", SideBuy, 100, 1), 3)
+
+	resend := client.expect(MsgTypeResendRequest)
+	expectField(t, resend, TagBeginSeqNo, "2")
+	expectField(t, resend, TagEndSeqNo, "0")
+
+	// The message after the gap is only processed once the gap is filled
+	client.sendSeq(NewMessage(MsgTypeSequenceReset).Set(TagGapFillFlag, "Y").SetInt(TagNewSeqNo, 3), 2)
+	client.sendSeq(newOrderSingle("order-1", SideBuy, 100, 1).Set(TagPossDupFlag, "Y"), 3)
+	report := client.expect(MsgTypeExecutionReport)
+	expectField(t, report, TagExecType, ExecTypeNew)
+}
+
+func TestResendRequestReplaysWithGapFill(t *testing.T) {
+	acceptor, _ := newTestAcceptor(t)
+	client := dialTestClient(t, acceptor)
+	client.logon() // our seq 1
+
+	client.send(newOrderSingle("order-1", SideBuy, 100, 1))
+	original := client.expect(MsgTypeExecutionReport) // our seq 2
+	expectField(t, original, TagMsgSeqNum, "2")
+
+	client.send(NewMessage(MsgTypeResendRequest).SetInt(TagBeginSeqNo, 1).SetInt(TagEndSeqNo, 0))
+
+	// The logon is an admin message and is replaced by a gap fill
+	gapFill := client.expect(MsgTypeSequenceReset)
+	expectField(t, gapFill, TagMsgSeqNum, "1")
+	expectField(t, gapFill, TagGapFillFlag, "Y")
+	expectField(t, gapFill, TagNewSeqNo, "2")
+
+	replayed := client.expect(MsgTypeExecutionReport)
+	expectField(t, replayed, TagMsgSeqNum, "2")
+	expectField(t, replayed, TagPossDupFlag, "Y")
+	expectField(t, replayed, TagExecID, original.Get(TagExecID))
+	if !replayed.Has(TagOrigSendingTime) {
+		t.Fatalf("replayed message has no OrigSendingTime: %s", replayed)
+	}
+}
+
+func TestSessionResendTrailingAdminMessagesBecomeGapFill(t *testing.T) {
+	session := newSession("MINIEX", testCompID, testUserID, NewMemoryStore())
+	server, client := net.Pipe()
+	defer client.Close()
+	if err := session.attach(server, time.Minute); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+
+	// Messages 1 and 2 are admin messages, 3 is an application message, 4 an admin message again
+	go func() {
+		session.Send(NewMessage(MsgTypeLogon))
+		session.Send(NewMessage(MsgTypeHeartbeat))
+		session.Send(NewMessage(MsgTypeExecutionReport).Set(TagExecID, "1"))
+		session.Send(NewMessage(MsgTypeHeartbeat))
+		session.resend(1, 0)
+	}()
+
+	reader := bufio.NewReader(client)
+	read := func() *Message {
+		t.Helper()
+		client.SetReadDeadline(time.Now().Add(2 * time.Second))
+		raw, err := readMessage(reader)
+		if err != nil {
+			t.Fatalf("readMessage: %v", err)
+		}
+		msg, err := ParseMessage(raw)
+		if err != nil {
+			t.Fatalf("ParseMessage: %v", err)
+		}
+		return msg
+	}
+	for i := 0; i < 4; i++ {
+		read()
+	}
+
+	first := read()
+	expectField(t, first, TagMsgType, MsgTypeSequenceReset)
+	expectField(t, first, TagMsgSeqNum, "1")
+	expectField(t, first, TagNewSeqNo, "3")
+
+	replayed := read()
+	expectField(t, replayed, TagMsgType, MsgTypeExecutionReport)
+	expectField(t, replayed, TagMsgSeqNum, "3")
+
+	last := read()
+	expectField(t, last, TagMsgType, MsgTypeSequenceReset)
+	expectField(t, last, TagMsgSeqNum, "4")
+	expectField(t, last, TagNewSeqNo, "5")
+}
@@ -0,0 +1,64 @@
+package fix
+
+// ExecType (150) values
+const (
+	ExecTypeNew      = "0"
+	ExecTypeCanceled = "4"
+	ExecTypeReplaced = "5"
+	ExecTypeRejected = "8"
+	ExecTypeTrade    = "F"
+)
+
+// OrdStatus (39) values
+const (
+	OrdStatusNew             = "0"
+	OrdStatusPartiallyFilled = "1"
+	OrdStatusFilled          = "2"
+	OrdStatusCanceled        = "4"
+	OrdStatusRejected        = "8"
+)
+
+// OrdType (40) values
+const (
+	OrdTypeLimit = "2"
+)
+
+// Side (54) values
+const (
+	SideBuy  = "1"
+	SideSell = "2"
+)
+
+// OrdRejReason (103) values
+const (
+	ordRejReasonUnknownSymbol  = 1
+	ordRejReasonExchangeClosed = 2
+	ordRejReasonExceedsLimit   = 3
+	ordRejReasonDuplicate      = 6
+	ordRejReasonOther          = 99
+)
+
+// CxlRejResponseTo (434) values
+const (
+	cxlRejResponseToCancel  = "1"
+	cxlRejResponseToReplace = "2"
+)
+
+// fromFIXSide maps a FIX side to the engine's "buy"/"sell"
+func fromFIXSide(side string) (string, bool) {
+	switch side {
+	case SideBuy:
+		return "buy", true
+	case SideSell:
+		return "sell", true
+	}
+	return "", false
+}
+
+// toFIXSide maps the engine's "buy"/"sell" to a FIX side
+func toFIXSide(side string) string {
+	if side == "buy" {
+		return SideBuy
+	}
+	return SideSell
+}
@@ -0,0 +1,323 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	// BeginString is the only FIX version supported by the gateway
+	BeginString = "FIX.4.4"
+
+	soh = '\x01'
+
+	// sendingTimeLayout is the FIX UTCTimestamp format with milliseconds
+	sendingTimeLayout = "20060102-15:04:05.000"
+)
+
+// FIX tags used by the gateway
+const (
+	TagAccount              = 1
+	TagAvgPx                = 6
+	TagBeginSeqNo           = 7
+	TagBeginString          = 8
+	TagBodyLength           = 9
+	TagCheckSum             = 10
+	TagClOrdID              = 11
+	TagCumQty               = 14
+	TagEndSeqNo             = 16
+	TagExecID               = 17
+	TagLastPx               = 31
+	TagLastQty              = 32
+	TagMsgSeqNum            = 34
+	TagMsgType              = 35
+	TagNewSeqNo             = 36
+	TagOrderID              = 37
+	TagOrderQty             = 38
+	TagOrdStatus            = 39
+	TagOrdType              = 40
+	TagOrigClOrdID          = 41
+	TagPossDupFlag          = 43
+	TagPrice                = 44
+	TagRefSeqNum            = 45
+	TagSenderCompID         = 49
+	TagSendingTime          = 52
+	TagSide                 = 54
+	TagSymbol               = 55
+	TagTargetCompID         = 56
+	TagText                 = 58
+	TagTransactTime         = 60
+	TagEncryptMethod        = 98
+	TagCxlRejReason         = 102
+	TagOrdRejReason         = 103
+	TagHeartBtInt           = 108
+	TagTestReqID            = 112
+	TagOrigSendingTime      = 122
+	TagGapFillFlag          = 123
+	TagResetSeqNumFlag      = 141
+	TagExecType             = 150
+	TagLeavesQty            = 151
+	TagRefMsgType           = 372
+	TagBusinessRejectReason = 380
+	TagCxlRejResponseTo     = 434
+	TagUsername             = 553
+	TagPassword             = 554
+)
+
+// FIX message types used by the gateway
+const (
+	MsgTypeHeartbeat                 = "0"
+	MsgTypeTestRequest               = "1"
+	MsgTypeResendRequest             = "2"
+	MsgTypeReject                    = "3"
+	MsgTypeSequenceReset             = "4"
+	MsgTypeLogout                    = "5"
+	MsgTypeExecutionReport           = "8"
+	MsgTypeOrderCancelReject         = "9"
+	MsgTypeLogon                     = "A"
+	MsgTypeNewOrderSingle            = "D"
+	MsgTypeOrderCancelRequest        = "F"
+	MsgTypeOrderCancelReplaceRequest = "G"
+	MsgTypeBusinessMessageReject     = "j"
+)
+
+// headerTags are written right after MsgType, in this order, when present
+var headerTags = []int{TagSenderCompID, TagTargetCompID, TagMsgSeqNum, TagPossDupFlag, TagSendingTime, TagOrigSendingTime}
+
+var (
+	errGarbled        = errors.New("garbled FIX message")
+	errBadChecksum    = errors.New("FIX checksum mismatch")
+	errBadBeginString = errors.New("unsupported FIX BeginString")
+	errMissingMsgType = errors.New("FIX message has no MsgType")
+)
+
+// Field is a single tag=value pair
+type Field struct {
+	Tag   int
+	Value string
+}
+
+// Message is a FIX message without its BeginString, BodyLength and CheckSum fields,
+// which are computed when the message is encoded
+type Message struct {
+	Fields []Field
+}
+
+// NewMessage creates a message of the given type
+func NewMessage(msgType string) *Message {
+	return &Message{Fields: []Field{{Tag: TagMsgType, Value: msgType}}}
+}
+
+// MsgType returns the message type
+func (m *Message) MsgType() string {
+	return m.Get(TagMsgType)
+}
+
+// Get returns the value of a tag, or "" when absent
+func (m *Message) Get(tag int) string {
+	for _, field := range m.Fields {
+		if field.Tag == tag {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Has reports whether the tag is present
+func (m *Message) Has(tag int) bool {
+	for _, field := range m.Fields {
+		if field.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// GetInt returns the integer value of a tag
+func (m *Message) GetInt(tag int) (int, error) {
+	return strconv.Atoi(m.Get(tag))
+}
+
+// GetFloat returns the decimal value of a tag
+func (m *Message) GetFloat(tag int) (float64, error) {
+	return strconv.ParseFloat(m.Get(tag), 64)
+}
+
+// Set replaces a tag's value or appends it when absent
+func (m *Message) Set(tag int, value string) *Message {
+	for i := range m.Fields {
+		if m.Fields[i].Tag == tag {
+			m.Fields[i].Value = value
+			return m
+		}
+	}
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+	return m
+}
+
+// SetInt sets an integer tag
+func (m *Message) SetInt(tag int, value int) *Message {
+	return m.Set(tag, strconv.Itoa(value))
+}
+
+// SetFloat sets a decimal tag
+func (m *Message) SetFloat(tag int, value float64) *Message {
+	return m.Set(tag, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// SetTime sets a UTCTimestamp tag
+func (m *Message) SetTime(tag int, value time.Time) *Message {
+	return m.Set(tag, value.UTC().Format(sendingTimeLayout))
+}
+
+// Bytes encodes the message with computed BodyLength and CheckSum
+func (m *Message) Bytes() []byte {
+	var body bytes.Buffer
+	writeField := func(tag int, value string) {
+		body.WriteString(strconv.Itoa(tag))
+		body.WriteByte('=')
+		body.WriteString(value)
+		body.WriteByte(soh)
+	}
+
+	writeField(TagMsgType, m.MsgType())
+	for _, tag := range headerTags {
+		if m.Has(tag) {
+			writeField(tag, m.Get(tag))
+		}
+	}
+	for _, field := range m.Fields {
+		if field.Tag == TagMsgType || isHeaderTag(field.Tag) {
+			continue
+		}
+		writeField(field.Tag, field.Value)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(fmt.Sprintf("%d=%s%c%d=%d%c", TagBeginString, BeginString, soh, TagBodyLength, body.Len(), soh))
+	out.Write(body.Bytes())
+	out.WriteString(fmt.Sprintf("%d=%03d%c", TagCheckSum, checksum(out.Bytes()), soh))
+
+	return out.Bytes()
+}
+
+// String renders the message with '|' separators for logging
+func (m *Message) String() string {
+	return string(bytes.ReplaceAll(m.Bytes(), []byte{soh}, []byte{'|'}))
+}
+
+// ParseMessage decodes a complete raw FIX message, verifying BodyLength and CheckSum
+func ParseMessage(raw []byte) (*Message, error) {
+	if len(raw) < 7 || raw[len(raw)-1] != soh {
+		return nil, errGarbled
+	}
+
+	// Verify the checksum over everything before the CheckSum field
+	trailer := bytes.LastIndex(raw[:len(raw)-1], []byte{soh})
+	if trailer < 0 || !bytes.HasPrefix(raw[trailer+1:], []byte("10=")) {
+		return nil, errGarbled
+	}
+	expected, err := strconv.Atoi(string(raw[trailer+4 : len(raw)-1]))
+	if err != nil {
+		return nil, errGarbled
+	}
+	if checksum(raw[:trailer+1]) != expected {
+		return nil, errBadChecksum
+	}
+
+	msg := &Message{}
+	for _, part := range bytes.Split(raw[:trailer], []byte{soh}) {
+		eq := bytes.IndexByte(part, '=')
+		if eq <= 0 {
+			return nil, errGarbled
+		}
+		tag, err := strconv.Atoi(string(part[:eq]))
+		if err != nil {
+			return nil, errGarbled
+		}
+		value := string(part[eq+1:])
+
+		switch tag {
+		case TagBeginString:
+			if value != BeginString {
+				return nil, errBadBeginString
+			}
+		case TagBodyLength:
+		default:
+			msg.Fields = append(msg.Fields, Field{Tag: tag, Value: value})
+		}
+	}
+
+	if msg.MsgType() == "" {
+		return nil, errMissingMsgType
+	}
+
+	return msg, nil
+}
+
+// readMessage reads one raw FIX message framed by BeginString, BodyLength and CheckSum
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	begin, err := r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(begin, []byte("8=")) {
+		return nil, errGarbled
+	}
+
+	length, err := r.ReadBytes(soh)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(length, []byte("9=")) {
+		return nil, errGarbled
+	}
+	bodyLength, err := strconv.Atoi(string(length[2 : len(length)-1]))
+	if err != nil || bodyLength <= 0 {
+		return nil, errGarbled
+	}
+
+	// Body plus the 7 byte CheckSum field "10=NNN<SOH>"
+	rest := make([]byte, bodyLength+7)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 0, len(begin)+len(length)+len(rest))
+	raw = append(raw, begin...)
+	raw = append(raw, length...)
+	raw = append(raw, rest...)
+
+	return raw, nil
+}
+
+func checksum(data []byte) int {
+	sum := 0
+	for _, b := range data {
+		sum += int(b)
+	}
+	return sum % 256
+}
+
+func isHeaderTag(tag int) bool {
+	for _, headerTag := range headerTags {
+		if tag == headerTag {
+			return true
+		}
+	}
+	return false
+}
+
+func isAdminMsgType(msgType string) bool {
+	switch msgType {
+	case MsgTypeHeartbeat, MsgTypeTestRequest, MsgTypeResendRequest, MsgTypeReject,
+		MsgTypeSequenceReset, MsgTypeLogout, MsgTypeLogon:
+		return true
+	}
+	return false
+}
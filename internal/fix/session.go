@@ -0,0 +1,346 @@
+package fix
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"mini-crypto-exchange/internal/auth"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var errNotLoggedOn = errors.New("FIX session not logged on")
+
+// Session is the state of one counterparty. It outlives individual TCP
+// connections so sequence numbers continue across reconnects.
+type Session struct {
+	ID           string
+	SenderCompID string // our CompID
+	TargetCompID string // counterparty CompID
+	UserID       int64
+
+	store MessageStore
+
+	mu           sync.Mutex // guards the fields below and serializes writes
+	conn         net.Conn
+	heartBtInt   time.Duration
+	lastSent     time.Time
+	lastReceived time.Time
+	testReqSent  bool
+	resendTarget int // highest seq num requested via ResendRequest, 0 when none outstanding
+	generation   int // number of logons, to tell whether the counterparty came back
+}
+
+func newSession(senderCompID string, targetCompID string, userID int64, store MessageStore) *Session {
+	return &Session{
+		ID:           senderCompID + "-" + targetCompID,
+		SenderCompID: senderCompID,
+		TargetCompID: targetCompID,
+		UserID:       userID,
+		store:        store,
+	}
+}
+
+// IsLoggedOn reports whether the session currently has a connection
+func (s *Session) IsLoggedOn() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn != nil
+}
+
+// remoteIP returns the counterparty's IP, nil while disconnected
+func (s *Session) remoteIP() net.IP {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	return auth.RemoteIP(s.conn.RemoteAddr().String())
+}
+
+// Send assigns the next sequence number, stores the message for resends and writes it
+// when connected. Messages sent while disconnected are recovered by the counterparty
+// through a ResendRequest after the next logon.
+func (s *Session) Send(msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sendLocked(msg)
+}
+
+func (s *Session) sendLocked(msg *Message) error {
+	seqNum := s.store.NextSenderSeqNum()
+	msg.Set(TagSenderCompID, s.SenderCompID)
+	msg.Set(TagTargetCompID, s.TargetCompID)
+	msg.SetInt(TagMsgSeqNum, seqNum)
+	msg.SetTime(TagSendingTime, time.Now())
+
+	raw := msg.Bytes()
+	if err := s.store.SaveMessage(seqNum, raw); err != nil {
+		return err
+	}
+	if err := s.store.SetNextSenderSeqNum(seqNum + 1); err != nil {
+		return err
+	}
+
+	if s.conn == nil {
+		return nil
+	}
+	return s.writeLocked(raw)
+}
+
+func (s *Session) writeLocked(raw []byte) error {
+	if s.conn == nil {
+		return errNotLoggedOn
+	}
+	s.lastSent = time.Now()
+	_, err := s.conn.Write(raw)
+	return err
+}
+
+// attach binds a freshly logged on connection to the session
+func (s *Session) attach(conn net.Conn, heartBtInt time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		return fmt.Errorf("session %s already logged on", s.ID)
+	}
+	s.conn = conn
+	s.heartBtInt = heartBtInt
+	s.lastSent = time.Now()
+	s.lastReceived = time.Now()
+	s.testReqSent = false
+	s.resendTarget = 0
+	s.generation++
+	return nil
+}
+
+// detach closes and releases the session's connection
+func (s *Session) detach(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == conn {
+		s.conn = nil
+	}
+	conn.Close()
+}
+
+// disconnectedGeneration returns the generation of the last logon, reporting false while logged on
+func (s *Session) disconnectedGeneration() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation, s.conn == nil
+}
+
+// stillDisconnected reports whether the counterparty has not logged on again since generation
+func (s *Session) stillDisconnected(generation int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn == nil && s.generation == generation
+}
+
+// logout sends a Logout with an optional reason and drops the connection
+func (s *Session) logout(text string) {
+	s.mu.Lock()
+	conn := s.conn
+	if conn != nil {
+		msg := NewMessage(MsgTypeLogout)
+		if text != "" {
+			msg.Set(TagText, text)
+		}
+		if err := s.sendLocked(msg); err != nil {
+			log.Printf("FIX %s: failed to send logout: %v", s.ID, err)
+		}
+	}
+	s.mu.Unlock()
+
+	if conn != nil {
+		s.detach(conn)
+	}
+}
+
+// checkHeartbeat sends heartbeats and test requests, reporting false once the counterparty timed out
+func (s *Session) checkHeartbeat(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return false
+	}
+
+	if now.Sub(s.lastSent) >= s.heartBtInt {
+		if err := s.sendLocked(NewMessage(MsgTypeHeartbeat)); err != nil {
+			return false
+		}
+	}
+
+	// Allow some transmission latency before probing, then give one more interval to answer
+	silence := now.Sub(s.lastReceived)
+	grace := s.heartBtInt + s.heartBtInt/5
+	if silence >= grace+s.heartBtInt && s.testReqSent {
+		return false
+	}
+	if silence >= grace && !s.testReqSent {
+		s.testReqSent = true
+		testReq := NewMessage(MsgTypeTestRequest).Set(TagTestReqID, strconv.FormatInt(now.UnixNano(), 10))
+		if err := s.sendLocked(testReq); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// received records inbound activity for heartbeat monitoring
+func (s *Session) received() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastReceived = time.Now()
+	s.testReqSent = false
+}
+
+// verifySeqNum applies inbound sequence number rules. It returns whether the
+// message should be processed and an error when the session must be terminated.
+func (s *Session) verifySeqNum(msg *Message) (bool, error) {
+	seqNum, err := msg.GetInt(TagMsgSeqNum)
+	if err != nil {
+		return false, errors.New("missing or invalid MsgSeqNum")
+	}
+	expected := s.store.NextTargetSeqNum()
+
+	switch {
+	case seqNum == expected:
+		if err := s.store.SetNextTargetSeqNum(expected + 1); err != nil {
+			return false, err
+		}
+		s.mu.Lock()
+		if s.resendTarget != 0 && seqNum >= s.resendTarget {
+			s.resendTarget = 0
+		}
+		s.mu.Unlock()
+		return true, nil
+
+	case seqNum > expected:
+		// Gap detected: ask for everything missing once and drop this message,
+		// it will be replayed as part of the resend
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.resendTarget == 0 {
+			s.resendTarget = seqNum
+			resend := NewMessage(MsgTypeResendRequest).
+				SetInt(TagBeginSeqNo, expected).
+				SetInt(TagEndSeqNo, 0)
+			if err := s.sendLocked(resend); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+
+	default:
+		if msg.Get(TagPossDupFlag) == "Y" {
+			return false, nil
+		}
+		return false, fmt.Errorf("MsgSeqNum too low, expecting %d but received %d", expected, seqNum)
+	}
+}
+
+// resend replays stored messages in [begin, end] (end 0 means up to the latest).
+// Application messages are resent with PossDupFlag; admin messages and gaps become SequenceReset-GapFill.
+func (s *Session) resend(begin int, end int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := s.store.NextSenderSeqNum() - 1
+	if end == 0 || end > last {
+		end = last
+	}
+	if begin < 1 {
+		begin = 1
+	}
+	if begin > end {
+		return nil
+	}
+
+	stored, err := s.store.GetMessages(begin, end)
+	if err != nil {
+		return err
+	}
+
+	seqNums := make([]int, 0, len(stored))
+	for seqNum := range stored {
+		seqNums = append(seqNums, seqNum)
+	}
+	sort.Ints(seqNums)
+
+	gapStart := 0
+	flushGap := func(next int) error {
+		if gapStart == 0 {
+			return nil
+		}
+		gapFill := NewMessage(MsgTypeSequenceReset).
+			Set(TagSenderCompID, s.SenderCompID).
+			Set(TagTargetCompID, s.TargetCompID).
+			SetInt(TagMsgSeqNum, gapStart).
+			Set(TagPossDupFlag, "Y").
+			SetTime(TagSendingTime, time.Now()).
+			Set(TagGapFillFlag, "Y").
+			SetInt(TagNewSeqNo, next)
+		gapStart = 0
+		return s.writeLocked(gapFill.Bytes())
+	}
+
+	next := begin
+	for _, seqNum := range seqNums {
+		msg, err := ParseMessage(stored[seqNum])
+		if err != nil || isAdminMsgType(msg.MsgType()) {
+			continue
+		}
+		if seqNum > next && gapStart == 0 {
+			gapStart = next
+		}
+		if err := flushGap(seqNum); err != nil {
+			return err
+		}
+
+		msg.Set(TagPossDupFlag, "Y")
+		msg.Set(TagOrigSendingTime, msg.Get(TagSendingTime))
+		msg.SetTime(TagSendingTime, time.Now())
+		if err := s.writeLocked(msg.Bytes()); err != nil {
+			return err
+		}
+		next = seqNum + 1
+	}
+
+	if next <= end {
+		gapStart = next
+		return flushGap(end + 1)
+	}
+	return nil
+}
+
+// readLoop reads messages from the connection until it fails, passing each one to handle
+func readLoop(conn net.Conn, handle func(msg *Message) error) error {
+	reader := bufio.NewReader(conn)
+	for {
+		raw, err := readMessage(reader)
+		if err != nil {
+			return err
+		}
+
+		msg, err := ParseMessage(raw)
+		if err != nil {
+			// Garbled messages are ignored per the FIX session protocol
+			log.Printf("FIX: dropping message from %s: %v", conn.RemoteAddr(), err)
+			continue
+		}
+
+		if err := handle(msg); err != nil {
+			return err
+		}
+	}
+}
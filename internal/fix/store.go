@@ -0,0 +1,213 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MessageStore persists a session's sequence numbers and the outbound messages needed for resends
+type MessageStore interface {
+	NextSenderSeqNum() int
+	NextTargetSeqNum() int
+	SetNextSenderSeqNum(seqNum int) error
+	SetNextTargetSeqNum(seqNum int) error
+	SaveMessage(seqNum int, raw []byte) error
+	GetMessages(begin int, end int) (map[int][]byte, error)
+	Reset() error
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	senderSeq int
+	targetSeq int
+	messages  map[int][]byte
+}
+
+// NewMemoryStore creates a store that keeps session state for the life of the process
+func NewMemoryStore() MessageStore {
+	return &memoryStore{senderSeq: 1, targetSeq: 1, messages: make(map[int][]byte)}
+}
+
+func (s *memoryStore) NextSenderSeqNum() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.senderSeq
+}
+
+func (s *memoryStore) NextTargetSeqNum() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.targetSeq
+}
+
+func (s *memoryStore) SetNextSenderSeqNum(seqNum int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.senderSeq = seqNum
+	return nil
+}
+
+func (s *memoryStore) SetNextTargetSeqNum(seqNum int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.targetSeq = seqNum
+	return nil
+}
+
+func (s *memoryStore) SaveMessage(seqNum int, raw []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[seqNum] = append([]byte(nil), raw...)
+	return nil
+}
+
+func (s *memoryStore) GetMessages(begin int, end int) (map[int][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[int][]byte)
+	for seqNum, raw := range s.messages {
+		if seqNum >= begin && (end == 0 || seqNum <= end) {
+			result[seqNum] = raw
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryStore) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.senderSeq = 1
+	s.targetSeq = 1
+	s.messages = make(map[int][]byte)
+	return nil
+}
+
+// fileStore keeps session state in memory and mirrors it to disk so sequence
+// numbers and resendable messages survive restarts.
+//
+// <dir>/<session>.seqnums holds "sender target"; <dir>/<session>.messages holds
+// one "seqnum<TAB>raw message" line per outbound message.
+type fileStore struct {
+	*memoryStore
+	seqNumsPath  string
+	messagesPath string
+	messagesFile *os.File
+}
+
+// NewFileStore creates a store persisted under dir for the given session ID
+func NewFileStore(dir string, sessionID string) (MessageStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &fileStore{
+		memoryStore:  NewMemoryStore().(*memoryStore),
+		seqNumsPath:  filepath.Join(dir, sessionID+".seqnums"),
+		messagesPath: filepath.Join(dir, sessionID+".messages"),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(s.messagesPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.messagesFile = file
+
+	return s, nil
+}
+
+func (s *fileStore) load() error {
+	if data, err := os.ReadFile(s.seqNumsPath); err == nil {
+		parts := strings.Fields(string(data))
+		if len(parts) != 2 {
+			return fmt.Errorf("corrupt FIX sequence file %s", s.seqNumsPath)
+		}
+		if s.senderSeq, err = strconv.Atoi(parts[0]); err != nil {
+			return err
+		}
+		if s.targetSeq, err = strconv.Atoi(parts[1]); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	file, err := os.Open(s.messagesPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		tab := bytes.IndexByte(line, '\t')
+		if tab < 0 {
+			continue
+		}
+		seqNum, err := strconv.Atoi(string(line[:tab]))
+		if err != nil {
+			continue
+		}
+		s.messages[seqNum] = append([]byte(nil), line[tab+1:]...)
+	}
+	return scanner.Err()
+}
+
+func (s *fileStore) SetNextSenderSeqNum(seqNum int) error {
+	s.memoryStore.SetNextSenderSeqNum(seqNum)
+	return s.saveSeqNums()
+}
+
+func (s *fileStore) SetNextTargetSeqNum(seqNum int) error {
+	s.memoryStore.SetNextTargetSeqNum(seqNum)
+	return s.saveSeqNums()
+}
+
+func (s *fileStore) SaveMessage(seqNum int, raw []byte) error {
+	s.memoryStore.SaveMessage(seqNum, raw)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.messagesFile, "%d\t%s\n", seqNum, raw)
+	return err
+}
+
+func (s *fileStore) Reset() error {
+	s.memoryStore.Reset()
+
+	s.mu.Lock()
+	if err := s.messagesFile.Truncate(0); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.mu.Unlock()
+
+	return s.saveSeqNums()
+}
+
+func (s *fileStore) saveSeqNums() error {
+	s.mu.Lock()
+	data := fmt.Sprintf("%d %d\n", s.senderSeq, s.targetSeq)
+	s.mu.Unlock()
+
+	// Write then rename so a crash never leaves a half written file
+	tmp := s.seqNumsPath + ".tmp"
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.seqNumsPath)
+}
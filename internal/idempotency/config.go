@@ -0,0 +1,29 @@
+package idempotency
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// DefaultWindow is how long responses are kept when IDEMPOTENCY_WINDOW is not set
+const DefaultWindow = 24 * time.Hour
+
+// Config holds how long a stored response can be replayed
+type Config struct {
+	Window time.Duration
+}
+
+// ConfigFromEnv builds a Config from IDEMPOTENCY_WINDOW (a duration such as "24h")
+func ConfigFromEnv() Config {
+	config := Config{Window: DefaultWindow}
+	if value := os.Getenv("IDEMPOTENCY_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window <= 0 {
+			log.Printf("Ignoring invalid IDEMPOTENCY_WINDOW %q", value)
+		} else {
+			config.Window = window
+		}
+	}
+	return config
+}
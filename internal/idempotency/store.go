@@ -0,0 +1,124 @@
+// Package idempotency stores responses to mutating requests by idempotency key so retries can be
+// answered without repeating the request.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"mini-crypto-exchange/internal/apperrors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// pruneInterval bounds how often expired entries are swept
+const pruneInterval = time.Minute
+
+// Response is a stored response
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// Fingerprint identifies a request by method, URI and body
+type Fingerprint [sha256.Size]byte
+
+// NewFingerprint computes a request's fingerprint
+func NewFingerprint(method string, uri string, body []byte) Fingerprint {
+	h := sha256.New()
+	h.Write([]byte(method + " " + uri + "\n"))
+	h.Write(body)
+
+	var fingerprint Fingerprint
+	copy(fingerprint[:], h.Sum(nil))
+	return fingerprint
+}
+
+type entry struct {
+	fingerprint Fingerprint
+	response    *Response // nil while the first request is in flight
+	createdAt   time.Time
+}
+
+// Store keeps the first response per user and key for the configured window
+type Store struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewStore creates a store for config
+func NewStore(config Config) *Store {
+	if config.Window <= 0 {
+		config.Window = DefaultWindow
+	}
+	return &Store{
+		window:  config.Window,
+		entries: make(map[string]*entry),
+		now:     time.Now,
+	}
+}
+
+// Begin claims a key for a request. It returns the stored response when the key was already used
+// for the same request, ErrIdempotencyKeyReused when it was used for a different one and
+// ErrIdempotencyKeyInProgress while the first request is still running. Otherwise it returns
+// nil and the caller must Complete or Release the key.
+func (s *Store) Begin(userID int64, key string, fingerprint Fingerprint) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+
+	id := storeKey(userID, key)
+	existing, exists := s.entries[id]
+	if exists && now.Sub(existing.createdAt) <= s.window {
+		switch {
+		case existing.fingerprint != fingerprint:
+			return nil, apperrors.ErrIdempotencyKeyReused
+		case existing.response == nil:
+			return nil, apperrors.ErrIdempotencyKeyInProgress
+		}
+		return existing.response, nil
+	}
+
+	s.entries[id] = &entry{fingerprint: fingerprint, createdAt: now}
+	return nil, nil
+}
+
+// Complete stores the response to a claimed key
+func (s *Store) Complete(userID int64, key string, response *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, exists := s.entries[storeKey(userID, key)]; exists {
+		existing.response = response
+	}
+}
+
+// Release frees a claimed key without storing a response, so the request can be retried
+func (s *Store) Release(userID int64, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, storeKey(userID, key))
+}
+
+// prune drops entries older than the window. Caller must hold s.mu.
+func (s *Store) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+	for id, existing := range s.entries {
+		if existing.response != nil && now.Sub(existing.createdAt) > s.window {
+			delete(s.entries, id)
+		}
+	}
+}
+
+func storeKey(userID int64, key string) string {
+	return strconv.FormatInt(userID, 10) + "|" + key
+}
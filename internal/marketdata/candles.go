@@ -0,0 +1,140 @@
+package marketdata
+
+import (
+	"mini-crypto-exchange/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxCandlesPerSeries caps the number of bars retained per pair and interval
+const maxCandlesPerSeries = 5000
+
+// CandleIntervals lists the supported candle intervals in ascending order
+var CandleIntervals = []string{"1m", "5m", "15m", "1h", "4h", "1d"}
+
+var intervalDurations = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// IsValidInterval reports whether the interval is supported
+func IsValidInterval(interval string) bool {
+	_, ok := intervalDurations[interval]
+	return ok
+}
+
+// CandleAggregator builds OHLCV candles per pair and interval from executed trades
+type CandleAggregator struct {
+	mu     sync.RWMutex
+	series map[string]map[string][]*models.Candle // pair -> interval -> candles ordered by open time
+	hub    *Hub
+}
+
+// NewCandleAggregator creates a new candle aggregator publishing updates to hub
+func NewCandleAggregator(hub *Hub) *CandleAggregator {
+	return &CandleAggregator{
+		series: make(map[string]map[string][]*models.Candle),
+		hub:    hub,
+	}
+}
+
+// OnTrade folds a trade into every interval's current candle
+func (ca *CandleAggregator) OnTrade(trade *models.Trade) {
+	updates := make([]models.Candle, 0, len(CandleIntervals))
+
+	ca.mu.Lock()
+	pairSeries, exists := ca.series[trade.Pair]
+	if !exists {
+		pairSeries = make(map[string][]*models.Candle)
+		ca.series[trade.Pair] = pairSeries
+	}
+
+	for _, interval := range CandleIntervals {
+		candle := ca.candleFor(pairSeries, trade.Pair, interval, trade.CreatedAt)
+		if candle.TradeCount == 0 {
+			candle.Open = trade.Price
+			candle.High = trade.Price
+			candle.Low = trade.Price
+		}
+		if trade.Price > candle.High {
+			candle.High = trade.Price
+		}
+		if trade.Price < candle.Low {
+			candle.Low = trade.Price
+		}
+		candle.Close = trade.Price
+		candle.Volume += trade.Quantity
+		candle.QuoteVolume += trade.Price * trade.Quantity
+		candle.TradeCount++
+		updates = append(updates, *candle)
+	}
+	ca.mu.Unlock()
+
+	if ca.hub == nil {
+		return
+	}
+	for i := range updates {
+		ca.hub.Publish(Event{Channel: ChannelCandles, Pair: trade.Pair, Data: updates[i]})
+	}
+}
+
+// candleFor returns the candle covering ts, creating it if needed. Caller must hold ca.mu.
+func (ca *CandleAggregator) candleFor(pairSeries map[string][]*models.Candle, pair string, interval string, ts time.Time) *models.Candle {
+	duration := intervalDurations[interval]
+	openTime := ts.UTC().Truncate(duration)
+	candles := pairSeries[interval]
+
+	// Trades almost always land in the latest candle
+	if n := len(candles); n > 0 && candles[n-1].OpenTime.Equal(openTime) {
+		return candles[n-1]
+	}
+
+	idx := sort.Search(len(candles), func(i int) bool {
+		return !candles[i].OpenTime.Before(openTime)
+	})
+	if idx < len(candles) && candles[idx].OpenTime.Equal(openTime) {
+		return candles[idx]
+	}
+
+	candle := &models.Candle{
+		Pair:      pair,
+		Interval:  interval,
+		OpenTime:  openTime,
+		CloseTime: openTime.Add(duration),
+	}
+	candles = append(candles, nil)
+	copy(candles[idx+1:], candles[idx:])
+	candles[idx] = candle
+
+	if len(candles) > maxCandlesPerSeries {
+		candles = candles[len(candles)-maxCandlesPerSeries:]
+	}
+	pairSeries[interval] = candles
+
+	return candle
+}
+
+// GetCandles returns candles for a pair and interval whose open time falls within [start, end].
+// A zero start or end leaves that side of the range unbounded.
+func (ca *CandleAggregator) GetCandles(pair string, interval string, start time.Time, end time.Time) []models.Candle {
+	ca.mu.RLock()
+	defer ca.mu.RUnlock()
+
+	result := make([]models.Candle, 0)
+	for _, candle := range ca.series[pair][interval] {
+		if !start.IsZero() && candle.OpenTime.Before(start) {
+			continue
+		}
+		if !end.IsZero() && candle.OpenTime.After(end) {
+			break
+		}
+		result = append(result, *candle)
+	}
+
+	return result
+}
@@ -0,0 +1,186 @@
+package marketdata
+
+import (
+	"mini-crypto-exchange/internal/models"
+	"testing"
+	"time"
+)
+
+const testPair = "BTC/USDT"
+
+// testTime is a Monday 12:00 UTC, aligned to every candle interval shorter than a day
+var testTime = time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+
+func trade(at time.Time, price float64, quantity float64) *models.Trade {
+	return &models.Trade{Pair: testPair, Price: price, Quantity: quantity, CreatedAt: at}
+}
+
+func openTimes(candles []models.Candle) []time.Time {
+	result := make([]time.Time, len(candles))
+	for i, candle := range candles {
+		result[i] = candle.OpenTime
+	}
+	return result
+}
+
+func expectOpenTimes(t *testing.T, what string, candles []models.Candle, want ...time.Time) {
+	t.Helper()
+	got := openTimes(candles)
+	if len(got) != len(want) {
+		t.Fatalf("%s: got candles opening at %v, want %v", what, got, want)
+	}
+	for i := range got {
+		if !got[i].Equal(want[i]) {
+			t.Fatalf("%s: got candles opening at %v, want %v", what, got, want)
+		}
+	}
+}
+
+func TestCandleAggregation(t *testing.T) {
+	ca := NewCandleAggregator(nil)
+	ca.OnTrade(trade(testTime.Add(5*time.Second), 100, 1))
+	ca.OnTrade(trade(testTime.Add(10*time.Second), 105, 2))
+	ca.OnTrade(trade(testTime.Add(20*time.Second), 95, 1))
+	ca.OnTrade(trade(testTime.Add(30*time.Second), 98, 0.5))
+
+	for _, interval := range CandleIntervals {
+		candles := ca.GetCandles(testPair, interval, time.Time{}, time.Time{})
+		if len(candles) != 1 {
+			t.Fatalf("%s: got %d candles, want 1", interval, len(candles))
+		}
+		want := models.Candle{
+			Pair:        testPair,
+			Interval:    interval,
+			OpenTime:    testTime.Truncate(intervalDurations[interval]),
+			CloseTime:   testTime.Truncate(intervalDurations[interval]).Add(intervalDurations[interval]),
+			Open:        100,
+			High:        105,
+			Low:         95,
+			Close:       98,
+			Volume:      4.5,
+			QuoteVolume: 100 + 210 + 95 + 49,
+			TradeCount:  4,
+		}
+		if got := candles[0]; got != want {
+			t.Errorf("%s: got %+v, want %+v", interval, got, want)
+		}
+	}
+
+	if candles := ca.GetCandles("ETH/USDT", "1m", time.Time{}, time.Time{}); len(candles) != 0 {
+		t.Fatalf("got %d candles for a pair without trades, want none", len(candles))
+	}
+}
+
+func TestCandleBucketBoundaries(t *testing.T) {
+	ca := NewCandleAggregator(nil)
+	lastInstant := time.Nanosecond
+	ca.OnTrade(trade(testTime.Add(-lastInstant), 100, 1))            // last instant of 11:59 and of the 11:00 hour
+	ca.OnTrade(trade(testTime, 101, 1))                              // opens 12:00
+	ca.OnTrade(trade(testTime.Add(time.Minute-lastInstant), 102, 1)) // still 12:00
+	ca.OnTrade(trade(testTime.Add(time.Minute), 103, 1))             // opens 12:01
+	ca.OnTrade(trade(testTime.Add(5*time.Minute), 104, 1))           // opens 12:05
+
+	expectOpenTimes(t, "1m", ca.GetCandles(testPair, "1m", time.Time{}, time.Time{}),
+		testTime.Add(-time.Minute), testTime, testTime.Add(time.Minute), testTime.Add(5*time.Minute))
+	expectOpenTimes(t, "5m", ca.GetCandles(testPair, "5m", time.Time{}, time.Time{}),
+		testTime.Add(-5*time.Minute), testTime, testTime.Add(5*time.Minute))
+	expectOpenTimes(t, "1h", ca.GetCandles(testPair, "1h", time.Time{}, time.Time{}),
+		testTime.Add(-time.Hour), testTime)
+	expectOpenTimes(t, "4h", ca.GetCandles(testPair, "4h", time.Time{}, time.Time{}),
+		testTime.Add(-4*time.Hour), testTime)
+	expectOpenTimes(t, "1d", ca.GetCandles(testPair, "1d", time.Time{}, time.Time{}),
+		time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC))
+
+	minute := ca.GetCandles(testPair, "1m", testTime, testTime)
+	if len(minute) != 1 || minute[0].Open != 101 || minute[0].Close != 102 || minute[0].TradeCount != 2 {
+		t.Fatalf("12:00 candle %+v, want the trades at its first and last instant", minute)
+	}
+
+	// Buckets are aligned in UTC whatever the trade's location
+	ca.OnTrade(trade(time.Date(2024, 3, 5, 0, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60)), 105, 1))
+	expectOpenTimes(t, "1d across time zones", ca.GetCandles(testPair, "1d", time.Time{}, time.Time{}),
+		time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC))
+	day := ca.GetCandles(testPair, "1d", time.Time{}, time.Time{})
+	if day[0].Close != 105 || day[0].TradeCount != 6 {
+		t.Fatalf("1d candle %+v, want the 22:30 UTC trade in March 4th", day[0])
+	}
+}
+
+func TestLateTradesUpdateEarlierCandles(t *testing.T) {
+	ca := NewCandleAggregator(nil)
+	ca.OnTrade(trade(testTime, 100, 1))
+	ca.OnTrade(trade(testTime.Add(2*time.Minute), 102, 1))
+	ca.OnTrade(trade(testTime.Add(time.Minute), 101, 1))
+	ca.OnTrade(trade(testTime.Add(30*time.Second), 99, 1))
+
+	candles := ca.GetCandles(testPair, "1m", time.Time{}, time.Time{})
+	expectOpenTimes(t, "1m", candles, testTime, testTime.Add(time.Minute), testTime.Add(2*time.Minute))
+	if candles[0].Low != 99 || candles[0].TradeCount != 2 || candles[1].Open != 101 {
+		t.Fatalf("candles %+v, want the late trades in their own minutes", candles)
+	}
+}
+
+func TestGetCandlesRange(t *testing.T) {
+	ca := NewCandleAggregator(nil)
+	for i := 0; i < 5; i++ {
+		ca.OnTrade(trade(testTime.Add(time.Duration(i)*time.Minute), 100, 1))
+	}
+	at := func(minute int) time.Time { return testTime.Add(time.Duration(minute) * time.Minute) }
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		want  []time.Time
+	}{
+		{"unbounded", time.Time{}, time.Time{}, []time.Time{at(0), at(1), at(2), at(3), at(4)}},
+		{"inclusive bounds", at(1), at(3), []time.Time{at(1), at(2), at(3)}},
+		{"start only", at(3), time.Time{}, []time.Time{at(3), at(4)}},
+		{"end only", time.Time{}, at(1), []time.Time{at(0), at(1)}},
+		{"bounds inside a candle", at(1).Add(time.Second), at(2).Add(time.Second), []time.Time{at(2)}},
+		{"after the last candle", at(5), time.Time{}, []time.Time{}},
+	}
+	for _, tt := range tests {
+		expectOpenTimes(t, tt.name, ca.GetCandles(testPair, "1m", tt.start, tt.end), tt.want...)
+	}
+}
+
+func TestCandleSeriesAreCapped(t *testing.T) {
+	ca := NewCandleAggregator(nil)
+	for i := 0; i <= maxCandlesPerSeries; i++ {
+		ca.OnTrade(trade(testTime.Add(time.Duration(i)*time.Minute), 100, 1))
+	}
+
+	candles := ca.GetCandles(testPair, "1m", time.Time{}, time.Time{})
+	if len(candles) != maxCandlesPerSeries {
+		t.Fatalf("got %d candles, want %d", len(candles), maxCandlesPerSeries)
+	}
+	if !candles[0].OpenTime.Equal(testTime.Add(time.Minute)) {
+		t.Fatalf("oldest candle opens at %v, want the first one dropped", candles[0].OpenTime)
+	}
+}
+
+func TestCandleUpdatesArePublished(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe([]string{ChannelCandles}, testPair)
+	defer sub.Close()
+	ca := NewCandleAggregator(hub)
+
+	ca.OnTrade(trade(testTime, 100, 1))
+	ca.OnTrade(trade(testTime.Add(time.Second), 101, 2))
+
+	for i := 0; i < 2*len(CandleIntervals); i++ {
+		select {
+		case event := <-sub.C:
+			candle, ok := event.Data.(models.Candle)
+			if !ok || event.Pair != testPair || candle.Interval != CandleIntervals[i%len(CandleIntervals)] {
+				t.Fatalf("event %d is %+v, want the %s candle", i, event, CandleIntervals[i%len(CandleIntervals)])
+			}
+			if i >= len(CandleIntervals) && (candle.Close != 101 || candle.Volume != 3) {
+				t.Fatalf("update %+v, want the candle after the second trade", candle)
+			}
+		default:
+			t.Fatalf("got %d candle updates, want %d", i, 2*len(CandleIntervals))
+		}
+	}
+}
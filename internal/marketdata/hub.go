@@ -0,0 +1,96 @@
+package marketdata
+
+import (
+	"sync"
+)
+
+const (
+	// ChannelCandles carries live candle updates
+	ChannelCandles = "candles"
+
+	// subscriptionBuffer is the number of events buffered per subscriber
+	subscriptionBuffer = 256
+)
+
+// Event is a single market data update pushed to stream subscribers
+type Event struct {
+	Channel string      `json:"channel"`
+	Pair    string      `json:"pair"`
+	Data    interface{} `json:"data"`
+}
+
+// Hub fans out market data events to subscribers
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives events matching its channel and pair filters
+type Subscription struct {
+	C        chan Event
+	channels map[string]bool
+	pair     string
+	hub      *Hub
+	once     sync.Once
+}
+
+// NewHub creates a new market data hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber for the given channels and pair.
+// An empty channel list or pair matches everything.
+func (h *Hub) Subscribe(channels []string, pair string) *Subscription {
+	sub := &Subscription{
+		C:        make(chan Event, subscriptionBuffer),
+		channels: make(map[string]bool),
+		pair:     pair,
+		hub:      h,
+	}
+	for _, channel := range channels {
+		sub.channels[channel] = true
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Publish delivers an event to every matching subscriber.
+// Slow subscribers whose buffer is full miss the event instead of blocking the publisher.
+func (h *Hub) Publish(event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.C <- event:
+		default:
+		}
+	}
+}
+
+// Close unregisters the subscription and closes its channel
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		delete(s.hub.subscribers, s)
+		s.hub.mu.Unlock()
+		close(s.C)
+	})
+}
+
+func (s *Subscription) matches(event Event) bool {
+	if len(s.channels) > 0 && !s.channels[event.Channel] {
+		return false
+	}
+	return s.pair == "" || s.pair == event.Pair
+}
@@ -0,0 +1,54 @@
+package marketdata
+
+import (
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+)
+
+const (
+	// ChannelTrades carries every executed trade
+	ChannelTrades = "trades"
+
+	// ChannelOrderBook carries order book snapshots after every change
+	ChannelOrderBook = "orderbook"
+
+	// ChannelAuction carries indicative call auction prices and each auction's final uncross
+	ChannelAuction = "auction"
+
+	// publishedBookDepth is the number of price levels per side in order book events
+	publishedBookDepth = 50
+)
+
+// Publisher forwards engine trades, order book changes and auction states to the hub
+type Publisher struct {
+	hub *Hub
+}
+
+// NewPublisher creates a new engine event publisher
+func NewPublisher(hub *Hub) *Publisher {
+	return &Publisher{hub: hub}
+}
+
+// OnTrade publishes an executed trade
+func (p *Publisher) OnTrade(trade *models.Trade) {
+	p.hub.Publish(Event{Channel: ChannelTrades, Pair: trade.Pair, Data: *trade})
+}
+
+// OnOrderBookUpdate publishes a depth snapshot of the changed order book
+func (p *Publisher) OnOrderBookUpdate(ob *engine.OrderBook) {
+	buys, sells := ob.GetDepth(publishedBookDepth)
+	p.hub.Publish(Event{
+		Channel: ChannelOrderBook,
+		Pair:    ob.Pair,
+		Data: map[string]interface{}{
+			"pair": ob.Pair,
+			"buy":  buys,
+			"sell": sells,
+		},
+	})
+}
+
+// OnAuctionUpdate publishes an indicative or final auction state
+func (p *Publisher) OnAuctionUpdate(state models.AuctionState) {
+	p.hub.Publish(Event{Channel: ChannelAuction, Pair: state.Pair, Data: state})
+}
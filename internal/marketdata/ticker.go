@@ -0,0 +1,144 @@
+package marketdata
+
+import (
+	"mini-crypto-exchange/internal/models"
+	"sync"
+	"time"
+)
+
+// TickerWindow is the rolling window covered by ticker statistics
+const TickerWindow = 24 * time.Hour
+
+// tickerBucketSize is the granularity of the rolling window; the window moves one bucket at a time
+const tickerBucketSize = time.Minute
+
+// tickerBuckets is the number of buckets covering TickerWindow
+const tickerBuckets = int64(TickerWindow / tickerBucketSize)
+
+// tickerBucket aggregates the trades of one minute
+type tickerBucket struct {
+	minute      int64 // unix minute the bucket holds, 0 when unused
+	open        float64
+	high        float64
+	low         float64
+	close       float64
+	volume      float64
+	quoteVolume float64
+	count       int64
+}
+
+// TickerTracker keeps per-minute aggregates of the last 24 hours per pair to compute rolling
+// statistics. Recording a trade is O(1) and reading a ticker is O(buckets).
+type TickerTracker struct {
+	mu      sync.Mutex
+	buckets map[string]*[tickerBuckets]tickerBucket // pair -> ring of buckets indexed by minute
+	now     func() time.Time
+}
+
+// NewTickerTracker creates a new ticker tracker
+func NewTickerTracker() *TickerTracker {
+	return &TickerTracker{
+		buckets: make(map[string]*[tickerBuckets]tickerBucket),
+		now:     time.Now,
+	}
+}
+
+// OnTrade folds a trade into its minute's bucket. Trades older than the bucket currently occupying
+// their slot have already left the window and are ignored.
+func (tt *TickerTracker) OnTrade(trade *models.Trade) {
+	minute := unixMinute(trade.CreatedAt)
+
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	ring, exists := tt.buckets[trade.Pair]
+	if !exists {
+		ring = new([tickerBuckets]tickerBucket)
+		tt.buckets[trade.Pair] = ring
+	}
+
+	bucket := &ring[minute%tickerBuckets]
+	switch {
+	case bucket.minute > minute:
+		return
+	case bucket.minute < minute:
+		*bucket = tickerBucket{minute: minute, open: trade.Price, high: trade.Price, low: trade.Price}
+	}
+
+	if trade.Price > bucket.high {
+		bucket.high = trade.Price
+	}
+	if trade.Price < bucket.low {
+		bucket.low = trade.Price
+	}
+	bucket.close = trade.Price
+	bucket.volume += trade.Quantity
+	bucket.quoteVolume += trade.Price * trade.Quantity
+	bucket.count++
+}
+
+// GetTicker returns the rolling statistics for a pair over the last TickerWindow, aligned to whole
+// minutes. Best bid and ask are left for the caller to fill from the order book.
+func (tt *TickerTracker) GetTicker(pair string) models.Ticker {
+	now := tt.now()
+	nowMinute := unixMinute(now)
+	firstMinute := nowMinute - tickerBuckets + 1
+
+	ticker := models.Ticker{
+		Pair:        pair,
+		WindowStart: time.Unix(firstMinute*int64(tickerBucketSize/time.Second), 0),
+		WindowEnd:   now,
+	}
+
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	ring, exists := tt.buckets[pair]
+	if !exists {
+		return ticker
+	}
+
+	var openMinute, lastMinute int64
+	for i := range ring {
+		bucket := &ring[i]
+		if bucket.count == 0 || bucket.minute < firstMinute || bucket.minute > nowMinute {
+			continue
+		}
+		if ticker.TradeCount == 0 {
+			ticker.High = bucket.high
+			ticker.Low = bucket.low
+		}
+		if openMinute == 0 || bucket.minute < openMinute {
+			openMinute = bucket.minute
+			ticker.Open = bucket.open
+		}
+		if bucket.minute > lastMinute {
+			lastMinute = bucket.minute
+			ticker.Last = bucket.close
+		}
+		if bucket.high > ticker.High {
+			ticker.High = bucket.high
+		}
+		if bucket.low < ticker.Low {
+			ticker.Low = bucket.low
+		}
+		ticker.Volume += bucket.volume
+		ticker.QuoteVolume += bucket.quoteVolume
+		ticker.TradeCount += bucket.count
+	}
+
+	if ticker.TradeCount == 0 {
+		return ticker
+	}
+	ticker.PriceChange = ticker.Last - ticker.Open
+	if ticker.Open > 0 {
+		ticker.PriceChangePercent = ticker.PriceChange / ticker.Open * 100
+	}
+
+	return ticker
+}
+
+// unixMinute returns the number of whole minutes since the Unix epoch
+func unixMinute(t time.Time) int64 {
+	return t.Unix() / int64(tickerBucketSize/time.Second)
+}
@@ -0,0 +1,107 @@
+package marketdata
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// newTestTickerTracker returns a tracker whose clock reads *now
+func newTestTickerTracker(now *time.Time) *TickerTracker {
+	tt := NewTickerTracker()
+	tt.now = func() time.Time { return *now }
+	return tt
+}
+
+func TestTickerStatistics(t *testing.T) {
+	now := testTime.Add(30 * time.Second)
+	tt := newTestTickerTracker(&now)
+	tt.OnTrade(trade(now.Add(-23*time.Hour), 100, 1))
+	tt.OnTrade(trade(now.Add(-2*time.Hour), 130, 2))
+	tt.OnTrade(trade(now.Add(-time.Hour), 90, 1))
+	tt.OnTrade(trade(now, 110, 0.5))
+
+	ticker := tt.GetTicker(testPair)
+	if ticker.Pair != testPair || ticker.Open != 100 || ticker.High != 130 || ticker.Low != 90 || ticker.Last != 110 {
+		t.Fatalf("ticker %+v, want open 100, high 130, low 90 and last 110", ticker)
+	}
+	if ticker.Volume != 4.5 || ticker.QuoteVolume != 100+260+90+55 || ticker.TradeCount != 4 {
+		t.Fatalf("ticker %+v, want 4 trades for 4.5 BTC and 505 USDT", ticker)
+	}
+	if ticker.PriceChange != 10 || math.Abs(ticker.PriceChangePercent-10) > 1e-9 {
+		t.Fatalf("ticker changed by %v (%v%%), want 10 (10%%)", ticker.PriceChange, ticker.PriceChangePercent)
+	}
+	if !ticker.WindowEnd.Equal(now) || !ticker.WindowStart.Equal(testTime.Add(-TickerWindow+time.Minute)) {
+		t.Fatalf("window %v to %v, want the 1440 whole minutes ending with now", ticker.WindowStart, ticker.WindowEnd)
+	}
+
+	empty := tt.GetTicker("ETH/USDT")
+	if empty.TradeCount != 0 || empty.Open != 0 || empty.Last != 0 || !empty.WindowStart.Equal(ticker.WindowStart) {
+		t.Fatalf("ticker of a pair without trades %+v, want zero statistics over the same window", empty)
+	}
+}
+
+func TestTickerWindowRolls(t *testing.T) {
+	now := testTime
+	tt := newTestTickerTracker(&now)
+	windowStart := now.Add(-TickerWindow + time.Minute)
+	tt.OnTrade(trade(windowStart.Add(-time.Nanosecond), 50, 1)) // the minute before the window
+	tt.OnTrade(trade(windowStart, 100, 1))                      // the window's first minute
+	tt.OnTrade(trade(windowStart.Add(time.Minute), 120, 1))
+	tt.OnTrade(trade(now, 110, 1))
+
+	ticker := tt.GetTicker(testPair)
+	if ticker.Open != 100 || ticker.Low != 100 || ticker.TradeCount != 3 {
+		t.Fatalf("ticker %+v, want the trades from the window's first minute on", ticker)
+	}
+
+	// The window moves a minute at a time, not a second at a time
+	now = now.Add(59 * time.Second)
+	if ticker := tt.GetTicker(testPair); ticker.Open != 100 || ticker.TradeCount != 3 {
+		t.Fatalf("within the same minute: ticker %+v, want the window unchanged", ticker)
+	}
+	now = now.Add(time.Second)
+	if ticker := tt.GetTicker(testPair); ticker.Open != 120 || ticker.High != 120 || ticker.TradeCount != 2 {
+		t.Fatalf("a minute later: ticker %+v, want the first minute dropped", ticker)
+	}
+
+	now = now.Add(TickerWindow)
+	if ticker := tt.GetTicker(testPair); ticker.TradeCount != 0 || ticker.Volume != 0 || ticker.PriceChange != 0 {
+		t.Fatalf("a day later: ticker %+v, want no trades", ticker)
+	}
+}
+
+func TestTickerReusesBucketsOfExpiredMinutes(t *testing.T) {
+	now := testTime
+	tt := newTestTickerTracker(&now)
+	tt.OnTrade(trade(now, 100, 1))
+
+	// The same slot a day later holds the new minute only
+	now = now.Add(TickerWindow)
+	tt.OnTrade(trade(now, 200, 2))
+	ticker := tt.GetTicker(testPair)
+	if ticker.Open != 200 || ticker.Low != 200 || ticker.Volume != 2 || ticker.TradeCount != 1 {
+		t.Fatalf("ticker %+v, want only the new trade", ticker)
+	}
+
+	// A late trade for the minute the slot held before is ignored
+	tt.OnTrade(trade(testTime.Add(time.Second), 50, 5))
+	if ticker := tt.GetTicker(testPair); ticker.Low != 200 || ticker.TradeCount != 1 {
+		t.Fatalf("after a late trade: ticker %+v, want it ignored", ticker)
+	}
+}
+
+func TestTickerIgnoresTradesAfterNow(t *testing.T) {
+	now := testTime
+	tt := newTestTickerTracker(&now)
+	tt.OnTrade(trade(now, 100, 1))
+	tt.OnTrade(trade(now.Add(time.Minute), 150, 1))
+
+	if ticker := tt.GetTicker(testPair); ticker.Last != 100 || ticker.TradeCount != 1 {
+		t.Fatalf("ticker %+v, want the trade in the next minute left out", ticker)
+	}
+	now = now.Add(time.Minute)
+	if ticker := tt.GetTicker(testPair); ticker.Last != 150 || ticker.TradeCount != 2 {
+		t.Fatalf("a minute later: ticker %+v, want both trades", ticker)
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+// AssetStatus is whether an asset can be used by new trading pairs
+type AssetStatus string
+
+const (
+	AssetStatusActive   AssetStatus = "active"   // new pairs may quote or trade the asset
+	AssetStatusDisabled AssetStatus = "disabled" // existing pairs keep trading, no new pairs
+)
+
+// IsValid reports whether s is a known status
+func (s AssetStatus) IsValid() bool {
+	return s == AssetStatusActive || s == AssetStatusDisabled
+}
+
+// Asset is a currency that trading pairs are built from
+type Asset struct {
+	Symbol   string      `json:"symbol"` // e.g., "BTC"
+	Name     string      `json:"name"`   // e.g., "Bitcoin"
+	Decimals int         `json:"decimals"`
+	Status   AssetStatus `json:"status"`
+}
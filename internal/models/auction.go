@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// AuctionState is a pair's call auction outcome: indicative while the auction runs,
+// final once the book has uncrossed
+type AuctionState struct {
+	Pair            string     `json:"pair"`
+	InAuction       bool       `json:"in_auction"`
+	IndicativePrice float64    `json:"indicative_price"` // 0 when no orders would match
+	MatchedVolume   float64    `json:"matched_volume"`
+	Imbalance       float64    `json:"imbalance"`                // volume left unmatched at the price
+	ImbalanceSide   string     `json:"imbalance_side,omitempty"` // "buy" or "sell"
+	EndsAt          *time.Time `json:"ends_at,omitempty"`        // nil when the auction ends on an admin decision
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Candle represents an OHLCV bar for a trading pair over a fixed interval
+type Candle struct {
+	Pair        string    `json:"pair"`
+	Interval    string    `json:"interval"` // e.g., "1m", "1h"
+	OpenTime    time.Time `json:"open_time"`
+	CloseTime   time.Time `json:"close_time"`
+	Open        float64   `json:"open"`
+	High        float64   `json:"high"`
+	Low         float64   `json:"low"`
+	Close       float64   `json:"close"`
+	Volume      float64   `json:"volume"`       // base asset volume
+	QuoteVolume float64   `json:"quote_volume"` // quote asset volume
+	TradeCount  int64     `json:"trade_count"`
+}
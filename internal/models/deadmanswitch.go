@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// DeadMansSwitch is a user's cancel-all-after countdown. Unless it is refreshed before TriggerTime,
+// every open order of the user is cancelled.
+type DeadMansSwitch struct {
+	TimeoutMs   int64      `json:"timeout_ms"` // 0 when the switch is disarmed
+	CurrentTime time.Time  `json:"current_time"`
+	TriggerTime *time.Time `json:"trigger_time,omitempty"`
+}
@@ -6,21 +6,33 @@ import (
 
 // TradingPair represents a currency pair
 type TradingPair struct {
-	Base  string // e.g., "BTC"
-	Quote string // e.g., "USDT"
+	Base  string `json:"base"`  // e.g., "BTC"
+	Quote string `json:"quote"` // e.g., "USDT"
+}
+
+// String returns the pair's name as used across the APIs, e.g. "BTC/USDT"
+func (p TradingPair) String() string {
+	return p.Base + "/" + p.Quote
 }
 
 // Order represents a user's buy/sell intent
 type Order struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Pair      string    `json:"pair"` // e.g., "BTC/USDT"
-	Side      string    `json:"side"` // "buy" or "sell"
-	Price     float64   `json:"price"`
-	Quantity  float64   `json:"quantity"`
-	Filled    float64   `json:"filled"`
-	Status    string    `json:"status"` // "open", "partial", "filled"
-	CreatedAt time.Time `json:"created_at"`
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"user_id"`
+	ClientOrderID string    `json:"client_order_id,omitempty"` // optional, unique per user among open and recent orders
+	Pair          string    `json:"pair"`                      // e.g., "BTC/USDT"
+	Side          string    `json:"side"`                      // "buy" or "sell"
+	Price         float64   `json:"price"`
+	Quantity      float64   `json:"quantity"`
+	Filled        float64   `json:"filled"`
+	Status        string    `json:"status"` // "open", "partial", "filled", "cancelled"
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// OrderPage is one page of an order history query
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"next_cursor,omitempty"` // empty on the last page
 }
 
 // Remaining returns the unfilled quantity
@@ -0,0 +1,22 @@
+package models
+
+// PairStatus is the lifecycle state of a trading pair
+type PairStatus string
+
+const (
+	PairStatusTrading    PairStatus = "trading"     // orders are accepted and matched
+	PairStatusHalted     PairStatus = "halted"      // no orders or amends, cancels are accepted
+	PairStatusCancelOnly PairStatus = "cancel_only" // only cancels are accepted
+	PairStatusPostOnly   PairStatus = "post_only"   // only orders that rest without matching are accepted
+	PairStatusAuction    PairStatus = "auction"     // orders and cancels are accepted, orders rest until the book uncrosses
+	PairStatusDelisted   PairStatus = "delisted"    // resting orders were cancelled, final
+)
+
+// IsValid reports whether s is a known status
+func (s PairStatus) IsValid() bool {
+	switch s {
+	case PairStatusTrading, PairStatusHalted, PairStatusCancelOnly, PairStatusPostOnly, PairStatusAuction, PairStatusDelisted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// CircuitBreakerEvent records a pair being halted after a sharp price move
+type CircuitBreakerEvent struct {
+	Pair           string    `json:"pair"`
+	ReferencePrice float64   `json:"reference_price"` // the window's price the move is measured from
+	TriggerPrice   float64   `json:"trigger_price"`
+	MovePercent    float64   `json:"move_percent"`
+	Window         string    `json:"window"`
+	HaltedAt       time.Time `json:"halted_at"`
+	ResumesAt      time.Time `json:"resumes_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Ticker represents rolling 24 hour statistics for a trading pair
+type Ticker struct {
+	Pair               string    `json:"pair"`
+	Open               float64   `json:"open"`
+	High               float64   `json:"high"`
+	Low                float64   `json:"low"`
+	Last               float64   `json:"last"`
+	Volume             float64   `json:"volume"`       // base asset volume
+	QuoteVolume        float64   `json:"quote_volume"` // quote asset volume
+	TradeCount         int64     `json:"trade_count"`
+	PriceChange        float64   `json:"price_change"`
+	PriceChangePercent float64   `json:"price_change_percent"`
+	BestBid            float64   `json:"best_bid"` // 0 when the book has no bids
+	BestAsk            float64   `json:"best_ask"` // 0 when the book has no asks
+	WindowStart        time.Time `json:"window_start"`
+	WindowEnd          time.Time `json:"window_end"`
+}
@@ -0,0 +1,1161 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: exchange.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Order struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Pair          string                 `protobuf:"bytes,3,opt,name=pair,proto3" json:"pair,omitempty"`
+	Side          string                 `protobuf:"bytes,4,opt,name=side,proto3" json:"side,omitempty"`
+	Price         float64                `protobuf:"fixed64,5,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity      float64                `protobuf:"fixed64,6,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Filled        float64                `protobuf:"fixed64,7,opt,name=filled,proto3" json:"filled,omitempty"`
+	Status        string                 `protobuf:"bytes,8,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ClientOrderId string                 `protobuf:"bytes,10,opt,name=client_order_id,json=clientOrderId,proto3" json:"client_order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_exchange_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Order) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Order) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Order) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *Order) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *Order) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Order) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *Order) GetFilled() float64 {
+	if x != nil {
+		return x.Filled
+	}
+	return 0
+}
+
+func (x *Order) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Order) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Order) GetClientOrderId() string {
+	if x != nil {
+		return x.ClientOrderId
+	}
+	return ""
+}
+
+type Trade struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	BuyOrderId    int64                  `protobuf:"varint,2,opt,name=buy_order_id,json=buyOrderId,proto3" json:"buy_order_id,omitempty"`
+	SellOrderId   int64                  `protobuf:"varint,3,opt,name=sell_order_id,json=sellOrderId,proto3" json:"sell_order_id,omitempty"`
+	Pair          string                 `protobuf:"bytes,4,opt,name=pair,proto3" json:"pair,omitempty"`
+	Price         float64                `protobuf:"fixed64,5,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity      float64                `protobuf:"fixed64,6,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Trade) Reset() {
+	*x = Trade{}
+	mi := &file_exchange_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Trade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trade) ProtoMessage() {}
+
+func (x *Trade) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trade.ProtoReflect.Descriptor instead.
+func (*Trade) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Trade) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Trade) GetBuyOrderId() int64 {
+	if x != nil {
+		return x.BuyOrderId
+	}
+	return 0
+}
+
+func (x *Trade) GetSellOrderId() int64 {
+	if x != nil {
+		return x.SellOrderId
+	}
+	return 0
+}
+
+func (x *Trade) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *Trade) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Trade) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *Trade) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type PriceLevel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Price         float64                `protobuf:"fixed64,1,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity      float64                `protobuf:"fixed64,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PriceLevel) Reset() {
+	*x = PriceLevel{}
+	mi := &file_exchange_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PriceLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceLevel) ProtoMessage() {}
+
+func (x *PriceLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceLevel.ProtoReflect.Descriptor instead.
+func (*PriceLevel) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PriceLevel) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *PriceLevel) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+type OrderBook struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pair          string                 `protobuf:"bytes,1,opt,name=pair,proto3" json:"pair,omitempty"`
+	Buy           []*PriceLevel          `protobuf:"bytes,2,rep,name=buy,proto3" json:"buy,omitempty"`
+	Sell          []*PriceLevel          `protobuf:"bytes,3,rep,name=sell,proto3" json:"sell,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderBook) Reset() {
+	*x = OrderBook{}
+	mi := &file_exchange_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderBook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBook) ProtoMessage() {}
+
+func (x *OrderBook) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBook.ProtoReflect.Descriptor instead.
+func (*OrderBook) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *OrderBook) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *OrderBook) GetBuy() []*PriceLevel {
+	if x != nil {
+		return x.Buy
+	}
+	return nil
+}
+
+func (x *OrderBook) GetSell() []*PriceLevel {
+	if x != nil {
+		return x.Sell
+	}
+	return nil
+}
+
+type CreatePairRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Base            string                 `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
+	Quote           string                 `protobuf:"bytes,2,opt,name=quote,proto3" json:"quote,omitempty"`
+	MatchingMode    string                 `protobuf:"bytes,3,opt,name=matching_mode,json=matchingMode,proto3" json:"matching_mode,omitempty"`              // "continuous" (default) or "batch"
+	BatchIntervalMs int64                  `protobuf:"varint,4,opt,name=batch_interval_ms,json=batchIntervalMs,proto3" json:"batch_interval_ms,omitempty"`  // batch mode only, defaults to 100
+	Allocation      string                 `protobuf:"bytes,5,opt,name=allocation,proto3" json:"allocation,omitempty"`                                      // "fifo" (default), "pro_rata" or "pro_rata_top"
+	TopOrderPercent float64                `protobuf:"fixed64,6,opt,name=top_order_percent,json=topOrderPercent,proto3" json:"top_order_percent,omitempty"` // pro_rata_top only
+	LmmPercent      float64                `protobuf:"fixed64,7,opt,name=lmm_percent,json=lmmPercent,proto3" json:"lmm_percent,omitempty"`                  // pro_rata_top only
+	LmmUserIds      []int64                `protobuf:"varint,8,rep,packed,name=lmm_user_ids,json=lmmUserIds,proto3" json:"lmm_user_ids,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CreatePairRequest) Reset() {
+	*x = CreatePairRequest{}
+	mi := &file_exchange_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePairRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePairRequest) ProtoMessage() {}
+
+func (x *CreatePairRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePairRequest.ProtoReflect.Descriptor instead.
+func (*CreatePairRequest) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreatePairRequest) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *CreatePairRequest) GetQuote() string {
+	if x != nil {
+		return x.Quote
+	}
+	return ""
+}
+
+func (x *CreatePairRequest) GetMatchingMode() string {
+	if x != nil {
+		return x.MatchingMode
+	}
+	return ""
+}
+
+func (x *CreatePairRequest) GetBatchIntervalMs() int64 {
+	if x != nil {
+		return x.BatchIntervalMs
+	}
+	return 0
+}
+
+func (x *CreatePairRequest) GetAllocation() string {
+	if x != nil {
+		return x.Allocation
+	}
+	return ""
+}
+
+func (x *CreatePairRequest) GetTopOrderPercent() float64 {
+	if x != nil {
+		return x.TopOrderPercent
+	}
+	return 0
+}
+
+func (x *CreatePairRequest) GetLmmPercent() float64 {
+	if x != nil {
+		return x.LmmPercent
+	}
+	return 0
+}
+
+func (x *CreatePairRequest) GetLmmUserIds() []int64 {
+	if x != nil {
+		return x.LmmUserIds
+	}
+	return nil
+}
+
+type CreatePairResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Pair            string                 `protobuf:"bytes,1,opt,name=pair,proto3" json:"pair,omitempty"`
+	MatchingMode    string                 `protobuf:"bytes,2,opt,name=matching_mode,json=matchingMode,proto3" json:"matching_mode,omitempty"`
+	BatchIntervalMs int64                  `protobuf:"varint,3,opt,name=batch_interval_ms,json=batchIntervalMs,proto3" json:"batch_interval_ms,omitempty"`
+	Allocation      string                 `protobuf:"bytes,4,opt,name=allocation,proto3" json:"allocation,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CreatePairResponse) Reset() {
+	*x = CreatePairResponse{}
+	mi := &file_exchange_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePairResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePairResponse) ProtoMessage() {}
+
+func (x *CreatePairResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePairResponse.ProtoReflect.Descriptor instead.
+func (*CreatePairResponse) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CreatePairResponse) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *CreatePairResponse) GetMatchingMode() string {
+	if x != nil {
+		return x.MatchingMode
+	}
+	return ""
+}
+
+func (x *CreatePairResponse) GetBatchIntervalMs() int64 {
+	if x != nil {
+		return x.BatchIntervalMs
+	}
+	return 0
+}
+
+func (x *CreatePairResponse) GetAllocation() string {
+	if x != nil {
+		return x.Allocation
+	}
+	return ""
+}
+
+type PlaceOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ignored, the caller is identified by its API key
+	Pair          string                 `protobuf:"bytes,2,opt,name=pair,proto3" json:"pair,omitempty"`
+	Side          string                 `protobuf:"bytes,3,opt,name=side,proto3" json:"side,omitempty"`
+	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity      float64                `protobuf:"fixed64,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	ClientOrderId string                 `protobuf:"bytes,6,opt,name=client_order_id,json=clientOrderId,proto3" json:"client_order_id,omitempty"` // optional, see POST /api/orders
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceOrderRequest) Reset() {
+	*x = PlaceOrderRequest{}
+	mi := &file_exchange_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderRequest) ProtoMessage() {}
+
+func (x *PlaceOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderRequest.ProtoReflect.Descriptor instead.
+func (*PlaceOrderRequest) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PlaceOrderRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *PlaceOrderRequest) GetClientOrderId() string {
+	if x != nil {
+		return x.ClientOrderId
+	}
+	return ""
+}
+
+type PlaceOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	Trades        []*Trade               `protobuf:"bytes,2,rep,name=trades,proto3" json:"trades,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceOrderResponse) Reset() {
+	*x = PlaceOrderResponse{}
+	mi := &file_exchange_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderResponse) ProtoMessage() {}
+
+func (x *PlaceOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderResponse.ProtoReflect.Descriptor instead.
+func (*PlaceOrderResponse) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PlaceOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *PlaceOrderResponse) GetTrades() []*Trade {
+	if x != nil {
+		return x.Trades
+	}
+	return nil
+}
+
+type GetOrdersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ignored, the caller is identified by its API key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrdersRequest) Reset() {
+	*x = GetOrdersRequest{}
+	mi := &file_exchange_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrdersRequest) ProtoMessage() {}
+
+func (x *GetOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrdersRequest.ProtoReflect.Descriptor instead.
+func (*GetOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetOrdersRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type GetOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrdersResponse) Reset() {
+	*x = GetOrdersResponse{}
+	mi := &file_exchange_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrdersResponse) ProtoMessage() {}
+
+func (x *GetOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrdersResponse.ProtoReflect.Descriptor instead.
+func (*GetOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetOrdersResponse) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+type GetOrderBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pair          string                 `protobuf:"bytes,1,opt,name=pair,proto3" json:"pair,omitempty"`
+	Depth         int32                  `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"` // defaults to 10
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderBookRequest) Reset() {
+	*x = GetOrderBookRequest{}
+	mi := &file_exchange_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderBookRequest) ProtoMessage() {}
+
+func (x *GetOrderBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderBookRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderBookRequest) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetOrderBookRequest) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *GetOrderBookRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+type StreamOrderBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pair          string                 `protobuf:"bytes,1,opt,name=pair,proto3" json:"pair,omitempty"`
+	Depth         int32                  `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"` // defaults to 10
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamOrderBookRequest) Reset() {
+	*x = StreamOrderBookRequest{}
+	mi := &file_exchange_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamOrderBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamOrderBookRequest) ProtoMessage() {}
+
+func (x *StreamOrderBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamOrderBookRequest.ProtoReflect.Descriptor instead.
+func (*StreamOrderBookRequest) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StreamOrderBookRequest) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *StreamOrderBookRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+type GetTradesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pair          string                 `protobuf:"bytes,1,opt,name=pair,proto3" json:"pair,omitempty"`    // empty returns trades for every pair
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"` // most recent trades, defaults to 100
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTradesRequest) Reset() {
+	*x = GetTradesRequest{}
+	mi := &file_exchange_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTradesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTradesRequest) ProtoMessage() {}
+
+func (x *GetTradesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTradesRequest.ProtoReflect.Descriptor instead.
+func (*GetTradesRequest) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetTradesRequest) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *GetTradesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type GetTradesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Trades        []*Trade               `protobuf:"bytes,1,rep,name=trades,proto3" json:"trades,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTradesResponse) Reset() {
+	*x = GetTradesResponse{}
+	mi := &file_exchange_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTradesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTradesResponse) ProtoMessage() {}
+
+func (x *GetTradesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTradesResponse.ProtoReflect.Descriptor instead.
+func (*GetTradesResponse) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetTradesResponse) GetTrades() []*Trade {
+	if x != nil {
+		return x.Trades
+	}
+	return nil
+}
+
+type StreamTradesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pair          string                 `protobuf:"bytes,1,opt,name=pair,proto3" json:"pair,omitempty"` // empty streams trades for every pair
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTradesRequest) Reset() {
+	*x = StreamTradesRequest{}
+	mi := &file_exchange_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTradesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTradesRequest) ProtoMessage() {}
+
+func (x *StreamTradesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_exchange_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTradesRequest.ProtoReflect.Descriptor instead.
+func (*StreamTradesRequest) Descriptor() ([]byte, []int) {
+	return file_exchange_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StreamTradesRequest) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+var File_exchange_proto protoreflect.FileDescriptor
+
+const file_exchange_proto_rawDesc = "" +
+	"\n" +
+	"\x0eexchange.proto\x12\vexchange.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9d\x02\n" +
+	"\x05Order\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12\x12\n" +
+	"\x04pair\x18\x03 \x01(\tR\x04pair\x12\x12\n" +
+	"\x04side\x18\x04 \x01(\tR\x04side\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\x01R\x05price\x12\x1a\n" +
+	"\bquantity\x18\x06 \x01(\x01R\bquantity\x12\x16\n" +
+	"\x06filled\x18\a \x01(\x01R\x06filled\x12\x16\n" +
+	"\x06status\x18\b \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12&\n" +
+	"\x0fclient_order_id\x18\n" +
+	" \x01(\tR\rclientOrderId\"\xde\x01\n" +
+	"\x05Trade\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12 \n" +
+	"\fbuy_order_id\x18\x02 \x01(\x03R\n" +
+	"buyOrderId\x12\"\n" +
+	"\rsell_order_id\x18\x03 \x01(\x03R\vsellOrderId\x12\x12\n" +
+	"\x04pair\x18\x04 \x01(\tR\x04pair\x12\x14\n" +
+	"\x05price\x18\x05 \x01(\x01R\x05price\x12\x1a\n" +
+	"\bquantity\x18\x06 \x01(\x01R\bquantity\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\">\n" +
+	"\n" +
+	"PriceLevel\x12\x14\n" +
+	"\x05price\x18\x01 \x01(\x01R\x05price\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x01R\bquantity\"w\n" +
+	"\tOrderBook\x12\x12\n" +
+	"\x04pair\x18\x01 \x01(\tR\x04pair\x12)\n" +
+	"\x03buy\x18\x02 \x03(\v2\x17.exchange.v1.PriceLevelR\x03buy\x12+\n" +
+	"\x04sell\x18\x03 \x03(\v2\x17.exchange.v1.PriceLevelR\x04sell\"\x9d\x02\n" +
+	"\x11CreatePairRequest\x12\x12\n" +
+	"\x04base\x18\x01 \x01(\tR\x04base\x12\x14\n" +
+	"\x05quote\x18\x02 \x01(\tR\x05quote\x12#\n" +
+	"\rmatching_mode\x18\x03 \x01(\tR\fmatchingMode\x12*\n" +
+	"\x11batch_interval_ms\x18\x04 \x01(\x03R\x0fbatchIntervalMs\x12\x1e\n" +
+	"\n" +
+	"allocation\x18\x05 \x01(\tR\n" +
+	"allocation\x12*\n" +
+	"\x11top_order_percent\x18\x06 \x01(\x01R\x0ftopOrderPercent\x12\x1f\n" +
+	"\vlmm_percent\x18\a \x01(\x01R\n" +
+	"lmmPercent\x12 \n" +
+	"\flmm_user_ids\x18\b \x03(\x03R\n" +
+	"lmmUserIds\"\x99\x01\n" +
+	"\x12CreatePairResponse\x12\x12\n" +
+	"\x04pair\x18\x01 \x01(\tR\x04pair\x12#\n" +
+	"\rmatching_mode\x18\x02 \x01(\tR\fmatchingMode\x12*\n" +
+	"\x11batch_interval_ms\x18\x03 \x01(\x03R\x0fbatchIntervalMs\x12\x1e\n" +
+	"\n" +
+	"allocation\x18\x04 \x01(\tR\n" +
+	"allocation\"\xae\x01\n" +
+	"\x11PlaceOrderRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x12\n" +
+	"\x04pair\x18\x02 \x01(\tR\x04pair\x12\x12\n" +
+	"\x04side\x18\x03 \x01(\tR\x04side\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x01R\x05price\x12\x1a\n" +
+	"\bquantity\x18\x05 \x01(\x01R\bquantity\x12&\n" +
+	"\x0fclient_order_id\x18\x06 \x01(\tR\rclientOrderId\"j\n" +
+	"\x12PlaceOrderResponse\x12(\n" +
+	"\x05order\x18\x01 \x01(\v2\x12.exchange.v1.OrderR\x05order\x12*\n" +
+	"\x06trades\x18\x02 \x03(\v2\x12.exchange.v1.TradeR\x06trades\"+\n" +
+	"\x10GetOrdersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\"?\n" +
+	"\x11GetOrdersResponse\x12*\n" +
+	"\x06orders\x18\x01 \x03(\v2\x12.exchange.v1.OrderR\x06orders\"?\n" +
+	"\x13GetOrderBookRequest\x12\x12\n" +
+	"\x04pair\x18\x01 \x01(\tR\x04pair\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\x05R\x05depth\"B\n" +
+	"\x16StreamOrderBookRequest\x12\x12\n" +
+	"\x04pair\x18\x01 \x01(\tR\x04pair\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\x05R\x05depth\"<\n" +
+	"\x10GetTradesRequest\x12\x12\n" +
+	"\x04pair\x18\x01 \x01(\tR\x04pair\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"?\n" +
+	"\x11GetTradesResponse\x12*\n" +
+	"\x06trades\x18\x01 \x03(\v2\x12.exchange.v1.TradeR\x06trades\")\n" +
+	"\x13StreamTradesRequest\x12\x12\n" +
+	"\x04pair\x18\x01 \x01(\tR\x04pair2\\\n" +
+	"\vPairService\x12M\n" +
+	"\n" +
+	"CreatePair\x12\x1e.exchange.v1.CreatePairRequest\x1a\x1f.exchange.v1.CreatePairResponse2\xa9\x01\n" +
+	"\fOrderService\x12M\n" +
+	"\n" +
+	"PlaceOrder\x12\x1e.exchange.v1.PlaceOrderRequest\x1a\x1f.exchange.v1.PlaceOrderResponse\x12J\n" +
+	"\tGetOrders\x12\x1d.exchange.v1.GetOrdersRequest\x1a\x1e.exchange.v1.GetOrdersResponse2\xae\x01\n" +
+	"\x10OrderBookService\x12H\n" +
+	"\fGetOrderBook\x12 .exchange.v1.GetOrderBookRequest\x1a\x16.exchange.v1.OrderBook\x12P\n" +
+	"\x0fStreamOrderBook\x12#.exchange.v1.StreamOrderBookRequest\x1a\x16.exchange.v1.OrderBook0\x012\xa2\x01\n" +
+	"\fTradeService\x12J\n" +
+	"\tGetTrades\x12\x1d.exchange.v1.GetTradesRequest\x1a\x1e.exchange.v1.GetTradesResponse\x12F\n" +
+	"\fStreamTrades\x12 .exchange.v1.StreamTradesRequest\x1a\x12.exchange.v1.Trade0\x01B%Z#mini-crypto-exchange/internal/pb;pbb\x06proto3"
+
+var (
+	file_exchange_proto_rawDescOnce sync.Once
+	file_exchange_proto_rawDescData []byte
+)
+
+func file_exchange_proto_rawDescGZIP() []byte {
+	file_exchange_proto_rawDescOnce.Do(func() {
+		file_exchange_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_exchange_proto_rawDesc), len(file_exchange_proto_rawDesc)))
+	})
+	return file_exchange_proto_rawDescData
+}
+
+var file_exchange_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_exchange_proto_goTypes = []any{
+	(*Order)(nil),                  // 0: exchange.v1.Order
+	(*Trade)(nil),                  // 1: exchange.v1.Trade
+	(*PriceLevel)(nil),             // 2: exchange.v1.PriceLevel
+	(*OrderBook)(nil),              // 3: exchange.v1.OrderBook
+	(*CreatePairRequest)(nil),      // 4: exchange.v1.CreatePairRequest
+	(*CreatePairResponse)(nil),     // 5: exchange.v1.CreatePairResponse
+	(*PlaceOrderRequest)(nil),      // 6: exchange.v1.PlaceOrderRequest
+	(*PlaceOrderResponse)(nil),     // 7: exchange.v1.PlaceOrderResponse
+	(*GetOrdersRequest)(nil),       // 8: exchange.v1.GetOrdersRequest
+	(*GetOrdersResponse)(nil),      // 9: exchange.v1.GetOrdersResponse
+	(*GetOrderBookRequest)(nil),    // 10: exchange.v1.GetOrderBookRequest
+	(*StreamOrderBookRequest)(nil), // 11: exchange.v1.StreamOrderBookRequest
+	(*GetTradesRequest)(nil),       // 12: exchange.v1.GetTradesRequest
+	(*GetTradesResponse)(nil),      // 13: exchange.v1.GetTradesResponse
+	(*StreamTradesRequest)(nil),    // 14: exchange.v1.StreamTradesRequest
+	(*timestamppb.Timestamp)(nil),  // 15: google.protobuf.Timestamp
+}
+var file_exchange_proto_depIdxs = []int32{
+	15, // 0: exchange.v1.Order.created_at:type_name -> google.protobuf.Timestamp
+	15, // 1: exchange.v1.Trade.created_at:type_name -> google.protobuf.Timestamp
+	2,  // 2: exchange.v1.OrderBook.buy:type_name -> exchange.v1.PriceLevel
+	2,  // 3: exchange.v1.OrderBook.sell:type_name -> exchange.v1.PriceLevel
+	0,  // 4: exchange.v1.PlaceOrderResponse.order:type_name -> exchange.v1.Order
+	1,  // 5: exchange.v1.PlaceOrderResponse.trades:type_name -> exchange.v1.Trade
+	0,  // 6: exchange.v1.GetOrdersResponse.orders:type_name -> exchange.v1.Order
+	1,  // 7: exchange.v1.GetTradesResponse.trades:type_name -> exchange.v1.Trade
+	4,  // 8: exchange.v1.PairService.CreatePair:input_type -> exchange.v1.CreatePairRequest
+	6,  // 9: exchange.v1.OrderService.PlaceOrder:input_type -> exchange.v1.PlaceOrderRequest
+	8,  // 10: exchange.v1.OrderService.GetOrders:input_type -> exchange.v1.GetOrdersRequest
+	10, // 11: exchange.v1.OrderBookService.GetOrderBook:input_type -> exchange.v1.GetOrderBookRequest
+	11, // 12: exchange.v1.OrderBookService.StreamOrderBook:input_type -> exchange.v1.StreamOrderBookRequest
+	12, // 13: exchange.v1.TradeService.GetTrades:input_type -> exchange.v1.GetTradesRequest
+	14, // 14: exchange.v1.TradeService.StreamTrades:input_type -> exchange.v1.StreamTradesRequest
+	5,  // 15: exchange.v1.PairService.CreatePair:output_type -> exchange.v1.CreatePairResponse
+	7,  // 16: exchange.v1.OrderService.PlaceOrder:output_type -> exchange.v1.PlaceOrderResponse
+	9,  // 17: exchange.v1.OrderService.GetOrders:output_type -> exchange.v1.GetOrdersResponse
+	3,  // 18: exchange.v1.OrderBookService.GetOrderBook:output_type -> exchange.v1.OrderBook
+	3,  // 19: exchange.v1.OrderBookService.StreamOrderBook:output_type -> exchange.v1.OrderBook
+	13, // 20: exchange.v1.TradeService.GetTrades:output_type -> exchange.v1.GetTradesResponse
+	1,  // 21: exchange.v1.TradeService.StreamTrades:output_type -> exchange.v1.Trade
+	15, // [15:22] is the sub-list for method output_type
+	8,  // [8:15] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_exchange_proto_init() }
+func file_exchange_proto_init() {
+	if File_exchange_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_exchange_proto_rawDesc), len(file_exchange_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   4,
+		},
+		GoTypes:           file_exchange_proto_goTypes,
+		DependencyIndexes: file_exchange_proto_depIdxs,
+		MessageInfos:      file_exchange_proto_msgTypes,
+	}.Build()
+	File_exchange_proto = out.File
+	file_exchange_proto_goTypes = nil
+	file_exchange_proto_depIdxs = nil
+}
@@ -0,0 +1,573 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: exchange.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PairService_CreatePair_FullMethodName = "/exchange.v1.PairService/CreatePair"
+)
+
+// PairServiceClient is the client API for PairService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PairService manages trading pairs (mirrors POST /api/pairs)
+type PairServiceClient interface {
+	CreatePair(ctx context.Context, in *CreatePairRequest, opts ...grpc.CallOption) (*CreatePairResponse, error)
+}
+
+type pairServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPairServiceClient(cc grpc.ClientConnInterface) PairServiceClient {
+	return &pairServiceClient{cc}
+}
+
+func (c *pairServiceClient) CreatePair(ctx context.Context, in *CreatePairRequest, opts ...grpc.CallOption) (*CreatePairResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreatePairResponse)
+	err := c.cc.Invoke(ctx, PairService_CreatePair_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PairServiceServer is the server API for PairService service.
+// All implementations must embed UnimplementedPairServiceServer
+// for forward compatibility.
+//
+// PairService manages trading pairs (mirrors POST /api/pairs)
+type PairServiceServer interface {
+	CreatePair(context.Context, *CreatePairRequest) (*CreatePairResponse, error)
+	mustEmbedUnimplementedPairServiceServer()
+}
+
+// UnimplementedPairServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPairServiceServer struct{}
+
+func (UnimplementedPairServiceServer) CreatePair(context.Context, *CreatePairRequest) (*CreatePairResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreatePair not implemented")
+}
+func (UnimplementedPairServiceServer) mustEmbedUnimplementedPairServiceServer() {}
+func (UnimplementedPairServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafePairServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PairServiceServer will
+// result in compilation errors.
+type UnsafePairServiceServer interface {
+	mustEmbedUnimplementedPairServiceServer()
+}
+
+func RegisterPairServiceServer(s grpc.ServiceRegistrar, srv PairServiceServer) {
+	// If the following call panics, it indicates UnimplementedPairServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PairService_ServiceDesc, srv)
+}
+
+func _PairService_CreatePair_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePairRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PairServiceServer).CreatePair(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PairService_CreatePair_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PairServiceServer).CreatePair(ctx, req.(*CreatePairRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PairService_ServiceDesc is the grpc.ServiceDesc for PairService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PairService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "exchange.v1.PairService",
+	HandlerType: (*PairServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreatePair",
+			Handler:    _PairService_CreatePair_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "exchange.proto",
+}
+
+const (
+	OrderService_PlaceOrder_FullMethodName = "/exchange.v1.OrderService/PlaceOrder"
+	OrderService_GetOrders_FullMethodName  = "/exchange.v1.OrderService/GetOrders"
+)
+
+// OrderServiceClient is the client API for OrderService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// OrderService places and queries orders (mirrors POST/GET /api/orders).
+// Calls must be signed: send x-api-key, x-api-timestamp, x-api-nonce and x-api-signature
+// metadata, signing timestamp + nonce + "POST" + full method name + the serialized request.
+type OrderServiceClient interface {
+	// A duplicate client_order_id fails with ALREADY_EXISTS and the original Order in the status details
+	PlaceOrder(ctx context.Context, in *PlaceOrderRequest, opts ...grpc.CallOption) (*PlaceOrderResponse, error)
+	GetOrders(ctx context.Context, in *GetOrdersRequest, opts ...grpc.CallOption) (*GetOrdersResponse, error)
+}
+
+type orderServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrderServiceClient(cc grpc.ClientConnInterface) OrderServiceClient {
+	return &orderServiceClient{cc}
+}
+
+func (c *orderServiceClient) PlaceOrder(ctx context.Context, in *PlaceOrderRequest, opts ...grpc.CallOption) (*PlaceOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PlaceOrderResponse)
+	err := c.cc.Invoke(ctx, OrderService_PlaceOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderServiceClient) GetOrders(ctx context.Context, in *GetOrdersRequest, opts ...grpc.CallOption) (*GetOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOrdersResponse)
+	err := c.cc.Invoke(ctx, OrderService_GetOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OrderServiceServer is the server API for OrderService service.
+// All implementations must embed UnimplementedOrderServiceServer
+// for forward compatibility.
+//
+// OrderService places and queries orders (mirrors POST/GET /api/orders).
+// Calls must be signed: send x-api-key, x-api-timestamp, x-api-nonce and x-api-signature
+// metadata, signing timestamp + nonce + "POST" + full method name + the serialized request.
+type OrderServiceServer interface {
+	// A duplicate client_order_id fails with ALREADY_EXISTS and the original Order in the status details
+	PlaceOrder(context.Context, *PlaceOrderRequest) (*PlaceOrderResponse, error)
+	GetOrders(context.Context, *GetOrdersRequest) (*GetOrdersResponse, error)
+	mustEmbedUnimplementedOrderServiceServer()
+}
+
+// UnimplementedOrderServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrderServiceServer struct{}
+
+func (UnimplementedOrderServiceServer) PlaceOrder(context.Context, *PlaceOrderRequest) (*PlaceOrderResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PlaceOrder not implemented")
+}
+func (UnimplementedOrderServiceServer) GetOrders(context.Context, *GetOrdersRequest) (*GetOrdersResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetOrders not implemented")
+}
+func (UnimplementedOrderServiceServer) mustEmbedUnimplementedOrderServiceServer() {}
+func (UnimplementedOrderServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeOrderServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrderServiceServer will
+// result in compilation errors.
+type UnsafeOrderServiceServer interface {
+	mustEmbedUnimplementedOrderServiceServer()
+}
+
+func RegisterOrderServiceServer(s grpc.ServiceRegistrar, srv OrderServiceServer) {
+	// If the following call panics, it indicates UnimplementedOrderServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OrderService_ServiceDesc, srv)
+}
+
+func _OrderService_PlaceOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlaceOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).PlaceOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderService_PlaceOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).PlaceOrder(ctx, req.(*PlaceOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderService_GetOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).GetOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderService_GetOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).GetOrders(ctx, req.(*GetOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OrderService_ServiceDesc is the grpc.ServiceDesc for OrderService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OrderService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "exchange.v1.OrderService",
+	HandlerType: (*OrderServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PlaceOrder",
+			Handler:    _OrderService_PlaceOrder_Handler,
+		},
+		{
+			MethodName: "GetOrders",
+			Handler:    _OrderService_GetOrders_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "exchange.proto",
+}
+
+const (
+	OrderBookService_GetOrderBook_FullMethodName    = "/exchange.v1.OrderBookService/GetOrderBook"
+	OrderBookService_StreamOrderBook_FullMethodName = "/exchange.v1.OrderBookService/StreamOrderBook"
+)
+
+// OrderBookServiceClient is the client API for OrderBookService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// OrderBookService queries and streams order books (mirrors GET /api/orderbook)
+type OrderBookServiceClient interface {
+	GetOrderBook(ctx context.Context, in *GetOrderBookRequest, opts ...grpc.CallOption) (*OrderBook, error)
+	// StreamOrderBook sends the current snapshot followed by a snapshot after every book change
+	StreamOrderBook(ctx context.Context, in *StreamOrderBookRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderBook], error)
+}
+
+type orderBookServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrderBookServiceClient(cc grpc.ClientConnInterface) OrderBookServiceClient {
+	return &orderBookServiceClient{cc}
+}
+
+func (c *orderBookServiceClient) GetOrderBook(ctx context.Context, in *GetOrderBookRequest, opts ...grpc.CallOption) (*OrderBook, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderBook)
+	err := c.cc.Invoke(ctx, OrderBookService_GetOrderBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookServiceClient) StreamOrderBook(ctx context.Context, in *StreamOrderBookRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderBook], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderBookService_ServiceDesc.Streams[0], OrderBookService_StreamOrderBook_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamOrderBookRequest, OrderBook]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBookService_StreamOrderBookClient = grpc.ServerStreamingClient[OrderBook]
+
+// OrderBookServiceServer is the server API for OrderBookService service.
+// All implementations must embed UnimplementedOrderBookServiceServer
+// for forward compatibility.
+//
+// OrderBookService queries and streams order books (mirrors GET /api/orderbook)
+type OrderBookServiceServer interface {
+	GetOrderBook(context.Context, *GetOrderBookRequest) (*OrderBook, error)
+	// StreamOrderBook sends the current snapshot followed by a snapshot after every book change
+	StreamOrderBook(*StreamOrderBookRequest, grpc.ServerStreamingServer[OrderBook]) error
+	mustEmbedUnimplementedOrderBookServiceServer()
+}
+
+// UnimplementedOrderBookServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrderBookServiceServer struct{}
+
+func (UnimplementedOrderBookServiceServer) GetOrderBook(context.Context, *GetOrderBookRequest) (*OrderBook, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetOrderBook not implemented")
+}
+func (UnimplementedOrderBookServiceServer) StreamOrderBook(*StreamOrderBookRequest, grpc.ServerStreamingServer[OrderBook]) error {
+	return status.Error(codes.Unimplemented, "method StreamOrderBook not implemented")
+}
+func (UnimplementedOrderBookServiceServer) mustEmbedUnimplementedOrderBookServiceServer() {}
+func (UnimplementedOrderBookServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeOrderBookServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrderBookServiceServer will
+// result in compilation errors.
+type UnsafeOrderBookServiceServer interface {
+	mustEmbedUnimplementedOrderBookServiceServer()
+}
+
+func RegisterOrderBookServiceServer(s grpc.ServiceRegistrar, srv OrderBookServiceServer) {
+	// If the following call panics, it indicates UnimplementedOrderBookServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OrderBookService_ServiceDesc, srv)
+}
+
+func _OrderBookService_GetOrderBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServiceServer).GetOrderBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBookService_GetOrderBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServiceServer).GetOrderBook(ctx, req.(*GetOrderBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBookService_StreamOrderBook_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamOrderBookRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderBookServiceServer).StreamOrderBook(m, &grpc.GenericServerStream[StreamOrderBookRequest, OrderBook]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBookService_StreamOrderBookServer = grpc.ServerStreamingServer[OrderBook]
+
+// OrderBookService_ServiceDesc is the grpc.ServiceDesc for OrderBookService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OrderBookService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "exchange.v1.OrderBookService",
+	HandlerType: (*OrderBookServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetOrderBook",
+			Handler:    _OrderBookService_GetOrderBook_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamOrderBook",
+			Handler:       _OrderBookService_StreamOrderBook_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "exchange.proto",
+}
+
+const (
+	TradeService_GetTrades_FullMethodName    = "/exchange.v1.TradeService/GetTrades"
+	TradeService_StreamTrades_FullMethodName = "/exchange.v1.TradeService/StreamTrades"
+)
+
+// TradeServiceClient is the client API for TradeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TradeService queries and streams executed trades
+type TradeServiceClient interface {
+	GetTrades(ctx context.Context, in *GetTradesRequest, opts ...grpc.CallOption) (*GetTradesResponse, error)
+	StreamTrades(ctx context.Context, in *StreamTradesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Trade], error)
+}
+
+type tradeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTradeServiceClient(cc grpc.ClientConnInterface) TradeServiceClient {
+	return &tradeServiceClient{cc}
+}
+
+func (c *tradeServiceClient) GetTrades(ctx context.Context, in *GetTradesRequest, opts ...grpc.CallOption) (*GetTradesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTradesResponse)
+	err := c.cc.Invoke(ctx, TradeService_GetTrades_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *tradeServiceClient) StreamTrades(ctx context.Context, in *StreamTradesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Trade], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TradeService_ServiceDesc.Streams[0], TradeService_StreamTrades_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTradesRequest, Trade]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TradeService_StreamTradesClient = grpc.ServerStreamingClient[Trade]
+
+// TradeServiceServer is the server API for TradeService service.
+// All implementations must embed UnimplementedTradeServiceServer
+// for forward compatibility.
+//
+// TradeService queries and streams executed trades
+type TradeServiceServer interface {
+	GetTrades(context.Context, *GetTradesRequest) (*GetTradesResponse, error)
+	StreamTrades(*StreamTradesRequest, grpc.ServerStreamingServer[Trade]) error
+	mustEmbedUnimplementedTradeServiceServer()
+}
+
+// UnimplementedTradeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTradeServiceServer struct{}
+
+func (UnimplementedTradeServiceServer) GetTrades(context.Context, *GetTradesRequest) (*GetTradesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTrades not implemented")
+}
+func (UnimplementedTradeServiceServer) StreamTrades(*StreamTradesRequest, grpc.ServerStreamingServer[Trade]) error {
+	return status.Error(codes.Unimplemented, "method StreamTrades not implemented")
+}
+func (UnimplementedTradeServiceServer) mustEmbedUnimplementedTradeServiceServer() {}
+func (UnimplementedTradeServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeTradeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TradeServiceServer will
+// result in compilation errors.
+type UnsafeTradeServiceServer interface {
+	mustEmbedUnimplementedTradeServiceServer()
+}
+
+func RegisterTradeServiceServer(s grpc.ServiceRegistrar, srv TradeServiceServer) {
+	// If the following call panics, it indicates UnimplementedTradeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TradeService_ServiceDesc, srv)
+}
+
+func _TradeService_GetTrades_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTradesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TradeServiceServer).GetTrades(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TradeService_GetTrades_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TradeServiceServer).GetTrades(ctx, req.(*GetTradesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TradeService_StreamTrades_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTradesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TradeServiceServer).StreamTrades(m, &grpc.GenericServerStream[StreamTradesRequest, Trade]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TradeService_StreamTradesServer = grpc.ServerStreamingServer[Trade]
+
+// TradeService_ServiceDesc is the grpc.ServiceDesc for TradeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TradeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "exchange.v1.TradeService",
+	HandlerType: (*TradeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetTrades",
+			Handler:    _TradeService_GetTrades_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTrades",
+			Handler:       _TradeService_StreamTrades_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "exchange.proto",
+}
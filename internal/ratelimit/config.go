@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+// Config holds the bucket sizes and refill rates per request class
+type Config struct {
+	OrderBurst int
+	OrderRate  float64
+	QueryBurst int
+	QueryRate  float64
+}
+
+// ConfigFromEnv builds a Config from RATE_LIMIT_ORDER_BURST, RATE_LIMIT_ORDER_RATE,
+// RATE_LIMIT_QUERY_BURST and RATE_LIMIT_QUERY_RATE (tokens per second)
+func ConfigFromEnv() Config {
+	return Config{
+		OrderBurst: envInt("RATE_LIMIT_ORDER_BURST", 50),
+		OrderRate:  envFloat("RATE_LIMIT_ORDER_RATE", 10),
+		QueryBurst: envInt("RATE_LIMIT_QUERY_BURST", 200),
+		QueryRate:  envFloat("RATE_LIMIT_QUERY_RATE", 50),
+	}
+}
+
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Ignoring invalid %s %q", name, value)
+		return fallback
+	}
+	return parsed
+}
+
+func envFloat(name string, fallback float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed <= 0 {
+		log.Printf("Ignoring invalid %s %q", name, value)
+		return fallback
+	}
+	return parsed
+}
+
+// Limiters holds the limiter for each request class
+type Limiters struct {
+	Orders  *Limiter // order entry
+	Queries *Limiter // everything else
+}
+
+// NewLimiters creates the limiters described by config
+func NewLimiters(config Config) *Limiters {
+	return &Limiters{
+		Orders:  NewLimiter(config.OrderBurst, config.OrderRate),
+		Queries: NewLimiter(config.QueryBurst, config.QueryRate),
+	}
+}
@@ -0,0 +1,147 @@
+// Package ratelimit implements token bucket rate limiting keyed by client.
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// idleBucketTTL is how long a full bucket is kept after its last use
+const idleBucketTTL = 10 * time.Minute
+
+// Result is the outcome of taking tokens from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int           // bucket capacity
+	Remaining  int           // whole tokens left after the request
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the request would be allowed, zero when allowed
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps one token bucket per key. Buckets hold up to capacity tokens
+// and refill continuously at rate tokens per second.
+type Limiter struct {
+	capacity float64
+	rate     float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewLimiter creates a limiter with the given burst capacity and refill rate per second
+func NewLimiter(capacity int, ratePerSecond float64) *Limiter {
+	return &Limiter{
+		capacity: float64(capacity),
+		rate:     ratePerSecond,
+		buckets:  make(map[string]*bucket),
+		now:      time.Now,
+	}
+}
+
+// IPKey names the bucket of a client IP
+func IPKey(ip net.IP) string {
+	return "ip:" + ip.String()
+}
+
+// UserKey names the bucket of an authenticated user
+func UserKey(userID int64) string {
+	return "user:" + strconv.FormatInt(userID, 10)
+}
+
+// Take removes cost tokens from key's bucket when enough are available. A request never costs more
+// than the bucket's capacity, so a full bucket always admits it.
+func (l *Limiter) Take(key string, cost int) Result {
+	return l.TakeAll([]string{key}, cost)
+}
+
+// TakeAll removes cost tokens from every key's bucket, but only when all of them have enough; a
+// request rejected by one bucket costs nothing from the others. The result describes the bucket
+// that rejected the request or, when allowed, the one closest to its limit.
+func (l *Limiter) TakeAll(keys []string, cost int) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	need := math.Min(float64(cost), l.capacity)
+	buckets := make([]*bucket, len(keys))
+	for i, key := range keys {
+		buckets[i] = l.refill(key, now)
+		if buckets[i].tokens < need {
+			return l.result(buckets[i], false, need)
+		}
+	}
+
+	var result Result
+	for i, b := range buckets {
+		b.tokens -= need
+		if taken := l.result(b, true, need); i == 0 || taken.Remaining < result.Remaining {
+			result = taken
+		}
+	}
+	return result
+}
+
+// Refund returns cost tokens to key's bucket, for a request that was rejected after it was charged
+func (l *Limiter) Refund(key string, cost int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key, l.now())
+	b.tokens = math.Min(l.capacity, b.tokens+math.Min(float64(cost), l.capacity))
+}
+
+// refill returns key's bucket topped up to now, creating a full one. Caller must hold l.mu.
+func (l *Limiter) refill(key string, now time.Time) *bucket {
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: l.capacity, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.capacity, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	return b
+}
+
+// result describes bucket b after a request needing need tokens was allowed or rejected
+func (l *Limiter) result(b *bucket, allowed bool, need float64) Result {
+	result := Result{Limit: int(l.capacity), Allowed: allowed}
+	if !allowed {
+		result.RetryAfter = l.durationFor(need - b.tokens)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = l.durationFor(l.capacity - b.tokens)
+	return result
+}
+
+// durationFor returns how long refilling the given number of tokens takes
+func (l *Limiter) durationFor(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// prune drops buckets that have been idle long enough to be full. Caller must hold l.mu.
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < idleBucketTTL {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > idleBucketTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
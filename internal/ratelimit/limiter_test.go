@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestLimiter returns a limiter whose clock only moves when the test advances it
+func newTestLimiter(capacity int, ratePerSecond float64) (*Limiter, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewLimiter(capacity, ratePerSecond)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestTakeRefills(t *testing.T) {
+	limiter, now := newTestLimiter(2, 1)
+
+	for i := 0; i < 2; i++ {
+		if !limiter.Take("a", 1).Allowed {
+			t.Fatalf("request %d rejected", i)
+		}
+	}
+	result := limiter.Take("a", 1)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Fatalf("empty bucket: got %+v", result)
+	}
+
+	*now = now.Add(time.Second)
+	if !limiter.Take("a", 1).Allowed {
+		t.Fatal("refilled bucket rejected the request")
+	}
+}
+
+func TestTakeCapsCostAtCapacity(t *testing.T) {
+	limiter, _ := newTestLimiter(10, 1)
+
+	result := limiter.Take("a", 50)
+	if !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("request heavier than the burst: got %+v", result)
+	}
+}
+
+func TestTakeAllChargesNothingWhenOneBucketRejects(t *testing.T) {
+	limiter, _ := newTestLimiter(3, 1)
+	limiter.Take("user", 3)
+
+	if result := limiter.TakeAll([]string{"user", "ip"}, 1); result.Allowed {
+		t.Fatalf("empty user bucket: got %+v", result)
+	}
+	if result := limiter.Take("ip", 3); !result.Allowed {
+		t.Fatalf("IP bucket was charged for the rejected request: %+v", result)
+	}
+}
+
+func TestTakeAllReportsBucketClosestToLimit(t *testing.T) {
+	limiter, _ := newTestLimiter(5, 1)
+	limiter.Take("ip", 3)
+
+	result := limiter.TakeAll([]string{"user", "ip"}, 1)
+	if !result.Allowed || result.Remaining != 1 {
+		t.Fatalf("got %+v, want the IP bucket with 1 token left", result)
+	}
+}
+
+func TestRefund(t *testing.T) {
+	limiter, _ := newTestLimiter(2, 1)
+	limiter.Take("a", 2)
+	limiter.Refund("a", 5)
+
+	if result := limiter.Take("a", 2); !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("refund should restore at most the capacity: got %+v", result)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mini-crypto-exchange/internal/audit"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// AdminMiddleware requires a signed request by a key with the admin permission and records
+// the action, the admin and the request payload in the audit log
+func AdminMiddleware(authenticator *auth.Authenticator, auditLog *audit.Log, action string, config *util.RouterConfig) mux.MiddlewareFunc {
+	authenticated := AuthMiddleware(authenticator, config, auth.PermissionAdmin)
+
+	return func(next http.Handler) http.Handler {
+		return authenticated(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodOptions {
+				next.ServeHTTP(w, request)
+				return
+			}
+
+			// The body was already buffered for signature verification
+			body, _ := io.ReadAll(request.Body)
+			request.Body = io.NopCloser(bytes.NewReader(body))
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, request)
+
+			apiKey, _ := auth.APIKeyFromContext(request.Context())
+			entry := audit.Entry{
+				AdminUserID: apiKey.UserID,
+				APIKey:      apiKey.Key,
+				Action:      action,
+				Source:      "rest",
+				Path:        request.Method + " " + request.URL.RequestURI(),
+				Status:      recorder.status,
+			}
+			if json.Valid(body) {
+				entry.Payload = body
+			}
+			if _, err := auditLog.Record(entry); err != nil {
+				log.Printf("Failed to write audit entry for %s: %v", action, err)
+			}
+		}))
+	}
+}
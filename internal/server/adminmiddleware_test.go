@@ -0,0 +1,67 @@
+package server
+
+import (
+	"bytes"
+	"mini-crypto-exchange/internal/audit"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signedBodyRequest(apiKey *auth.APIKey, nonce string, method string, target string, body []byte) *http.Request {
+	request := httptest.NewRequest(method, target, bytes.NewReader(body))
+	request.RemoteAddr = "203.0.113.7:1234"
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	request.Header.Set(auth.HeaderAPIKey, apiKey.Key)
+	request.Header.Set(auth.HeaderTimestamp, timestamp)
+	request.Header.Set(auth.HeaderNonce, nonce)
+	request.Header.Set(auth.HeaderSignature, auth.Sign(apiKey.Secret, timestamp, nonce, method, request.URL.RequestURI(), body))
+	return request
+}
+
+func TestAdminMiddlewareRecordsTheAction(t *testing.T) {
+	keys := auth.NewKeyStore()
+	admin, _ := keys.Issue(7, []auth.Permission{auth.PermissionAdmin}, nil)
+	trader, _ := keys.Issue(8, auth.DefaultPermissions, nil)
+	auditLog, _ := audit.NewLog(audit.Config{})
+	status := http.StatusOK
+	handler := AdminMiddleware(auth.NewAuthenticator(keys), auditLog, "set_pair_status", &util.RouterConfig{})(
+		http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+	body := []byte(`{"status":"halted"}`)
+	if got := serve(handler, signedBodyRequest(admin, "1", http.MethodPut, "/api/v1/admin/pairs/BTC-USDT/status?reason=maintenance", body)); got != http.StatusOK {
+		t.Fatalf("admin request: got %d, want 200", got)
+	}
+	status = http.StatusConflict
+	serve(handler, signedBodyRequest(admin, "2", http.MethodPut, "/api/v1/admin/pairs/BTC-USDT/status", []byte("not json")))
+
+	// Rejected callers never reach the action and leave no entry
+	unsigned := httptest.NewRequest(http.MethodPut, "/api/v1/admin/pairs/BTC-USDT/status", bytes.NewReader(body))
+	if got := serve(handler, unsigned); got != http.StatusUnauthorized {
+		t.Fatalf("unsigned request: got %d, want 401", got)
+	}
+	if got := serve(handler, signedBodyRequest(trader, "3", http.MethodPut, "/api/v1/admin/pairs/BTC-USDT/status", body)); got != http.StatusForbidden {
+		t.Fatalf("request without the admin permission: got %d, want 403", got)
+	}
+
+	entries := auditLog.Entries(0)
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2", len(entries))
+	}
+	first := entries[0]
+	if first.AdminUserID != 7 || first.APIKey != admin.Key || first.Action != "set_pair_status" || first.Source != "rest" {
+		t.Fatalf("entry %+v, want the admin, their key, the action and the rest source", first)
+	}
+	if first.Path != "PUT /api/v1/admin/pairs/BTC-USDT/status?reason=maintenance" || string(first.Payload) != string(body) || first.Status != http.StatusOK {
+		t.Fatalf("entry %+v, want the request line, the payload and the 200 outcome", first)
+	}
+	if second := entries[1]; second.Payload != nil || second.Status != http.StatusConflict {
+		t.Fatalf("entry %+v, want no payload for a body that is not JSON and the 409 outcome", second)
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type IssueAPIKeyRequest struct {
+	UserID      int64    `json:"user_id"`
+	Permissions []string `json:"permissions"`
+	AllowedIPs  []string `json:"allowed_ips"`
+}
+
+type IssueAPIKeyResponse struct {
+	Key interface{} `json:"key,omitempty"`
+}
+
+type ListAPIKeysResponse struct {
+	Keys interface{} `json:"keys,omitempty"`
+}
+
+type RevokeAPIKeyResponse struct {
+	Revoked string `json:"revoked,omitempty"`
+}
+
+// IssueAPIKeyHandler handles POST /api/keys
+func IssueAPIKeyHandler(service services.APIKeyService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		var req IssueAPIKeyRequest
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			log.Printf("Failed to decode request: %v", err)
+			writeError(w, apperrors.ErrInvalidRequestBody)
+			return
+		}
+
+		key, err := service.IssueKey(ctx, req.UserID, req.Permissions, req.AllowedIPs, request.Header.Get(auth.HeaderBootstrapSecret))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(IssueAPIKeyResponse{Key: key})
+	}
+}
+
+// ListAPIKeysHandler handles GET /api/keys
+func ListAPIKeysHandler(service services.APIKeyService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		keys, err := service.ListKeys(ctx)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(ListAPIKeysResponse{Keys: keys})
+	}
+}
+
+// RevokeAPIKeyHandler handles DELETE /api/keys/{key}
+func RevokeAPIKeyHandler(service services.APIKeyService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		key := mux.Vars(request)["key"]
+
+		if err := service.RevokeKey(ctx, key); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(RevokeAPIKeyResponse{Revoked: key})
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+)
+
+type AssetResponse struct {
+	Data interface{} `json:"data"`
+}
+
+// ListAssetsHandler handles GET /api/assets
+func ListAssetsHandler(service services.AssetService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		assets, err := service.ListAssets(ctx)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AssetResponse{Data: assets})
+	}
+}
+
+// RegisterAssetHandler handles PUT /api/admin/assets
+func RegisterAssetHandler(service services.AssetService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		var req models.Asset
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			log.Printf("Failed to decode request: %v", err)
+			writeError(w, apperrors.ErrInvalidRequestBody)
+			return
+		}
+
+		asset, err := service.RegisterAsset(ctx, req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AssetResponse{Data: asset})
+	}
+}
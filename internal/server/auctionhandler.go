@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"time"
+)
+
+type StartAuctionRequest struct {
+	Pair            string `json:"pair"`
+	DurationSeconds int64  `json:"duration_seconds"` // 0 runs the auction until the pair's status is changed
+}
+
+type AuctionResponse struct {
+	Data interface{} `json:"data,omitempty"`
+}
+
+// GetAuctionHandler handles GET /api/pairs/auction
+func GetAuctionHandler(service services.AuctionService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		pair := request.URL.Query().Get("pair")
+		if pair == "" {
+			writeFieldError(w, apperrors.ErrMissingParameter, "pair")
+			return
+		}
+
+		data, err := service.GetAuctionState(ctx, pair)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AuctionResponse{Data: data})
+	}
+}
+
+// StartAuctionHandler handles POST /api/admin/pairs/auction
+func StartAuctionHandler(service services.AuctionService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		var req StartAuctionRequest
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			log.Printf("Failed to decode request: %v", err)
+			writeError(w, apperrors.ErrInvalidRequestBody)
+			return
+		}
+
+		data, err := service.StartAuction(ctx, req.Pair, time.Duration(req.DurationSeconds)*time.Second)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AuctionResponse{Data: data})
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"encoding/json"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/audit"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"strconv"
+)
+
+type AuditLogResponse struct {
+	Entries []audit.Entry `json:"entries"`
+}
+
+// AuditLogHandler handles GET /api/admin/audit
+func AuditLogHandler(auditLog *audit.Log, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		limit := 100
+		if limitStr := request.URL.Query().Get("limit"); limitStr != "" {
+			parsed, err := strconv.Atoi(limitStr)
+			if err != nil || parsed <= 0 {
+				writeFieldError(w, apperrors.ErrInvalidParameter, "limit")
+				return
+			}
+			limit = parsed
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(AuditLogResponse{Entries: auditLog.Entries(limit)})
+	}
+}
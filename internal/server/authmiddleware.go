@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// maxSignedBodyBytes bounds request bodies read for signature verification
+const maxSignedBodyBytes = 1 << 20
+
+// AuthMiddleware requires an HMAC signed request from an allowed IP by a key holding all the given
+// permissions, and injects the authenticated key and user into the context
+func AuthMiddleware(authenticator *auth.Authenticator, config *util.RouterConfig, permissions ...auth.Permission) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodOptions {
+				next.ServeHTTP(w, request)
+				return
+			}
+
+			authenticated, err := authenticateRequest(authenticator, request, permissions...)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			next.ServeHTTP(w, authenticated)
+		})
+	}
+}
+
+// OptionalAuthMiddleware authenticates the request only when credentials are present
+func OptionalAuthMiddleware(authenticator *auth.Authenticator, config *util.RouterConfig) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+			if request.Header.Get(auth.HeaderAPIKey) == "" {
+				next.ServeHTTP(w, request)
+				return
+			}
+
+			authenticated, err := authenticateRequest(authenticator, request)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			next.ServeHTTP(w, authenticated)
+		})
+	}
+}
+
+// authenticateRequest verifies the request signature, IP allowlist and permissions and returns
+// the request with the key in its context
+func authenticateRequest(authenticator *auth.Authenticator, request *http.Request, permissions ...auth.Permission) (*http.Request, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, request.Body, maxSignedBodyBytes))
+	if err != nil {
+		return nil, apperrors.ErrInvalidSignature
+	}
+	// Restore the body for the handler
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	apiKey, err := authenticator.Verify(
+		request.Header.Get(auth.HeaderAPIKey),
+		request.Header.Get(auth.HeaderTimestamp),
+		request.Header.Get(auth.HeaderNonce),
+		request.Header.Get(auth.HeaderSignature),
+		request.Method,
+		request.URL.RequestURI(),
+		body,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := apiKey.Authorize(auth.RemoteIP(request.RemoteAddr), permissions...); err != nil {
+		return nil, err
+	}
+
+	return request.WithContext(auth.WithAPIKey(request.Context(), apiKey)), nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+)
+
+type BatchOrderRequest struct {
+	Mode   string              `json:"mode"` // atomic (default) or best_effort
+	Orders []PlaceOrderRequest `json:"orders"`
+}
+
+type BatchOrderResponse struct {
+	Results interface{} `json:"results,omitempty"`
+	Error   *ErrorBody  `json:"error,omitempty"`
+}
+
+// BatchOrderWeight charges one token per order in the batch
+func BatchOrderWeight(request *http.Request) int {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, request.Body, maxSignedBodyBytes))
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 1
+	}
+
+	var req struct {
+		Orders []json.RawMessage `json:"orders"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || len(req.Orders) == 0 {
+		return 1
+	}
+	return len(req.Orders)
+}
+
+// BatchOrderHandler handles POST /api/orders/batch
+func BatchOrderHandler(service services.BatchOrderService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		userID, _ := auth.UserFromContext(ctx)
+
+		var req BatchOrderRequest
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			log.Printf("Failed to decode request: %v", err)
+			writeError(w, apperrors.ErrInvalidRequestBody)
+			return
+		}
+
+		entries := make([]services.BatchOrderEntry, len(req.Orders))
+		for i, order := range req.Orders {
+			entries[i] = services.BatchOrderEntry{Pair: order.Pair, Side: order.Side, Price: order.Price, Quantity: order.Quantity, ClientOrderID: order.ClientOrderID}
+		}
+
+		results, err := service.PlaceOrders(ctx, userID, req.Mode, entries)
+		for _, result := range results {
+			if result.Order != nil && len(result.Errors) == 0 {
+				trackSessionOrders(ctx, config, result.Order)
+			}
+		}
+		if err != nil {
+			writeBatchOrderError(w, results, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(BatchOrderResponse{Results: results})
+	}
+}
+
+// writeBatchOrderError answers with the error envelope and, for a rejected batch, the errors per order
+func writeBatchOrderError(w http.ResponseWriter, results []*services.BatchOrderResult, err error) {
+	body, status := newErrorBody(err, "")
+	response := BatchOrderResponse{Error: body}
+	if results != nil {
+		response.Results = results
+	}
+	writeErrorResponse(w, status, err, response)
+}
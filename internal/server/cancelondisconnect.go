@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"log"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+	"time"
+)
+
+// maxCancelOnDisconnectGrace bounds how long a session's orders may outlive its last stream
+const maxCancelOnDisconnectGrace = 5 * time.Minute
+
+// cancelOnDisconnect tracks cancel-on-disconnect sessions, one per API key. While a key has an
+// open stream, the orders placed with that key over REST or gRPC belong to its session. Once the
+// key's last stream closes and no new one opens within the grace period, those orders are
+// cancelled; orders the user placed with other keys or gateways are left alone.
+type cancelOnDisconnect struct {
+	engine *engine.MatchingEngine
+
+	mu       sync.Mutex
+	sessions map[string]*streamSession
+}
+
+// streamSession is the state of one API key's cancel-on-disconnect session
+type streamSession struct {
+	userID   int64
+	streams  int
+	timer    *time.Timer
+	orderIDs []int64
+	// pruneAt is the number of tracked orders at which orders no longer open are dropped
+	pruneAt int
+}
+
+// minSessionPrune is the smallest number of tracked orders a session prunes at
+const minSessionPrune = 64
+
+func newCancelOnDisconnect(matchingEngine *engine.MatchingEngine) *cancelOnDisconnect {
+	return &cancelOnDisconnect{
+		engine:   matchingEngine,
+		sessions: make(map[string]*streamSession),
+	}
+}
+
+// opened registers a stream of apiKey, stopping a pending cancellation of its session
+func (c *cancelOnDisconnect) opened(apiKey *auth.APIKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	session, exists := c.sessions[apiKey.Key]
+	if !exists {
+		session = &streamSession{userID: apiKey.UserID, pruneAt: minSessionPrune}
+		c.sessions[apiKey.Key] = session
+	}
+	session.streams++
+	if session.timer != nil {
+		session.timer.Stop()
+		session.timer = nil
+		log.Printf("User %d reconnected, session orders kept", apiKey.UserID)
+	}
+}
+
+// closed unregisters a stream of apiKey and schedules the cancellation when it was the last
+func (c *cancelOnDisconnect) closed(apiKey *auth.APIKey, grace time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	session := c.sessions[apiKey.Key]
+	session.streams--
+	if session.streams > 0 {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(grace, func() {
+		c.mu.Lock()
+		if session.timer != timer {
+			c.mu.Unlock()
+			return
+		}
+		delete(c.sessions, apiKey.Key)
+		c.mu.Unlock()
+
+		cancelled := c.engine.CancelOrders(session.userID, session.orderIDs)
+		log.Printf("Cancelled %d open orders of user %d on stream disconnect", len(cancelled), session.userID)
+	})
+	session.timer = timer
+}
+
+// placed adds orders placed with the key in ctx to its session, if it has one
+func (c *cancelOnDisconnect) placed(ctx context.Context, orders ...*models.Order) {
+	apiKey, authenticated := auth.APIKeyFromContext(ctx)
+	if !authenticated {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	session, exists := c.sessions[apiKey.Key]
+	if !exists {
+		return
+	}
+	for _, order := range orders {
+		if order != nil {
+			session.orderIDs = append(session.orderIDs, order.ID)
+		}
+	}
+
+	// Long sessions drop their filled and cancelled orders whenever the list doubles
+	if len(session.orderIDs) >= session.pruneAt {
+		open := session.orderIDs[:0]
+		for _, orderID := range session.orderIDs {
+			if order, exists := c.engine.GetOrder(orderID); exists && order.Remaining() > 0 && order.Status != "cancelled" {
+				open = append(open, orderID)
+			}
+		}
+		session.orderIDs = open
+		session.pruneAt = max(minSessionPrune, 2*len(open))
+	}
+}
+
+// trackSessionOrders adds orders placed with the request's key to its cancel-on-disconnect session
+func trackSessionOrders(ctx context.Context, config *util.RouterConfig, orders ...*models.Order) {
+	if config == nil {
+		return
+	}
+	if sessions, ok := config.StreamSessions.(*cancelOnDisconnect); ok {
+		sessions.placed(ctx, orders...)
+	}
+}
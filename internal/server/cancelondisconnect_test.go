@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"testing"
+	"time"
+)
+
+func TestCancelOnDisconnectCancelsOnlySessionOrders(t *testing.T) {
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	keys := auth.NewKeyStore()
+	sessionKey, _ := keys.Issue(1, auth.DefaultPermissions, nil)
+	otherKey, _ := keys.Issue(1, auth.DefaultPermissions, nil)
+	sessions := newCancelOnDisconnect(matchingEngine)
+
+	place := func(apiKey *auth.APIKey) *models.Order {
+		order, _, err := matchingEngine.PlaceOrder(1, "BTC/USDT", "buy", 100, 1)
+		if err != nil {
+			t.Fatalf("PlaceOrder: %v", err)
+		}
+		sessions.placed(auth.WithAPIKey(context.Background(), apiKey), order)
+		return order
+	}
+
+	before := place(sessionKey)
+	sessions.opened(sessionKey)
+	inSession := place(sessionKey)
+	otherKeyOrder := place(otherKey)
+	sessions.closed(sessionKey, 0)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if order, _ := matchingEngine.GetOrder(inSession.ID); order.Status == "cancelled" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the session's order was not cancelled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for _, order := range []*models.Order{before, otherKeyOrder} {
+		if snapshot, _ := matchingEngine.GetOrder(order.ID); snapshot.Status != "open" {
+			t.Errorf("order %d outside the session is %s, want open", order.ID, snapshot.Status)
+		}
+	}
+}
+
+func TestCancelOnDisconnectKeepsOrdersOnReconnect(t *testing.T) {
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	apiKey, _ := auth.NewKeyStore().Issue(1, auth.DefaultPermissions, nil)
+	sessions := newCancelOnDisconnect(matchingEngine)
+
+	sessions.opened(apiKey)
+	order, _, _ := matchingEngine.PlaceOrder(1, "BTC/USDT", "buy", 100, 1)
+	sessions.placed(auth.WithAPIKey(context.Background(), apiKey), order)
+	sessions.closed(apiKey, 50*time.Millisecond)
+	sessions.opened(apiKey)
+
+	time.Sleep(100 * time.Millisecond)
+	if snapshot, _ := matchingEngine.GetOrder(order.ID); snapshot.Status != "open" {
+		t.Fatalf("order is %s after reconnecting within the grace period, want open", snapshot.Status)
+	}
+}
+
+func TestCancelOnDisconnectCancelsOrdersInHaltedPairs(t *testing.T) {
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	apiKey, _ := auth.NewKeyStore().Issue(1, auth.DefaultPermissions, nil)
+	sessions := newCancelOnDisconnect(matchingEngine)
+
+	sessions.opened(apiKey)
+	order, _, _ := matchingEngine.PlaceOrder(1, "BTC/USDT", "buy", 100, 1)
+	sessions.placed(auth.WithAPIKey(context.Background(), apiKey), order)
+	if _, err := matchingEngine.SetPairStatus("BTC/USDT", models.PairStatusHalted); err != nil {
+		t.Fatalf("SetPairStatus: %v", err)
+	}
+	sessions.closed(apiKey, 0)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if snapshot, _ := matchingEngine.GetOrder(order.ID); snapshot.Status == "cancelled" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the session's order in a halted pair was not cancelled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
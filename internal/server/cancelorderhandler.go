@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type CancelAllAfterRequest struct {
+	TimeoutMs int64 `json:"timeout_ms"` // 0 disarms the switch
+}
+
+type CancelAllOrdersResult struct {
+	CancelledOrderIDs []int64 `json:"cancelled_order_ids"`
+}
+
+type CancelOrderResponse struct {
+	Data interface{} `json:"data,omitempty"`
+}
+
+// CancelAllOrdersHandler handles DELETE /api/orders?pair=X&side=Y
+func CancelAllOrdersHandler(service services.CancelOrderService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		userID, _ := auth.UserFromContext(ctx)
+
+		pair := request.URL.Query().Get("pair")
+		side := request.URL.Query().Get("side")
+
+		cancelled, err := service.CancelAllOrders(ctx, userID, pair, side)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		result := CancelAllOrdersResult{CancelledOrderIDs: make([]int64, 0, len(cancelled))}
+		for _, order := range cancelled {
+			result.CancelledOrderIDs = append(result.CancelledOrderIDs, order.ID)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(CancelOrderResponse{Data: result})
+	}
+}
+
+// CancelOrderByClientIDHandler handles DELETE /api/orders/client/{client_order_id}
+func CancelOrderByClientIDHandler(service services.CancelOrderService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		userID, _ := auth.UserFromContext(ctx)
+
+		order, err := service.CancelOrderByClientID(ctx, userID, mux.Vars(request)["client_order_id"])
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(CancelOrderResponse{Data: order})
+	}
+}
+
+// CancelAllAfterHandler handles POST /api/orders/cancel-all-after
+func CancelAllAfterHandler(service services.CancelOrderService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		userID, _ := auth.UserFromContext(ctx)
+
+		var req CancelAllAfterRequest
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			log.Printf("Failed to decode request: %v", err)
+			writeError(w, apperrors.ErrInvalidRequestBody)
+			return
+		}
+
+		// Check the range before converting, a huge timeout_ms would overflow the duration
+		if req.TimeoutMs < 0 || req.TimeoutMs > services.MaxCancelAllAfter.Milliseconds() {
+			writeFieldError(w, apperrors.ErrInvalidCancelTimeout, "timeout_ms")
+			return
+		}
+
+		data, err := service.CancelAllAfter(ctx, userID, time.Duration(req.TimeoutMs)*time.Millisecond)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(CancelOrderResponse{Data: data})
+	}
+}
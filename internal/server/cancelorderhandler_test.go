@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCancelOrderService records the filters and timeouts it is called with
+type testCancelOrderService struct {
+	filters  [][2]string
+	timeouts []time.Duration
+}
+
+func (s *testCancelOrderService) CancelAllOrders(ctx context.Context, userID int64, pair string, side string) ([]*models.Order, error) {
+	s.filters = append(s.filters, [2]string{pair, side})
+	return nil, nil
+}
+
+func (s *testCancelOrderService) CancelAllAfter(ctx context.Context, userID int64, timeout time.Duration) (*models.DeadMansSwitch, error) {
+	s.timeouts = append(s.timeouts, timeout)
+	return &models.DeadMansSwitch{TimeoutMs: timeout.Milliseconds()}, nil
+}
+
+func (s *testCancelOrderService) CancelOrderByClientID(ctx context.Context, userID int64, clientOrderID string) (*models.Order, error) {
+	return nil, nil
+}
+
+func TestCancelAllAfterRejectsTimeoutsOutOfRange(t *testing.T) {
+	service := &testCancelOrderService{}
+	handler := CancelAllAfterHandler(service, &util.RouterConfig{})
+
+	// 9223372036854775807ms wraps to a negative duration, 9223372036854776ms to a small positive one
+	for _, timeoutMs := range []string{"-1", "600001", "9223372036854775807", "9223372036854776"} {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/orders/cancel-all-after", strings.NewReader(`{"timeout_ms":`+timeoutMs+`}`))
+		if status := serve(handler, request); status != http.StatusBadRequest {
+			t.Errorf("timeout_ms %s: got %d, want 400", timeoutMs, status)
+		}
+	}
+	if len(service.timeouts) != 0 {
+		t.Fatalf("switch armed with %v", service.timeouts)
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/orders/cancel-all-after", strings.NewReader(`{"timeout_ms":600000}`))
+	if status := serve(handler, request); status != http.StatusOK {
+		t.Fatalf("maximum timeout: got %d, want 200", status)
+	}
+}
+
+func TestCancelAllOrdersPassesTheFilters(t *testing.T) {
+	service := &testCancelOrderService{}
+	handler := CancelAllOrdersHandler(service, &util.RouterConfig{})
+
+	for _, target := range []string{"/api/v1/orders", "/api/v1/orders?pair=BTC/USDT", "/api/v1/orders?side=sell", "/api/v1/orders?pair=eth/usdt&side=buy"} {
+		if status := serve(handler, httptest.NewRequest(http.MethodDelete, target, nil)); status != http.StatusOK {
+			t.Fatalf("%s: got %d, want 200", target, status)
+		}
+	}
+	want := [][2]string{{"", ""}, {"BTC/USDT", ""}, {"", "sell"}, {"eth/usdt", "buy"}}
+	for i := range want {
+		if service.filters[i] != want[i] {
+			t.Errorf("request %d: got pair and side %q, want %q", i, service.filters[i], want[i])
+		}
+	}
+}
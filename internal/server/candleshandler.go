@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CandlesResponse struct {
+	Candles []models.Candle `json:"candles,omitempty"`
+}
+
+// CandlesHandler handles GET /api/candles?pair=X&interval=Y&start=Z&end=W
+func CandlesHandler(service services.CandleService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		query := request.URL.Query()
+
+		pair := query.Get("pair")
+		if pair == "" {
+			writeFieldError(w, apperrors.ErrMissingParameter, "pair")
+			return
+		}
+
+		interval := query.Get("interval")
+		if interval == "" {
+			interval = "1m"
+		}
+
+		start, startErr := parseTimeParam(query.Get("start"))
+		end, endErr := parseTimeParam(query.Get("end"))
+		if startErr != nil || endErr != nil {
+			writeError(w, apperrors.ErrInvalidTimeRange)
+			return
+		}
+
+		candles, err := service.GetCandles(ctx, pair, interval, start, end)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(CandlesResponse{Candles: candles})
+	}
+}
+
+// parseTimeParam parses a query time given as unix seconds or RFC3339; empty yields the zero time
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+)
+
+// ErrorBody is the error envelope of every failed REST response. Field names the request field the
+// error is about, and Details lists the individual errors behind a VALIDATION_FAILED error.
+type ErrorBody struct {
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Field   string        `json:"field,omitempty"`
+	Details []*util.Error `json:"details,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error *ErrorBody `json:"error,omitempty"`
+}
+
+// newErrorBody converts err to an envelope and the HTTP status to answer with. Errors that are not
+// ServerErrors are reported as internal errors without exposing their text.
+func newErrorBody(err error, field string) (*ErrorBody, int) {
+	var serverErr *apperrors.ServerError
+	if !errors.As(err, &serverErr) {
+		serverErr = apperrors.ErrInternal
+	}
+
+	status := serverErr.HTTPResponseCode
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	return &ErrorBody{Code: serverErr.Code, Message: serverErr.Message, Field: field}, status
+}
+
+// writeError answers the request with the envelope for err
+func writeError(w http.ResponseWriter, err error) {
+	writeFieldError(w, err, "")
+}
+
+// writeFieldError answers the request with the envelope for err about the given request field
+func writeFieldError(w http.ResponseWriter, err error, field string) {
+	body, status := newErrorBody(err, field)
+	writeErrorResponse(w, status, err, ErrorResponse{Error: body})
+}
+
+// writeValidationErrors answers the request with a VALIDATION_FAILED envelope listing details
+func writeValidationErrors(w http.ResponseWriter, details []*util.Error) {
+	body, status := newErrorBody(apperrors.ErrValidationFailed, "")
+	body.Details = details
+	writeErrorResponse(w, status, apperrors.ErrValidationFailed, ErrorResponse{Error: body})
+}
+
+// writeErrorResponse logs err and writes response, which carries the envelope, with the status
+func writeErrorResponse(w http.ResponseWriter, status int, err error, response interface{}) {
+	log.Printf("Request failed with %d: %v", status, err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
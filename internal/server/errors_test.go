@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// decodeEnvelope returns the status and the error object of a response, failing unless the body is
+// exactly an error envelope
+func decodeEnvelope(t *testing.T, recorder *httptest.ResponseRecorder) (int, map[string]interface{}) {
+	t.Helper()
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("Content-Type %q, want application/json", contentType)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %q is not JSON: %v", recorder.Body.String(), err)
+	}
+	envelope, ok := body["error"].(map[string]interface{})
+	if !ok || len(body) != 1 {
+		t.Fatalf("body %s, want only an error envelope", recorder.Body.String())
+	}
+	return recorder.Code, envelope
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name       string
+		write      func(w http.ResponseWriter)
+		wantStatus int
+		want       map[string]interface{}
+	}{
+		{
+			"server error",
+			func(w http.ResponseWriter) { writeError(w, apperrors.ErrPairNotFound) },
+			http.StatusNotFound,
+			map[string]interface{}{"code": "PAIR_NOT_FOUND", "message": "Trading pair not found"},
+		},
+		{
+			"wrapped server error",
+			func(w http.ResponseWriter) { writeError(w, fmt.Errorf("loading book: %w", apperrors.ErrPairNotFound)) },
+			http.StatusNotFound,
+			map[string]interface{}{"code": "PAIR_NOT_FOUND", "message": "Trading pair not found"},
+		},
+		{
+			"field error",
+			func(w http.ResponseWriter) { writeFieldError(w, apperrors.ErrMissingParameter, "pair") },
+			http.StatusBadRequest,
+			map[string]interface{}{"code": "MISSING_PARAMETER", "message": apperrors.ErrMissingParameter.Message, "field": "pair"},
+		},
+		{
+			"validation errors",
+			func(w http.ResponseWriter) {
+				writeValidationErrors(w, []*util.Error{
+					util.ServerToFieldError(apperrors.ErrInvalidPrice, "price"),
+					util.ServerToFieldError(apperrors.ErrInvalidSide, "side"),
+				})
+			},
+			http.StatusBadRequest,
+			map[string]interface{}{
+				"code":    "VALIDATION_FAILED",
+				"message": apperrors.ErrValidationFailed.Message,
+				"details": []interface{}{
+					map[string]interface{}{"code": "INVALID_PRICE", "message": apperrors.ErrInvalidPrice.Message, "field": "price"},
+					map[string]interface{}{"code": "INVALID_SIDE", "message": apperrors.ErrInvalidSide.Message, "field": "side"},
+				},
+			},
+		},
+		{
+			"other errors stay hidden",
+			func(w http.ResponseWriter) { writeError(w, errors.New("connection refused by 10.0.0.5")) },
+			http.StatusInternalServerError,
+			map[string]interface{}{"code": "INTERNAL_ERROR", "message": "Internal server error"},
+		},
+		{
+			"server error without a status",
+			func(w http.ResponseWriter) { writeError(w, &apperrors.ServerError{Code: "UNSET", Message: "No status"}) },
+			http.StatusInternalServerError,
+			map[string]interface{}{"code": "UNSET", "message": "No status"},
+		},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		tt.write(recorder)
+		status, envelope := decodeEnvelope(t, recorder)
+		if status != tt.wantStatus || !reflect.DeepEqual(envelope, tt.want) {
+			t.Errorf("%s: got %d %v, want %d %v", tt.name, status, envelope, tt.wantStatus, tt.want)
+		}
+	}
+}
+
+// testOrderBookLookup knows the order book of BTC/USDT only
+type testOrderBookLookup struct {
+	services.OrderBookService
+}
+
+func (s *testOrderBookLookup) GetOrderBook(ctx context.Context, pair string, depth int) (map[string]interface{}, error) {
+	if assets.NormalizePair(pair) != "BTC/USDT" {
+		return nil, apperrors.ErrPairNotFound
+	}
+	return map[string]interface{}{"pair": "BTC/USDT"}, nil
+}
+
+func TestOrderBookHandlerErrors(t *testing.T) {
+	handler := OrderBookHandler(&testOrderBookLookup{}, &util.RouterConfig{})
+
+	tests := []struct {
+		target     string
+		wantStatus int
+		wantCode   string
+		wantField  interface{}
+	}{
+		{"/api/v1/orderbook", http.StatusBadRequest, "MISSING_PARAMETER", "pair"},
+		{"/api/v1/orderbook?pair=DOGE/USDT", http.StatusNotFound, "PAIR_NOT_FOUND", nil},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		status, envelope := decodeEnvelope(t, recorder)
+		if status != tt.wantStatus || envelope["code"] != tt.wantCode || envelope["field"] != tt.wantField {
+			t.Errorf("%s: got %d %v, want %d %s about %v", tt.target, status, envelope, tt.wantStatus, tt.wantCode, tt.wantField)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/orderbook?pair=btc/usdt", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("known pair: got %d, want 200", recorder.Code)
+	}
+}
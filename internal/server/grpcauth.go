@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/audit"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/pb"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcMethodPermissions lists the RPCs that require a signed request and the permission each needs
+var grpcMethodPermissions = map[string]auth.Permission{
+	pb.OrderService_PlaceOrder_FullMethodName: auth.PermissionTrade,
+	pb.OrderService_GetOrders_FullMethodName:  auth.PermissionRead,
+	pb.PairService_CreatePair_FullMethodName:  auth.PermissionAdmin,
+}
+
+// grpcAdminActions names the audit log action of each admin RPC
+var grpcAdminActions = map[string]string{
+	pb.PairService_CreatePair_FullMethodName: "create_pair",
+}
+
+// authUnaryInterceptor verifies signed calls, injects the authenticated user into the context
+// and records admin calls in the audit log
+func authUnaryInterceptor(authenticator *auth.Authenticator, auditLog *audit.Log) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		permission, required := grpcMethodPermissions[info.FullMethod]
+		if !required {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		get := func(header string) string {
+			if values := md.Get(strings.ToLower(header)); len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		}
+
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req.(proto.Message))
+		if err != nil {
+			return nil, toGRPCError(apperrors.ErrInvalidSignature)
+		}
+
+		apiKey, err := authenticator.Verify(
+			get(auth.HeaderAPIKey),
+			get(auth.HeaderTimestamp),
+			get(auth.HeaderNonce),
+			get(auth.HeaderSignature),
+			http.MethodPost,
+			info.FullMethod,
+			body,
+		)
+		if err == nil {
+			var ip net.IP
+			if p, ok := peer.FromContext(ctx); ok {
+				ip = auth.RemoteIP(p.Addr.String())
+			}
+			err = apiKey.Authorize(ip, permission)
+		}
+		if err != nil {
+			log.Printf("Authentication failed: %v", err)
+			return nil, toGRPCError(err)
+		}
+
+		resp, err := handler(auth.WithAPIKey(ctx, apiKey), req)
+
+		if action, isAdmin := grpcAdminActions[info.FullMethod]; isAdmin {
+			entry := audit.Entry{
+				AdminUserID: apiKey.UserID,
+				APIKey:      apiKey.Key,
+				Action:      action,
+				Source:      "grpc",
+				Path:        info.FullMethod,
+				Status:      http.StatusOK,
+			}
+			if payload, marshalErr := protojson.Marshal(req.(proto.Message)); marshalErr == nil {
+				entry.Payload = payload
+			}
+			if err != nil {
+				entry.Status = grpcToHTTPCode(status.Code(err))
+			}
+			if _, auditErr := auditLog.Record(entry); auditErr != nil {
+				log.Printf("Failed to write audit entry for %s: %v", action, auditErr)
+			}
+		}
+
+		return resp, err
+	}
+}
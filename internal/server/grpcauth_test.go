@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/audit"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/pb"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// signedGRPCContext returns an incoming context carrying the signature of req by apiKey
+func signedGRPCContext(t *testing.T, apiKey *auth.APIKey, nonce string, method string, req proto.Message) context.Context {
+	t.Helper()
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		auth.HeaderAPIKey, apiKey.Key,
+		auth.HeaderTimestamp, timestamp,
+		auth.HeaderNonce, nonce,
+		auth.HeaderSignature, auth.Sign(apiKey.Secret, timestamp, nonce, http.MethodPost, method, body),
+	))
+}
+
+func TestGRPCAdminCallsAreAudited(t *testing.T) {
+	keys := auth.NewKeyStore()
+	admin, _ := keys.Issue(7, []auth.Permission{auth.PermissionAdmin}, nil)
+	trader, _ := keys.Issue(8, auth.DefaultPermissions, nil)
+	auditLog, _ := audit.NewLog(audit.Config{})
+	interceptor := authUnaryInterceptor(auth.NewAuthenticator(keys), auditLog)
+	info := &grpc.UnaryServerInfo{FullMethod: pb.PairService_CreatePair_FullMethodName}
+	var handlerErr error
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &pb.CreatePairResponse{}, handlerErr
+	}
+
+	req := &pb.CreatePairRequest{Base: "BTC", Quote: "USDT", MatchingMode: "batch"}
+	if _, err := interceptor(signedGRPCContext(t, admin, "1", info.FullMethod, req), req, info, handler); err != nil {
+		t.Fatalf("admin call: %v", err)
+	}
+	handlerErr = toGRPCError(apperrors.ErrPairNotFound)
+	interceptor(signedGRPCContext(t, admin, "2", info.FullMethod, req), req, info, handler)
+
+	_, err := interceptor(signedGRPCContext(t, trader, "3", info.FullMethod, req), req, info, handler)
+	expectCode(t, "call without the admin permission", err, codes.PermissionDenied)
+
+	// Calls that are not admin actions are not audited
+	order := &pb.PlaceOrderRequest{Pair: "BTC/USDT", Side: "buy", Price: 100, Quantity: 1}
+	orderInfo := &grpc.UnaryServerInfo{FullMethod: pb.OrderService_PlaceOrder_FullMethodName}
+	handlerErr = nil
+	if _, err := interceptor(signedGRPCContext(t, trader, "4", orderInfo.FullMethod, order), order, orderInfo, handler); err != nil {
+		t.Fatalf("order call: %v", err)
+	}
+
+	entries := auditLog.Entries(0)
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2", len(entries))
+	}
+	first := entries[0]
+	if first.AdminUserID != 7 || first.APIKey != admin.Key || first.Action != "create_pair" || first.Source != "grpc" || first.Path != info.FullMethod || first.Status != http.StatusOK {
+		t.Fatalf("entry %+v, want the admin's create_pair call over grpc with a 200 outcome", first)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(first.Payload, &payload); err != nil || payload["base"] != "BTC" || payload["matchingMode"] != "batch" {
+		t.Fatalf("payload %s, want the request as JSON", first.Payload)
+	}
+	if entries[1].Status != http.StatusNotFound {
+		t.Fatalf("entry %+v, want the failed call recorded with its REST status", entries[1])
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// toGRPCError converts an error into a gRPC status error.
+// ServerErrors use their GRPCResponseCode, falling back to their HTTP status when it is unset.
+// Other errors are logged and reported as internal errors without exposing their text.
+func toGRPCError(err error) error {
+	var serverErr *apperrors.ServerError
+	if !errors.As(err, &serverErr) {
+		log.Printf("gRPC request failed: %v", err)
+		serverErr = apperrors.ErrInternal
+	}
+
+	code := codes.Code(serverErr.GRPCResponseCode)
+	if code == codes.OK {
+		code = httpToGRPCCode(serverErr.HTTPResponseCode)
+	}
+	return status.Error(code, serverErr.Error())
+}
+
+// toGRPCErrorWithDetails converts an error like toGRPCError and attaches details to the status,
+// e.g. the original order of a duplicate client order ID. The details are dropped if they cannot
+// be attached.
+func toGRPCErrorWithDetails(err error, details ...protoadapt.MessageV1) error {
+	grpcErr := toGRPCError(err)
+	withDetails, detailsErr := status.Convert(grpcErr).WithDetails(details...)
+	if detailsErr != nil {
+		log.Printf("Failed to attach gRPC error details: %v", detailsErr)
+		return grpcErr
+	}
+	return withDetails.Err()
+}
+
+func httpToGRPCCode(httpCode int) codes.Code {
+	switch httpCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+func grpcToHTTPCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"mini-crypto-exchange/internal/apperrors"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToGRPCError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{"invalid argument", apperrors.ErrInvalidPrice, codes.InvalidArgument, apperrors.ErrInvalidPrice.Error()},
+		{"not found", apperrors.ErrPairNotFound, codes.NotFound, apperrors.ErrPairNotFound.Error()},
+		{"already exists", apperrors.ErrDuplicateClientOrderID, codes.AlreadyExists, apperrors.ErrDuplicateClientOrderID.Error()},
+		{"failed precondition", apperrors.ErrAssetDecimalsLocked, codes.FailedPrecondition, apperrors.ErrAssetDecimalsLocked.Error()},
+		{"permission denied", apperrors.ErrAllowlistEscalation, codes.PermissionDenied, apperrors.ErrAllowlistEscalation.Error()},
+		{"wrapped", fmt.Errorf("placing order: %w", apperrors.ErrPairNotFound), codes.NotFound, apperrors.ErrPairNotFound.Error()},
+		{"HTTP status fallback", &apperrors.ServerError{Code: "SLOW_DOWN", Message: "Slow down", HTTPResponseCode: http.StatusTooManyRequests}, codes.ResourceExhausted, "SLOW_DOWN: Slow down"},
+		{"unmapped HTTP status", &apperrors.ServerError{Code: "TEAPOT", Message: "Teapot", HTTPResponseCode: http.StatusTeapot}, codes.Internal, "TEAPOT: Teapot"},
+		{"other errors stay hidden", errors.New("database password rejected"), codes.Internal, apperrors.ErrInternal.Error()},
+	}
+	for _, tt := range tests {
+		st, ok := status.FromError(toGRPCError(tt.err))
+		if !ok {
+			t.Fatalf("%s: not a gRPC status", tt.name)
+		}
+		if st.Code() != tt.wantCode || st.Message() != tt.wantMessage {
+			t.Errorf("%s: got %v %q, want %v %q", tt.name, st.Code(), st.Message(), tt.wantCode, tt.wantMessage)
+		}
+	}
+}
+
+func TestGRPCCodesMapToTheRESTStatus(t *testing.T) {
+	for _, serverErr := range []*apperrors.ServerError{
+		apperrors.ErrInvalidPrice,
+		apperrors.ErrPairNotFound,
+		apperrors.ErrDuplicateClientOrderID,
+		apperrors.ErrAssetDecimalsLocked,
+		apperrors.ErrAllowlistEscalation,
+		apperrors.ErrInternal,
+	} {
+		code := status.Code(toGRPCError(serverErr))
+		if got := grpcToHTTPCode(code); got != serverErr.HTTPResponseCode {
+			t.Errorf("%s: gRPC %v maps back to HTTP %d, want %d", serverErr.Code, code, got, serverErr.HTTPResponseCode)
+		}
+	}
+
+	for httpCode, want := range map[int]codes.Code{
+		http.StatusBadRequest:          codes.InvalidArgument,
+		http.StatusUnauthorized:        codes.Unauthenticated,
+		http.StatusForbidden:           codes.PermissionDenied,
+		http.StatusNotFound:            codes.NotFound,
+		http.StatusConflict:            codes.AlreadyExists,
+		http.StatusTooManyRequests:     codes.ResourceExhausted,
+		http.StatusServiceUnavailable:  codes.Unavailable,
+		http.StatusInternalServerError: codes.Internal,
+	} {
+		if got := httpToGRPCCode(httpCode); got != want {
+			t.Errorf("HTTP %d: got %v, want %v", httpCode, got, want)
+		}
+		if back := grpcToHTTPCode(want); back != httpCode {
+			t.Errorf("gRPC %v: got HTTP %d, want %d", want, back, httpCode)
+		}
+	}
+}
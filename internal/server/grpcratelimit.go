@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/pb"
+	"mini-crypto-exchange/internal/ratelimit"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// grpcOrderMethods lists the RPCs drawing from the order bucket; other calls draw from the query bucket
+var grpcOrderMethods = map[string]bool{
+	pb.OrderService_PlaceOrder_FullMethodName: true,
+}
+
+// grpcMethodWeights lists the RPCs costing more than one token, matching their REST routes
+var grpcMethodWeights = map[string]int{
+	pb.OrderService_GetOrders_FullMethodName: 5,
+}
+
+func grpcLimiter(limiters *ratelimit.Limiters, method string) *ratelimit.Limiter {
+	if grpcOrderMethods[method] {
+		return limiters.Orders
+	}
+	return limiters.Queries
+}
+
+func grpcWeight(method string) int {
+	if weight, ok := grpcMethodWeights[method]; ok {
+		return weight
+	}
+	return 1
+}
+
+// ipRateLimitUnaryInterceptor charges a call to the peer IP's bucket before it is authenticated,
+// like RateLimitMiddleware does for REST
+func ipRateLimitUnaryInterceptor(limiters *ratelimit.Limiters) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := takeGRPCIP(ctx, grpcLimiter(limiters, info.FullMethod), info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// userRateLimitUnaryInterceptor charges an authenticated call to the user's bucket, returning the
+// IP's tokens when the user's bucket rejects it
+func userRateLimitUnaryInterceptor(limiters *ratelimit.Limiters) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		userID, ok := auth.UserFromContext(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		limiter := grpcLimiter(limiters, info.FullMethod)
+		charge := ipRateLimitFromContext(ctx)
+		if taken := limiter.Take(ratelimit.UserKey(userID), charge.cost); !taken.Allowed {
+			limiter.Refund(charge.key, charge.cost)
+			return nil, grpcRateLimited(ctx, info.FullMethod, taken)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ipRateLimitStreamInterceptor charges opening a stream to the peer IP's query bucket
+func ipRateLimitStreamInterceptor(limiters *ratelimit.Limiters) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if _, err := takeGRPCIP(stream.Context(), limiters.Queries, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func takeGRPCIP(ctx context.Context, limiter *ratelimit.Limiter, method string) (context.Context, error) {
+	var key string
+	if p, ok := peer.FromContext(ctx); ok {
+		key = ratelimit.IPKey(auth.RemoteIP(p.Addr.String()))
+	}
+	cost := grpcWeight(method)
+
+	taken := limiter.Take(key, cost)
+	if !taken.Allowed {
+		return ctx, grpcRateLimited(ctx, method, taken)
+	}
+	return withIPRateLimit(ctx, ipRateLimit{Result: taken, key: key, cost: cost}), nil
+}
+
+// grpcRateLimited reports the retry delay in the retry-after header metadata
+func grpcRateLimited(ctx context.Context, method string, result ratelimit.Result) error {
+	retryAfter := ceilSeconds(result.RetryAfter)
+	log.Printf("Rate limited %s, retry after %ds", method, retryAfter)
+	grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+	return toGRPCError(apperrors.ErrRateLimited)
+}
@@ -0,0 +1,308 @@
+package server
+
+import (
+	"context"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/audit"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/marketdata"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/pb"
+	"mini-crypto-exchange/internal/ratelimit"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultGRPCBookDepth  = 10
+	defaultGRPCTradeLimit = 100
+)
+
+// NewGRPCServer creates the gRPC server mirroring the REST API
+func NewGRPCServer(routerConfig *util.RouterConfig) *grpc.Server {
+	matchingEngine := routerConfig.MatchingEngine.(*engine.MatchingEngine)
+	hub := routerConfig.MarketDataHub.(*marketdata.Hub)
+	authenticator := routerConfig.Authenticator.(*auth.Authenticator)
+	auditLog := routerConfig.AuditLog.(*audit.Log)
+
+	limiters := routerConfig.RateLimiters.(*ratelimit.Limiters)
+
+	// Calls share the REST rate limits: the peer IP is charged before authentication, the user after it
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			ipRateLimitUnaryInterceptor(limiters),
+			authUnaryInterceptor(authenticator, auditLog),
+			userRateLimitUnaryInterceptor(limiters),
+		),
+		grpc.StreamInterceptor(ipRateLimitStreamInterceptor(limiters)),
+	)
+	pb.RegisterPairServiceServer(grpcServer, &pairGRPCService{pairService: services.GetPairService()})
+	pb.RegisterOrderServiceServer(grpcServer, &orderGRPCService{
+		placeOrderService: services.GetPlaceOrderService(),
+		orderBookService:  services.GetOrderBookService(),
+		config:            routerConfig,
+	})
+	pb.RegisterOrderBookServiceServer(grpcServer, &orderBookGRPCService{engine: matchingEngine, hub: hub})
+	pb.RegisterTradeServiceServer(grpcServer, &tradeGRPCService{engine: matchingEngine, hub: hub})
+
+	return grpcServer
+}
+
+type pairGRPCService struct {
+	pb.UnimplementedPairServiceServer
+	pairService services.PairService
+}
+
+// CreatePair mirrors POST /api/pairs
+func (s *pairGRPCService) CreatePair(ctx context.Context, req *pb.CreatePairRequest) (*pb.CreatePairResponse, error) {
+	if strings.TrimSpace(req.GetBase()) == "" || strings.TrimSpace(req.GetQuote()) == "" {
+		log.Println("Missing base or quote")
+		return nil, status.Error(codes.InvalidArgument, "Base and quote are required")
+	}
+
+	pair, err := s.pairService.CreatePair(ctx, req.GetBase(), req.GetQuote(), engine.MatchingConfig{
+		Mode:            req.GetMatchingMode(),
+		BatchIntervalMs: req.GetBatchIntervalMs(),
+		AllocationConfig: engine.AllocationConfig{
+			Algorithm:       req.GetAllocation(),
+			TopOrderPercent: req.GetTopOrderPercent(),
+			LMMPercent:      req.GetLmmPercent(),
+			LMMUserIDs:      req.GetLmmUserIds(),
+		},
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	return &pb.CreatePairResponse{Pair: pair.Pair, MatchingMode: pair.Mode, BatchIntervalMs: pair.BatchIntervalMs, Allocation: pair.Algorithm}, nil
+}
+
+type orderGRPCService struct {
+	pb.UnimplementedOrderServiceServer
+	placeOrderService services.PlaceOrderService
+	orderBookService  services.OrderBookService
+	config            *util.RouterConfig
+}
+
+// PlaceOrder mirrors POST /api/orders
+func (s *orderGRPCService) PlaceOrder(ctx context.Context, req *pb.PlaceOrderRequest) (*pb.PlaceOrderResponse, error) {
+	userID, _ := auth.UserFromContext(ctx)
+
+	validationErrors := s.placeOrderService.ValidateRequest(ctx, userID, req.GetPair(), req.GetSide(), req.GetPrice(), req.GetQuantity(), req.GetClientOrderId())
+	if len(validationErrors) > 0 {
+		log.Println("Validation failed")
+		messages := make([]string, 0, len(validationErrors))
+		for _, validationErr := range validationErrors {
+			messages = append(messages, validationErr.Code+": "+validationErr.Message)
+		}
+		return nil, status.Error(codes.InvalidArgument, "Validation failed: "+strings.Join(messages, "; "))
+	}
+
+	order, trades, err := s.placeOrderService.ProcessRequest(ctx, userID, req.GetPair(), req.GetSide(), req.GetPrice(), req.GetQuantity(), req.GetClientOrderId())
+	if err != nil {
+		log.Printf("Failed to place order: %v", err)
+
+		// A retried call gets the order its client order ID already refers to, as over REST
+		if err == apperrors.ErrDuplicateClientOrderID && order != nil {
+			return nil, toGRPCErrorWithDetails(err, toPBOrder(order))
+		}
+		return nil, toGRPCError(err)
+	}
+	trackSessionOrders(ctx, s.config, order)
+
+	return &pb.PlaceOrderResponse{Order: toPBOrder(order), Trades: toPBTrades(trades)}, nil
+}
+
+// GetOrders mirrors GET /api/orders
+func (s *orderGRPCService) GetOrders(ctx context.Context, req *pb.GetOrdersRequest) (*pb.GetOrdersResponse, error) {
+	userID, _ := auth.UserFromContext(ctx)
+
+	orders, err := s.orderBookService.GetOrdersByUser(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to get orders: %v", err)
+		return nil, toGRPCError(err)
+	}
+
+	resp := &pb.GetOrdersResponse{Orders: make([]*pb.Order, 0, len(orders))}
+	for _, order := range orders {
+		resp.Orders = append(resp.Orders, toPBOrder(order))
+	}
+	return resp, nil
+}
+
+type orderBookGRPCService struct {
+	pb.UnimplementedOrderBookServiceServer
+	engine *engine.MatchingEngine
+	hub    *marketdata.Hub
+}
+
+// GetOrderBook mirrors GET /api/orderbook
+func (s *orderBookGRPCService) GetOrderBook(ctx context.Context, req *pb.GetOrderBookRequest) (*pb.OrderBook, error) {
+	ob, err := s.lookup(req.GetPair())
+	if err != nil {
+		return nil, err
+	}
+	return toPBOrderBook(ob, bookDepth(req.GetDepth())), nil
+}
+
+// StreamOrderBook sends the current book and a fresh snapshot after every change
+func (s *orderBookGRPCService) StreamOrderBook(req *pb.StreamOrderBookRequest, stream grpc.ServerStreamingServer[pb.OrderBook]) error {
+	ob, err := s.lookup(req.GetPair())
+	if err != nil {
+		return err
+	}
+	depth := bookDepth(req.GetDepth())
+
+	// Subscribe before the initial snapshot so no change is missed in between
+	sub := s.hub.Subscribe([]string{marketdata.ChannelOrderBook}, ob.Pair)
+	defer sub.Close()
+
+	if err := stream.Send(toPBOrderBook(ob, depth)); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-sub.C:
+			if err := stream.Send(toPBOrderBook(ob, depth)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *orderBookGRPCService) lookup(pair string) (*engine.OrderBook, error) {
+	if pair == "" {
+		log.Printf("Missing pair parameter")
+		return nil, status.Error(codes.InvalidArgument, "Missing pair parameter")
+	}
+	ob := s.engine.GetOrderBook(assets.NormalizePair(pair))
+	if ob == nil {
+		log.Printf("Order book not found for pair: %s", pair)
+		return nil, toGRPCError(apperrors.ErrPairNotFound)
+	}
+	return ob, nil
+}
+
+type tradeGRPCService struct {
+	pb.UnimplementedTradeServiceServer
+	engine *engine.MatchingEngine
+	hub    *marketdata.Hub
+}
+
+// GetTrades returns the most recent trades, optionally filtered by pair
+func (s *tradeGRPCService) GetTrades(ctx context.Context, req *pb.GetTradesRequest) (*pb.GetTradesResponse, error) {
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultGRPCTradeLimit
+	}
+
+	pair := assets.NormalizePair(req.GetPair())
+	all := s.engine.GetTrades()
+	trades := make([]*models.Trade, 0, limit)
+	for i := len(all) - 1; i >= 0 && len(trades) < limit; i-- {
+		if pair == "" || all[i].Pair == pair {
+			trades = append(trades, all[i])
+		}
+	}
+
+	// Return oldest first
+	for i, j := 0, len(trades)-1; i < j; i, j = i+1, j-1 {
+		trades[i], trades[j] = trades[j], trades[i]
+	}
+
+	return &pb.GetTradesResponse{Trades: toPBTrades(trades)}, nil
+}
+
+// StreamTrades pushes every trade executed after the call starts
+func (s *tradeGRPCService) StreamTrades(req *pb.StreamTradesRequest, stream grpc.ServerStreamingServer[pb.Trade]) error {
+	sub := s.hub.Subscribe([]string{marketdata.ChannelTrades}, assets.NormalizePair(req.GetPair()))
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-sub.C:
+			trade, ok := event.Data.(models.Trade)
+			if !ok {
+				continue
+			}
+			if err := stream.Send(toPBTrade(&trade)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func bookDepth(depth int32) int {
+	if depth <= 0 {
+		return defaultGRPCBookDepth
+	}
+	return int(depth)
+}
+
+func toPBOrder(order *models.Order) *pb.Order {
+	return &pb.Order{
+		Id:            order.ID,
+		UserId:        order.UserID,
+		Pair:          order.Pair,
+		Side:          order.Side,
+		Price:         order.Price,
+		Quantity:      order.Quantity,
+		Filled:        order.Filled,
+		Status:        order.Status,
+		CreatedAt:     timestamppb.New(order.CreatedAt),
+		ClientOrderId: order.ClientOrderID,
+	}
+}
+
+func toPBTrade(trade *models.Trade) *pb.Trade {
+	return &pb.Trade{
+		Id:          trade.ID,
+		BuyOrderId:  trade.BuyOrderID,
+		SellOrderId: trade.SellOrderID,
+		Pair:        trade.Pair,
+		Price:       trade.Price,
+		Quantity:    trade.Quantity,
+		CreatedAt:   timestamppb.New(trade.CreatedAt),
+	}
+}
+
+func toPBTrades(trades []*models.Trade) []*pb.Trade {
+	result := make([]*pb.Trade, 0, len(trades))
+	for _, trade := range trades {
+		result = append(result, toPBTrade(trade))
+	}
+	return result
+}
+
+func toPBOrderBook(ob *engine.OrderBook, depth int) *pb.OrderBook {
+	buys, sells := ob.GetDepth(depth)
+	return &pb.OrderBook{
+		Pair: ob.Pair,
+		Buy:  toPBPriceLevels(buys),
+		Sell: toPBPriceLevels(sells),
+	}
+}
+
+func toPBPriceLevels(levels []map[string]interface{}) []*pb.PriceLevel {
+	result := make([]*pb.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		price, _ := level["price"].(float64)
+		quantity, _ := level["quantity"].(float64)
+		result = append(result, &pb.PriceLevel{Price: price, Quantity: quantity})
+	}
+	return result
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/marketdata"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/pb"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testPlaceOrderService places orders straight on the engine, failing validation on the side only
+type testPlaceOrderService struct {
+	engine *engine.MatchingEngine
+}
+
+func (s *testPlaceOrderService) ValidateRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) []*util.Error {
+	if side != "buy" && side != "sell" {
+		return []*util.Error{util.ServerToFieldError(apperrors.ErrInvalidSide, "side")}
+	}
+	return nil
+}
+
+func (s *testPlaceOrderService) ProcessRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) (*models.Order, []*models.Trade, error) {
+	return s.engine.PlaceOrderWithClientID(userID, pair, side, price, quantity, clientOrderID)
+}
+
+// testOrderBookService serves the caller's orders from the engine
+type testOrderBookService struct {
+	services.OrderBookService
+	engine *engine.MatchingEngine
+}
+
+func (s *testOrderBookService) GetOrdersByUser(ctx context.Context, userID int64) ([]*models.Order, error) {
+	return s.engine.GetOrdersByUser(userID), nil
+}
+
+// newTestGRPCClient serves the gRPC services over an in-memory listener, treating every call as
+// coming from userID in place of the signature check
+func newTestGRPCClient(t *testing.T, matchingEngine *engine.MatchingEngine, userID int64) *grpc.ClientConn {
+	t.Helper()
+	hub := marketdata.NewHub()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(auth.WithUser(ctx, userID), req)
+	}))
+	pb.RegisterOrderServiceServer(grpcServer, &orderGRPCService{
+		placeOrderService: &testPlaceOrderService{engine: matchingEngine},
+		orderBookService:  &testOrderBookService{engine: matchingEngine},
+	})
+	pb.RegisterOrderBookServiceServer(grpcServer, &orderBookGRPCService{engine: matchingEngine, hub: hub})
+	pb.RegisterTradeServiceServer(grpcServer, &tradeGRPCService{engine: matchingEngine, hub: hub})
+
+	listener := bufconn.Listen(1 << 20)
+	go grpcServer.Serve(listener)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		grpcServer.Stop()
+	})
+	return conn
+}
+
+func newTestGRPCEngine(t *testing.T) *engine.MatchingEngine {
+	t.Helper()
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	return matchingEngine
+}
+
+func expectCode(t *testing.T, what string, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("%s: got %v (%v), want %v", what, got, err, want)
+	}
+}
+
+func TestGRPCPlaceOrder(t *testing.T) {
+	matchingEngine := newTestGRPCEngine(t)
+	client := pb.NewOrderServiceClient(newTestGRPCClient(t, matchingEngine, 1))
+	ctx := context.Background()
+
+	resting, err := client.PlaceOrder(ctx, &pb.PlaceOrderRequest{Pair: "BTC/USDT", Side: "sell", Price: 100, Quantity: 1})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if resting.GetOrder().GetUserId() != 1 || resting.GetOrder().GetStatus() != "open" || len(resting.GetTrades()) != 0 {
+		t.Fatalf("resting order %+v, want an open order of user 1", resting)
+	}
+
+	taker, err := client.PlaceOrder(ctx, &pb.PlaceOrderRequest{Pair: "BTC/USDT", Side: "buy", Price: 100, Quantity: 1, ClientOrderId: "taker-1"})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if taker.GetOrder().GetClientOrderId() != "taker-1" || len(taker.GetTrades()) != 1 || taker.GetTrades()[0].GetSellOrderId() != resting.GetOrder().GetId() {
+		t.Fatalf("taker %+v, want one trade against order %d", taker, resting.GetOrder().GetId())
+	}
+
+	_, err = client.PlaceOrder(ctx, &pb.PlaceOrderRequest{Pair: "BTC/USDT", Side: "hold", Price: 100, Quantity: 1})
+	expectCode(t, "invalid side", err, codes.InvalidArgument)
+	_, err = client.PlaceOrder(ctx, &pb.PlaceOrderRequest{Pair: "DOGE/USDT", Side: "buy", Price: 100, Quantity: 1})
+	expectCode(t, "unknown pair", err, codes.NotFound)
+
+	orders, err := client.GetOrders(ctx, &pb.GetOrdersRequest{UserId: 2})
+	if err != nil {
+		t.Fatalf("GetOrders: %v", err)
+	}
+	if len(orders.GetOrders()) != 2 {
+		t.Fatalf("got %d orders, want the caller's 2 whatever user_id says", len(orders.GetOrders()))
+	}
+}
+
+func TestGRPCDuplicateClientOrderIDReturnsTheOriginalOrder(t *testing.T) {
+	matchingEngine := newTestGRPCEngine(t)
+	client := pb.NewOrderServiceClient(newTestGRPCClient(t, matchingEngine, 1))
+	ctx := context.Background()
+
+	original, err := client.PlaceOrder(ctx, &pb.PlaceOrderRequest{Pair: "BTC/USDT", Side: "buy", Price: 100, Quantity: 1, ClientOrderId: "bid-1"})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	_, err = client.PlaceOrder(ctx, &pb.PlaceOrderRequest{Pair: "BTC/USDT", Side: "buy", Price: 99, Quantity: 2, ClientOrderId: "bid-1"})
+	expectCode(t, "duplicate client order ID", err, codes.AlreadyExists)
+	st := status.Convert(err)
+	if st.Message() != apperrors.ErrDuplicateClientOrderID.Error() {
+		t.Fatalf("got message %q, want %q", st.Message(), apperrors.ErrDuplicateClientOrderID.Error())
+	}
+	details := st.Details()
+	if len(details) != 1 {
+		t.Fatalf("got %d status details, want the original order", len(details))
+	}
+	order, ok := details[0].(*pb.Order)
+	if !ok {
+		t.Fatalf("status detail is %T, want *pb.Order", details[0])
+	}
+	if order.GetId() != original.GetOrder().GetId() || order.GetPrice() != 100 || order.GetQuantity() != 1 {
+		t.Fatalf("status detail %+v, want the original order %+v", order, original.GetOrder())
+	}
+	if orders := matchingEngine.GetOrdersByUser(1); len(orders) != 1 {
+		t.Fatalf("user has %d orders, want the duplicate not placed", len(orders))
+	}
+}
+
+func TestGRPCOrderBookAndTrades(t *testing.T) {
+	matchingEngine := newTestGRPCEngine(t)
+	conn := newTestGRPCClient(t, matchingEngine, 1)
+	books := pb.NewOrderBookServiceClient(conn)
+	trades := pb.NewTradeServiceClient(conn)
+	ctx := context.Background()
+
+	for _, price := range []float64{100, 101, 102} {
+		if _, _, err := matchingEngine.PlaceOrder(1, "BTC/USDT", "sell", price, 1); err != nil {
+			t.Fatalf("PlaceOrder: %v", err)
+		}
+	}
+	if _, _, err := matchingEngine.PlaceOrder(2, "BTC/USDT", "buy", 99, 1); err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	book, err := books.GetOrderBook(ctx, &pb.GetOrderBookRequest{Pair: " btc/usdt "})
+	if err != nil {
+		t.Fatalf("GetOrderBook: %v", err)
+	}
+	if book.GetPair() != "BTC/USDT" || len(book.GetSell()) != 3 || len(book.GetBuy()) != 1 || book.GetBuy()[0].GetPrice() != 99 {
+		t.Fatalf("order book %+v, want the 3 asks and the bid of BTC/USDT", book)
+	}
+	if book, _ := books.GetOrderBook(ctx, &pb.GetOrderBookRequest{Pair: "BTC/USDT", Depth: 2}); len(book.GetSell()) != 2 {
+		t.Fatalf("order book %+v, want 2 ask levels at depth 2", book)
+	}
+	_, err = books.GetOrderBook(ctx, &pb.GetOrderBookRequest{})
+	expectCode(t, "order book without a pair", err, codes.InvalidArgument)
+	_, err = books.GetOrderBook(ctx, &pb.GetOrderBookRequest{Pair: "DOGE/USDT"})
+	expectCode(t, "order book of an unknown pair", err, codes.NotFound)
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := matchingEngine.PlaceOrder(2, "BTC/USDT", "buy", 102, 1); err != nil {
+			t.Fatalf("PlaceOrder: %v", err)
+		}
+	}
+	recent, err := trades.GetTrades(ctx, &pb.GetTradesRequest{Pair: "btc/usdt", Limit: 2})
+	if err != nil {
+		t.Fatalf("GetTrades: %v", err)
+	}
+	if len(recent.GetTrades()) != 2 || recent.GetTrades()[0].GetPrice() != 101 || recent.GetTrades()[1].GetPrice() != 102 {
+		t.Fatalf("trades %+v, want the 2 most recent oldest first", recent.GetTrades())
+	}
+	other, err := trades.GetTrades(ctx, &pb.GetTradesRequest{Pair: "ETH/USDT"})
+	if err != nil {
+		t.Fatalf("GetTrades: %v", err)
+	}
+	if len(other.GetTrades()) != 0 {
+		t.Fatalf("got %d trades for another pair, want none", len(other.GetTrades()))
+	}
+}
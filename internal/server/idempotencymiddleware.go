@@ -0,0 +1,117 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/idempotency"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Idempotency headers
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// responseRecorder captures the status code and body written by a handler while passing them on
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// IdempotencyMiddleware answers retries of an authenticated request carrying an Idempotency-Key
+// header with the response to the first request with that key, instead of running it again.
+// Requests are compared by method, route and body, where /api/v1 and /api routes are the same,
+// and reusing a key for a different request is rejected. Server errors, rate limit rejections and
+// panics are not stored, so those requests can be retried with the same key. Routes whose responses
+// carry secrets must not use it, the stored body would be kept in memory.
+func IdempotencyMiddleware(store *idempotency.Store, config *util.RouterConfig) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+			key := request.Header.Get(HeaderIdempotencyKey)
+			userID, authenticated := auth.UserFromContext(request.Context())
+			if request.Method == http.MethodOptions || key == "" || !authenticated {
+				next.ServeHTTP(w, request)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				writeError(w, apperrors.ErrInvalidIdempotencyKey)
+				return
+			}
+
+			// The body was already buffered for signature verification
+			body, _ := io.ReadAll(request.Body)
+			request.Body = io.NopCloser(bytes.NewReader(body))
+
+			fingerprint := idempotency.NewFingerprint(request.Method, canonicalRoute(request.URL), body)
+			stored, err := store.Begin(userID, key, fingerprint)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			if stored != nil {
+				w.Header().Set("Content-Type", stored.ContentType)
+				w.Header().Set(HeaderIdempotentReplayed, "true")
+				w.WriteHeader(stored.StatusCode)
+				w.Write(stored.Body)
+				return
+			}
+
+			// A handler that panics must not leave the key in progress forever
+			completed := false
+			defer func() {
+				if !completed {
+					store.Release(userID, key)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, request)
+
+			if recorder.status >= http.StatusInternalServerError || recorder.status == http.StatusTooManyRequests {
+				return
+			}
+			store.Complete(userID, key, &idempotency.Response{
+				StatusCode:  recorder.status,
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			})
+			completed = true
+		})
+	}
+}
+
+// canonicalRoute returns a request's path without its API prefix, followed by the query string,
+// so a retry sent to the other prefix matches the first request
+func canonicalRoute(u *url.URL) string {
+	route := u.Path
+	if trimmed := strings.TrimPrefix(route, APIPrefix); trimmed != route {
+		route = trimmed
+	} else {
+		route = strings.TrimPrefix(route, LegacyAPIPrefix)
+	}
+	if u.RawQuery != "" {
+		route += "?" + u.RawQuery
+	}
+	return route
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/idempotency"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func idempotentRequest(path string) *http.Request {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"pair":"BTC/USDT"}`))
+	request.Header.Set(HeaderIdempotencyKey, "retry-1")
+	return request.WithContext(auth.WithUser(request.Context(), 1))
+}
+
+func TestIdempotencyReplaysAcrossAPIPrefixes(t *testing.T) {
+	calls := 0
+	handler := IdempotencyMiddleware(idempotency.NewStore(idempotency.Config{}), &util.RouterConfig{})(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	serve(handler, idempotentRequest(LegacyAPIPrefix+"/orders"))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, idempotentRequest(APIPrefix+"/orders"))
+
+	if calls != 1 || recorder.Code != http.StatusCreated || recorder.Header().Get(HeaderIdempotentReplayed) != "true" {
+		t.Fatalf("retry on %s: %d calls, status %d, want the first response replayed", APIPrefix, calls, recorder.Code)
+	}
+}
+
+func TestIdempotencyReleasesKeyWhenHandlerPanics(t *testing.T) {
+	calls := 0
+	handler := IdempotencyMiddleware(idempotency.NewStore(idempotency.Config{}), &util.RouterConfig{})(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("handler failed")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	func() {
+		defer func() { recover() }()
+		serve(handler, idempotentRequest(APIPrefix+"/orders"))
+	}()
+
+	if status := serve(handler, idempotentRequest(APIPrefix+"/orders")); status != http.StatusOK || calls != 2 {
+		t.Fatalf("retry after a panic: status %d after %d calls, want the request run again", status, calls)
+	}
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/marketdata"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamWriteTimeout = 10 * time.Second
+	streamPingInterval = 30 * time.Second
+	// streamReadTimeout closes streams whose client stopped answering pings
+	streamReadTimeout = 2 * streamPingInterval
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// MarketDataStreamHandler handles GET /api/stream?channels=X,Y&pair=Z. With
+// cancel_on_disconnect=true&grace_ms=N on a request signed by a key with the trade permission, the
+// orders placed with that key while it has such a stream open are cancelled once its last stream
+// closes or stops answering pings and no new one opens within the grace period.
+func MarketDataStreamHandler(hub *marketdata.Hub, config *util.RouterConfig) http.HandlerFunc {
+	sessions, _ := config.StreamSessions.(*cancelOnDisconnect)
+
+	return func(w http.ResponseWriter, request *http.Request) {
+		var channels []string
+		if channelsStr := request.URL.Query().Get("channels"); channelsStr != "" {
+			channels = strings.Split(channelsStr, ",")
+		}
+		pair := assets.NormalizePair(request.URL.Query().Get("pair"))
+
+		cancelOnDisconnect := request.URL.Query().Get("cancel_on_disconnect") == "true"
+		var apiKey *auth.APIKey
+		var grace time.Duration
+		if cancelOnDisconnect {
+			if sessions == nil {
+				writeFieldError(w, apperrors.ErrInvalidParameter, "cancel_on_disconnect")
+				return
+			}
+			var authenticated bool
+			apiKey, authenticated = auth.APIKeyFromContext(request.Context())
+			if !authenticated {
+				writeError(w, apperrors.ErrMissingCredentials)
+				return
+			}
+			if !apiKey.HasPermission(auth.PermissionTrade) {
+				writeError(w, apperrors.ErrPermissionDenied)
+				return
+			}
+			if graceStr := request.URL.Query().Get("grace_ms"); graceStr != "" {
+				graceMs, err := strconv.ParseInt(graceStr, 10, 64)
+				grace = time.Duration(graceMs) * time.Millisecond
+				if err != nil || grace < 0 || grace > maxCancelOnDisconnectGrace {
+					writeFieldError(w, apperrors.ErrInvalidGracePeriod, "grace_ms")
+					return
+				}
+			}
+		}
+
+		conn, err := upgrader.Upgrade(w, request, nil)
+		if err != nil {
+			log.Printf("Failed to upgrade stream connection: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		if cancelOnDisconnect {
+			sessions.opened(apiKey)
+			defer sessions.closed(apiKey, grace)
+		}
+
+		sub := hub.Subscribe(channels, pair)
+		defer sub.Close()
+
+		// Every pong extends the read deadline, so a client that stops answering pings is dropped
+		conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(streamReadTimeout))
+		})
+
+		// Drain client frames so control messages are processed and closes are noticed
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ticker := time.NewTicker(streamPingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case event := <-sub.C:
+				conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+				if err := conn.WriteJSON(event); err != nil {
+					log.Printf("Failed to write stream event: %v", err)
+					return
+				}
+			case <-ticker.C:
+				conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
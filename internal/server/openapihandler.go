@@ -0,0 +1,21 @@
+package server
+
+import (
+	_ "embed"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+)
+
+// openAPIDocument describes the /api/v1 routes and is maintained by hand alongside the handlers
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// OpenAPIHandler handles GET /api/v1/openapi.json
+func OpenAPIHandler(config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(openAPIDocument)
+	}
+}
@@ -0,0 +1,502 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/audit"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/idempotency"
+	"mini-crypto-exchange/internal/marketdata"
+	"mini-crypto-exchange/internal/ratelimit"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+)
+
+// contractSchema is a schema or any other object of the OpenAPI document
+type contractSchema = map[string]interface{}
+
+// contractClient sends signed requests through the full router, validating each request against
+// the embedded OpenAPI document before dispatch and each response after it
+type contractClient struct {
+	t        *testing.T
+	router   *Router
+	document contractSchema
+	// operations finds the documented operation of a request for openapi3filter
+	operations routers.Router
+	nonce      int
+	// covered records the documented operations that were exercised, as "METHOD /path"
+	covered map[string]bool
+}
+
+// newContractClient wires the services and router like RunServer, over an unlimited rate limiter.
+// Services are singletons, so it can only be called once per test binary.
+func newContractClient(t *testing.T) (*contractClient, *auth.KeyStore) {
+	t.Helper()
+	var document contractSchema
+	if err := json.Unmarshal(openAPIDocument, &document); err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromData(openAPIDocument)
+	if err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+	if err := spec.Validate(loader.Context); err != nil {
+		t.Fatalf("openapi.json is not a valid OpenAPI document: %v", err)
+	}
+	operations, err := gorillamux.NewRouter(spec)
+	if err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+
+	matchingEngine := engine.NewMatchingEngine()
+	matchingEngine.SetDefaultLimits(engine.LimitsFromEnv())
+	matchingEngine.SetDefaultRiskConfig(engine.RiskConfigFromEnv())
+	assetRegistry := assets.NewRegistry(assets.Config{Assets: assets.DefaultAssets})
+	marketDataHub := marketdata.NewHub()
+	candleAggregator := marketdata.NewCandleAggregator(marketDataHub)
+	tickerTracker := marketdata.NewTickerTracker()
+	matchingEngine.AddTradeListener(candleAggregator)
+	matchingEngine.AddTradeListener(tickerTracker)
+
+	keyStore := auth.NewKeyStore()
+	authenticator := auth.NewAuthenticator(keyStore)
+	auditLog, _ := audit.NewLog(audit.Config{})
+	unlimited := ratelimit.Config{OrderBurst: math.MaxInt32, OrderRate: math.MaxInt32, QueryBurst: math.MaxInt32, QueryRate: math.MaxInt32}
+
+	config := &util.RouterConfig{
+		MatchingEngine:   matchingEngine,
+		MarketDataHub:    marketDataHub,
+		Authenticator:    authenticator,
+		AuditLog:         auditLog,
+		RateLimiters:     ratelimit.NewLimiters(unlimited),
+		IdempotencyStore: idempotency.NewStore(idempotency.Config{}),
+	}
+	services.InitPlaceOrderService(matchingEngine, config)
+	services.InitOrderBookService(matchingEngine, config)
+	services.InitCandleService(matchingEngine, candleAggregator, config)
+	services.InitTickerService(matchingEngine, tickerTracker, config)
+	services.InitAPIKeyService(authenticator, auth.Config{}, config)
+	services.InitOrderLimitsService(matchingEngine, config)
+	services.InitPairStatusService(matchingEngine, config)
+	services.InitRiskService(matchingEngine, config)
+	services.InitAuctionService(matchingEngine, config)
+	services.InitCancelOrderService(matchingEngine, config)
+	services.InitBatchOrderService(services.GetPlaceOrderService(), matchingEngine, config)
+	services.InitAssetService(assetRegistry, matchingEngine, config)
+	services.InitPairService(matchingEngine, assetRegistry, config)
+	config.StreamSessions = newCancelOnDisconnect(matchingEngine)
+
+	router := NewRouter()
+	router.InitializeRouter(config)
+	return &contractClient{t: t, router: router, document: document, operations: operations, covered: make(map[string]bool)}, keyStore
+}
+
+// send serves a request, signed with apiKey when it is not nil, and returns the response
+func (c *contractClient) send(method string, path string, body interface{}, apiKey *auth.APIKey) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	c.router.ServeHTTP(recorder, c.newRequest(method, path, body, apiKey))
+	return recorder
+}
+
+// newRequest builds a request, signed with apiKey when it is not nil
+func (c *contractClient) newRequest(method string, path string, body interface{}, apiKey *auth.APIKey) *http.Request {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	request := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	request.Header.Set("Content-Type", "application/json")
+	if apiKey != nil {
+		c.nonce++
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		nonce := strconv.Itoa(c.nonce)
+		request.Header.Set(auth.HeaderAPIKey, apiKey.Key)
+		request.Header.Set(auth.HeaderTimestamp, timestamp)
+		request.Header.Set(auth.HeaderNonce, nonce)
+		request.Header.Set(auth.HeaderSignature, auth.Sign(apiKey.Secret, timestamp, nonce, method, request.URL.RequestURI(), payload))
+	}
+	return request
+}
+
+// validateRequest checks request against its documented operation: path and query parameters,
+// the content type and the body schema. Signatures are checked by the server, not here.
+func (c *contractClient) validateRequest(request *http.Request) error {
+	route, pathParams, err := c.operations.FindRoute(request)
+	if err != nil {
+		return err
+	}
+	return openapi3filter.ValidateRequest(context.Background(), &openapi3filter.RequestValidationInput{
+		Request:    request,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			// Defaults would change the body after it was signed
+			SkipSettingDefaults: true,
+		},
+	})
+}
+
+// check sends a request to path under APIPrefix, expects status and validates the response body
+// against the operation's documented response. The request must match the document too, unless it
+// is expected to be rejected with 400. A 2xx status must be documented explicitly; error statuses
+// may fall back to the default error envelope. The decoded body is returned.
+func (c *contractClient) check(method string, path string, body interface{}, apiKey *auth.APIKey, status int) map[string]interface{} {
+	c.t.Helper()
+	name := method + " " + path
+	request := c.newRequest(method, APIPrefix+path, body, apiKey)
+	if err := c.validateRequest(request); err != nil && status != http.StatusBadRequest {
+		c.t.Errorf("%s: request does not match the document: %v", name, err)
+	}
+	recorder := httptest.NewRecorder()
+	c.router.ServeHTTP(recorder, request)
+	if recorder.Code != status {
+		c.t.Errorf("%s: got status %d, want %d: %s", name, recorder.Code, status, recorder.Body)
+	}
+
+	template, operation := c.operation(method, strings.SplitN(path, "?", 2)[0])
+	if operation == nil {
+		c.t.Errorf("%s: operation not documented", name)
+		return nil
+	}
+	c.covered[method+" "+template] = true
+
+	responses := operation["responses"].(contractSchema)
+	response, documented := responses[strconv.Itoa(recorder.Code)].(contractSchema)
+	if !documented {
+		response, documented = responses["default"].(contractSchema)
+		if !documented || recorder.Code < http.StatusBadRequest {
+			c.t.Errorf("%s: status %d not documented", name, recorder.Code)
+			return nil
+		}
+	}
+	response = c.resolve(response)
+	schema := response["content"].(contractSchema)["application/json"].(contractSchema)["schema"].(contractSchema)
+
+	decoder := json.NewDecoder(recorder.Body)
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		c.t.Errorf("%s: response is not JSON: %v", name, err)
+		return nil
+	}
+	for _, problem := range c.validate(decoded, schema, fmt.Sprintf("%s %d", name, recorder.Code)) {
+		c.t.Error(problem)
+	}
+	object, _ := decoded.(map[string]interface{})
+	if recorder.Code >= http.StatusBadRequest && !hasErrorEnvelope(object) {
+		c.t.Errorf("%s: status %d without an error envelope: %v", name, recorder.Code, decoded)
+	}
+	return object
+}
+
+// hasErrorEnvelope reports whether a response body carries an error with a code and a message
+func hasErrorEnvelope(body map[string]interface{}) bool {
+	envelope, _ := body["error"].(map[string]interface{})
+	code, _ := envelope["code"].(string)
+	message, _ := envelope["message"].(string)
+	return code != "" && message != ""
+}
+
+// operation finds the documented operation serving method and path, preferring literal paths
+// such as /orders/batch over templated ones such as /orders/{id}
+func (c *contractClient) operation(method string, path string) (string, contractSchema) {
+	paths := c.document["paths"].(contractSchema)
+	templates := make([]string, 0, len(paths))
+	for template := range paths {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return strings.Count(templates[i], "{") < strings.Count(templates[j], "{")
+	})
+	for _, template := range templates {
+		segments := strings.Split(template, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, "{") {
+				segments[i] = "[^/]+"
+			} else {
+				segments[i] = regexp.QuoteMeta(segment)
+			}
+		}
+		if regexp.MustCompile("^" + strings.Join(segments, "/") + "$").MatchString(path) {
+			operation, _ := paths[template].(contractSchema)[strings.ToLower(method)].(contractSchema)
+			return template, operation
+		}
+	}
+	return "", nil
+}
+
+// resolve follows $ref pointers into the document's components
+func (c *contractClient) resolve(schema contractSchema) contractSchema {
+	for {
+		ref, isRef := schema["$ref"].(string)
+		if !isRef {
+			return schema
+		}
+		parts := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+		target := c.document
+		for _, part := range parts {
+			target = target[part].(contractSchema)
+		}
+		schema = target
+	}
+}
+
+// validate returns the ways value does not match schema
+func (c *contractClient) validate(value interface{}, schema contractSchema, path string) []string {
+	schema = c.resolve(schema)
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return []string{path + ": null not allowed"}
+	}
+
+	if parts, isAllOf := schema["allOf"].([]interface{}); isAllOf {
+		merged := contractSchema{"type": "object", "properties": contractSchema{}}
+		var required []interface{}
+		for _, part := range parts {
+			part := c.resolve(part.(contractSchema))
+			properties, isObject := part["properties"].(contractSchema)
+			if !isObject {
+				return c.validate(value, part, path)
+			}
+			for name, property := range properties {
+				merged["properties"].(contractSchema)[name] = property
+			}
+			if names, exists := part["required"].([]interface{}); exists {
+				required = append(required, names...)
+			}
+		}
+		merged["required"] = required
+		return c.validate(value, merged, path)
+	}
+
+	if alternatives, isOneOf := schema["oneOf"].([]interface{}); isOneOf {
+		matches := 0
+		for _, alternative := range alternatives {
+			if len(c.validate(value, alternative.(contractSchema), path)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return []string{fmt.Sprintf("%s: matches %d oneOf alternatives", path, matches)}
+		}
+		return nil
+	}
+
+	var problems []string
+	switch schema["type"] {
+	case "object":
+		object, isObject := value.(map[string]interface{})
+		if !isObject {
+			return []string{path + ": want object"}
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, exists := object[name.(string)]; !exists {
+				problems = append(problems, fmt.Sprintf("%s: missing %s", path, name))
+			}
+		}
+		if properties, exists := schema["properties"].(contractSchema); exists {
+			for name, field := range object {
+				property, documented := properties[name].(contractSchema)
+				if !documented {
+					problems = append(problems, fmt.Sprintf("%s: undocumented %s", path, name))
+					continue
+				}
+				problems = append(problems, c.validate(field, property, path+"."+name)...)
+			}
+		}
+	case "array":
+		items, isArray := value.([]interface{})
+		if !isArray {
+			return []string{path + ": want array"}
+		}
+		for i, item := range items {
+			problems = append(problems, c.validate(item, schema["items"].(contractSchema), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		text, isString := value.(string)
+		if !isString {
+			return []string{path + ": want string"}
+		}
+		if values, isEnum := schema["enum"].([]interface{}); isEnum {
+			for _, allowed := range values {
+				if allowed == text {
+					return nil
+				}
+			}
+			return []string{fmt.Sprintf("%s: %q not in enum", path, text)}
+		}
+	case "integer":
+		if number, isNumber := value.(json.Number); !isNumber || strings.ContainsAny(number.String(), ".eE") {
+			return []string{fmt.Sprintf("%s: want integer, got %v", path, value)}
+		}
+	case "number":
+		if _, isNumber := value.(json.Number); !isNumber {
+			return []string{fmt.Sprintf("%s: want number, got %v", path, value)}
+		}
+	case "boolean":
+		if _, isBool := value.(bool); !isBool {
+			return []string{fmt.Sprintf("%s: want boolean, got %v", path, value)}
+		}
+	}
+	return problems
+}
+
+func TestRoutesMatchOpenAPIDocument(t *testing.T) {
+	c, keys := newContractClient(t)
+
+	c.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(template, APIPrefix+"/") {
+			return nil
+		}
+		path := regexp.MustCompile(`\{(\w+):[^}]+\}`).ReplaceAllString(strings.TrimPrefix(template, APIPrefix), "{$1}")
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			if method == http.MethodOptions {
+				continue
+			}
+			if documented, _ := c.operation(method, path); documented != path {
+				t.Errorf("%s %s is not documented", method, path)
+			}
+		}
+		return nil
+	})
+
+	admin, _ := keys.Issue(1, []auth.Permission{auth.PermissionRead, auth.PermissionTrade, auth.PermissionAdmin}, nil)
+	trader, _ := keys.Issue(2, auth.DefaultPermissions, nil)
+	taker, _ := keys.Issue(3, auth.DefaultPermissions, nil)
+
+	// Pairs and assets
+	c.check(http.MethodPost, "/pairs", map[string]interface{}{"base": "BTC", "quote": "USD"}, admin, http.StatusOK)
+	c.check(http.MethodPost, "/pairs", map[string]interface{}{"base": "ETH", "quote": "USD", "matching_mode": "batch", "batch_interval_ms": 100}, admin, http.StatusOK)
+	c.check(http.MethodPost, "/pairs", map[string]interface{}{"base": "BTC"}, admin, http.StatusBadRequest)
+	c.check(http.MethodPost, "/pairs", map[string]interface{}{"base": "btc", "quote": "usd"}, admin, http.StatusConflict)
+	c.check(http.MethodPost, "/pairs", map[string]interface{}{"base": "BTC", "quote": "EUR"}, trader, http.StatusForbidden)
+	c.check(http.MethodGet, "/pairs", nil, nil, http.StatusOK)
+	c.check(http.MethodGet, "/assets", nil, nil, http.StatusOK)
+	c.check(http.MethodPut, "/admin/assets", map[string]interface{}{"symbol": "doge", "decimals": 8}, admin, http.StatusOK)
+	c.check(http.MethodPut, "/admin/assets", map[string]interface{}{"symbol": "doge", "decimals": 80}, admin, http.StatusBadRequest)
+	c.check(http.MethodGet, "/pairs/status?pair=BTC/USD", nil, nil, http.StatusOK)
+	c.check(http.MethodGet, "/pairs/status?pair=NOPE", nil, nil, http.StatusNotFound)
+	c.check(http.MethodGet, "/pairs/status", nil, nil, http.StatusBadRequest)
+	c.check(http.MethodGet, "/pairs/risk?pair=BTC/USD", nil, nil, http.StatusOK)
+	c.check(http.MethodGet, "/pairs/auction?pair=BTC/USD", nil, nil, http.StatusOK)
+	c.check(http.MethodGet, "/circuit-breakers", nil, nil, http.StatusOK)
+
+	// Orders
+	resting := c.check(http.MethodPost, "/orders", map[string]interface{}{"pair": "BTC/USD", "side": "sell", "price": 100, "quantity": 2, "client_order_id": "a1"}, trader, http.StatusOK)
+	c.check(http.MethodPost, "/orders", map[string]interface{}{"pair": "btc/usd", "side": "buy", "price": 100, "quantity": 1}, taker, http.StatusOK)
+	c.check(http.MethodPost, "/orders", map[string]interface{}{"pair": "BTC/USD", "side": "sell", "price": 100, "quantity": 2, "client_order_id": "a1"}, trader, http.StatusConflict)
+	c.check(http.MethodPost, "/orders", map[string]interface{}{"pair": "BTC/USD", "side": "x", "price": 0, "quantity": 2}, trader, http.StatusBadRequest)
+	c.check(http.MethodPost, "/orders", map[string]interface{}{"pair": "BTC/USD", "side": "buy", "price": 100.001, "quantity": 1}, trader, http.StatusBadRequest)
+	c.check(http.MethodPost, "/orders", map[string]interface{}{"pair": "NOPE/USD", "side": "buy", "price": 100, "quantity": 1}, trader, http.StatusNotFound)
+	c.check(http.MethodGet, "/orders", nil, trader, http.StatusOK)
+	c.check(http.MethodGet, "/orders?limit=1&sort=asc", nil, trader, http.StatusOK)
+	c.check(http.MethodGet, "/orders", nil, nil, http.StatusUnauthorized)
+	if order, _ := resting["order"].(map[string]interface{}); order != nil {
+		c.check(http.MethodGet, "/orders/"+order["id"].(json.Number).String(), nil, trader, http.StatusOK)
+	}
+	c.check(http.MethodGet, "/orders/999", nil, trader, http.StatusNotFound)
+	c.check(http.MethodGet, "/orders/client/a1", nil, trader, http.StatusOK)
+	c.check(http.MethodPost, "/orders/batch", map[string]interface{}{"orders": []interface{}{map[string]interface{}{"pair": "BTC/USD", "side": "buy", "price": 90, "quantity": 1}}}, trader, http.StatusOK)
+	c.check(http.MethodPost, "/orders/batch", map[string]interface{}{"orders": []interface{}{map[string]interface{}{"pair": "BTC/USD", "side": "buy", "price": 0, "quantity": 1}}}, trader, http.StatusBadRequest)
+	c.check(http.MethodPut, "/admin/users/3/limits", map[string]interface{}{"max_open_orders_per_pair": 1}, admin, http.StatusOK)
+	rolledBack := c.check(http.MethodPost, "/orders/batch", map[string]interface{}{"orders": []interface{}{
+		map[string]interface{}{"pair": "BTC/USD", "side": "buy", "price": 80, "quantity": 1},
+		map[string]interface{}{"pair": "BTC/USD", "side": "buy", "price": 79, "quantity": 1},
+	}}, taker, http.StatusConflict)
+	if results, _ := rolledBack["results"].([]interface{}); len(results) == 2 {
+		if order, _ := results[0].(map[string]interface{})["order"].(map[string]interface{}); order == nil || order["status"] != "cancelled" {
+			t.Errorf("rolled back batch: got first result %v, want its order cancelled", results[0])
+		}
+	}
+	c.check(http.MethodDelete, "/admin/users/3/limits", nil, admin, http.StatusOK)
+	c.check(http.MethodPost, "/orders/cancel-all-after", map[string]interface{}{"timeout_ms": 60000}, trader, http.StatusOK)
+	c.check(http.MethodPost, "/orders/cancel-all-after", map[string]interface{}{"timeout_ms": 0}, trader, http.StatusOK)
+	c.check(http.MethodPost, "/orders/cancel-all-after", map[string]interface{}{"timeout_ms": -1}, trader, http.StatusBadRequest)
+	c.check(http.MethodDelete, "/orders/client/a1", nil, trader, http.StatusOK)
+	c.check(http.MethodDelete, "/orders?pair=BTC/USD", nil, trader, http.StatusOK)
+
+	// Market data
+	c.check(http.MethodGet, "/orderbook?pair=BTC/USD&depth=5", nil, nil, http.StatusOK)
+	c.check(http.MethodGet, "/orderbook?pair=NOPE", nil, nil, http.StatusNotFound)
+	c.check(http.MethodGet, "/candles?pair=BTC/USD", nil, nil, http.StatusOK)
+	c.check(http.MethodGet, "/ticker?pair=BTC/USD", nil, nil, http.StatusOK)
+	c.check(http.MethodGet, "/ticker", nil, nil, http.StatusOK)
+	c.check(http.MethodGet, "/openapi.json", nil, nil, http.StatusOK)
+
+	// Keys and administration
+	c.check(http.MethodGet, "/keys", nil, trader, http.StatusOK)
+	issued := c.check(http.MethodPost, "/keys", map[string]interface{}{"permissions": []string{"read"}}, trader, http.StatusCreated)
+	if key, _ := issued["key"].(map[string]interface{}); key != nil {
+		c.check(http.MethodDelete, "/keys/"+key["api_key"].(string), nil, trader, http.StatusOK)
+	}
+	c.check(http.MethodDelete, "/keys/unknown", nil, trader, http.StatusNotFound)
+	c.check(http.MethodGet, "/admin/audit?limit=5", nil, admin, http.StatusOK)
+	c.check(http.MethodGet, "/admin/users/2/limits", nil, admin, http.StatusOK)
+	c.check(http.MethodPut, "/admin/users/2/limits", map[string]interface{}{"max_open_orders_per_pair": 10}, admin, http.StatusOK)
+	c.check(http.MethodDelete, "/admin/users/2/limits", nil, admin, http.StatusOK)
+	c.check(http.MethodPut, "/admin/pairs/risk", map[string]interface{}{"pair": "BTC/USD", "price_band_percent": 10}, admin, http.StatusOK)
+	c.check(http.MethodPost, "/admin/pairs/auction", map[string]interface{}{"pair": "ETH/USD", "duration_seconds": 60}, admin, http.StatusOK)
+	c.check(http.MethodPost, "/admin/pairs/status", map[string]interface{}{"pair": "ETH/USD", "status": "trading"}, admin, http.StatusOK)
+
+	// Unknown routes and methods answer with the error envelope too
+	for _, request := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, APIPrefix + "/nope", http.StatusNotFound},
+		{http.MethodPatch, APIPrefix + "/orders", http.StatusMethodNotAllowed},
+		{http.MethodGet, LegacyAPIPrefix + "/openapi.json", http.StatusNotFound},
+	} {
+		recorder := c.send(request.method, request.path, nil, nil)
+		var body map[string]interface{}
+		json.Unmarshal(recorder.Body.Bytes(), &body)
+		if recorder.Code != request.status || !hasErrorEnvelope(body) {
+			t.Errorf("%s %s: got %d %s, want %d with an error envelope", request.method, request.path, recorder.Code, recorder.Body, request.status)
+		}
+	}
+
+	// Public queries answer the same under the legacy prefix
+	for _, path := range []string{"/pairs", "/assets", "/orderbook?pair=BTC/USD", "/pairs/status?pair=NOPE"} {
+		versioned, legacy := c.send(http.MethodGet, APIPrefix+path, nil, nil), c.send(http.MethodGet, LegacyAPIPrefix+path, nil, nil)
+		if versioned.Code != legacy.Code || versioned.Body.String() != legacy.Body.String() {
+			t.Errorf("GET %s%s: got %d, want %d as under %s", LegacyAPIPrefix, path, legacy.Code, versioned.Code, APIPrefix)
+		}
+	}
+
+	// Every documented operation but the WebSocket upgrade was exercised
+	for template, item := range c.document["paths"].(contractSchema) {
+		for method := range item.(contractSchema) {
+			operation := strings.ToUpper(method) + " " + template
+			if !c.covered[operation] && operation != "GET /stream" {
+				t.Errorf("%s is not exercised", operation)
+			}
+		}
+	}
+}
@@ -2,22 +2,28 @@ package server
 
 import (
 	"encoding/json"
-	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
 	"mini-crypto-exchange/internal/models"
 	"mini-crypto-exchange/internal/services"
 	"mini-crypto-exchange/internal/util"
 	"net/http"
 	"strconv"
+
+	"github.com/gorilla/mux"
 )
 
 type OrderBookResponse struct {
-	Data  interface{} `json:"data,omitempty"`
-	Error string      `json:"error,omitempty"`
+	Data interface{} `json:"data,omitempty"`
 }
 
 type GetOrdersResponse struct {
-	Orders []*models.Order `json:"orders,omitempty"`
-	Error  string          `json:"error,omitempty"`
+	Orders     interface{} `json:"orders,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type GetOrderResponse struct {
+	Order *models.Order `json:"order,omitempty"`
 }
 
 // OrderBookHandler handles GET /api/orderbook
@@ -27,10 +33,7 @@ func OrderBookHandler(service services.OrderBookService, config *util.RouterConf
 
 		pair := request.URL.Query().Get("pair")
 		if pair == "" {
-			log.Printf("Missing pair parameter")
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(OrderBookResponse{Error: "Missing pair parameter"})
+			writeFieldError(w, apperrors.ErrMissingParameter, "pair")
 			return
 		}
 
@@ -43,10 +46,7 @@ func OrderBookHandler(service services.OrderBookService, config *util.RouterConf
 
 		data, err := service.GetOrderBook(ctx, pair, depth)
 		if err != nil {
-			log.Printf("Failed to get order book: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(OrderBookResponse{Error: err.Error()})
+			writeError(w, err)
 			return
 		}
 
@@ -56,40 +56,89 @@ func OrderBookHandler(service services.OrderBookService, config *util.RouterConf
 	}
 }
 
-// GetOrdersHandler handles GET /api/orders?user_id=X
+// GetOrdersHandler handles GET /api/orders?pair=X&side=Y&status=Z&start=S&end=E&sort=asc&limit=N&cursor=C
+// for the authenticated user
 func GetOrdersHandler(service services.OrderBookService, config *util.RouterConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, request *http.Request) {
 		ctx := request.Context()
+		userID, _ := auth.UserFromContext(ctx)
+		query := request.URL.Query()
 
-		userIDStr := request.URL.Query().Get("user_id")
-		if userIDStr == "" {
-			log.Printf("Missing user_id parameter")
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(GetOrdersResponse{Error: "Missing user_id parameter"})
+		start, startErr := parseTimeParam(query.Get("start"))
+		end, endErr := parseTimeParam(query.Get("end"))
+		if startErr != nil || endErr != nil {
+			writeError(w, apperrors.ErrInvalidTimeRange)
 			return
 		}
 
-		userID, err := strconv.ParseInt(userIDStr, 10, 64)
-		if err != nil || userID <= 0 {
-			log.Printf("Invalid user_id parameter")
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(GetOrdersResponse{Error: "Invalid user_id parameter"})
+		limit := 0
+		if limitStr := query.Get("limit"); limitStr != "" {
+			var err error
+			if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+				writeFieldError(w, apperrors.ErrInvalidPageLimit, "limit")
+				return
+			}
+		}
+
+		page, err := service.QueryOrders(ctx, userID, services.OrderHistoryQuery{
+			Pair:   query.Get("pair"),
+			Side:   query.Get("side"),
+			Status: query.Get("status"),
+			Start:  start,
+			End:    end,
+			Sort:   query.Get("sort"),
+			Limit:  limit,
+			Cursor: query.Get("cursor"),
+		})
+		if err != nil {
+			writeError(w, err)
 			return
 		}
 
-		orders, err := service.GetOrdersByUser(ctx, userID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(GetOrdersResponse{Orders: page.Orders, NextCursor: page.NextCursor})
+	}
+}
+
+// GetOrderHandler handles GET /api/orders/{id} for the authenticated user
+func GetOrderHandler(service services.OrderBookService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		userID, _ := auth.UserFromContext(ctx)
+
+		orderID, err := strconv.ParseInt(mux.Vars(request)["id"], 10, 64)
+		if err != nil {
+			writeError(w, apperrors.ErrOrderNotFound)
+			return
+		}
+
+		order, err := service.GetOrder(ctx, userID, orderID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(GetOrderResponse{Order: order})
+	}
+}
+
+// GetOrderByClientIDHandler handles GET /api/orders/client/{client_order_id} for the authenticated user
+func GetOrderByClientIDHandler(service services.OrderBookService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+		userID, _ := auth.UserFromContext(ctx)
+
+		order, err := service.GetOrderByClientID(ctx, userID, mux.Vars(request)["client_order_id"])
 		if err != nil {
-			log.Printf("Failed to get orders: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(GetOrdersResponse{Error: err.Error()})
+			writeError(w, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(GetOrdersResponse{Orders: orders})
+		json.NewEncoder(w).Encode(GetOrderResponse{Order: order})
 	}
 }
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type OrderLimitsResponse struct {
+	Data interface{} `json:"data,omitempty"`
+}
+
+// OrderLimitsHandler handles GET, PUT and DELETE /api/admin/users/{user_id}/limits
+func OrderLimitsHandler(service services.OrderLimitsService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		userID, err := strconv.ParseInt(mux.Vars(request)["user_id"], 10, 64)
+		if err != nil {
+			writeFieldError(w, apperrors.ErrInvalidUserID, "user_id")
+			return
+		}
+
+		var data *services.UserOrderLimits
+		switch request.Method {
+		case http.MethodPut:
+			var limits engine.OrderLimits
+			if err := json.NewDecoder(request.Body).Decode(&limits); err != nil {
+				log.Printf("Failed to decode request: %v", err)
+				writeError(w, apperrors.ErrInvalidRequestBody)
+				return
+			}
+			data, err = service.SetUserLimits(ctx, userID, limits)
+		case http.MethodDelete:
+			data, err = service.ClearUserLimits(ctx, userID)
+		default:
+			data, err = service.GetUserLimits(ctx, userID)
+		}
+
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(OrderLimitsResponse{Data: data})
+	}
+}
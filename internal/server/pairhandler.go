@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"strings"
+
+	"log"
+)
+
+type CreatePairRequest struct {
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+	engine.MatchingConfig
+}
+
+type ListPairsResponse struct {
+	Data []*services.PairInfo `json:"data"`
+}
+
+// CreatePairHandler handles POST /api/pairs
+func CreatePairHandler(service services.PairService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		var req CreatePairRequest
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			log.Printf("Failed to decode request: %v", err)
+			writeError(w, apperrors.ErrInvalidRequestBody)
+			return
+		}
+
+		if strings.TrimSpace(req.Base) == "" {
+			writeFieldError(w, apperrors.ErrMissingParameter, "base")
+			return
+		}
+		if strings.TrimSpace(req.Quote) == "" {
+			writeFieldError(w, apperrors.ErrMissingParameter, "quote")
+			return
+		}
+
+		pair, err := service.CreatePair(ctx, req.Base, req.Quote, req.MatchingConfig)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(pair)
+	}
+}
+
+// ListPairsHandler handles GET /api/pairs
+func ListPairsHandler(service services.PairService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		pairs, err := service.ListPairs(ctx)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(ListPairsResponse{Data: pairs})
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+)
+
+type SetPairStatusRequest struct {
+	Pair   string `json:"pair"`
+	Status string `json:"status"`
+}
+
+type PairStatusResponse struct {
+	Data interface{} `json:"data,omitempty"`
+}
+
+// GetPairStatusHandler handles GET /api/pairs/status
+func GetPairStatusHandler(service services.PairStatusService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		pair := request.URL.Query().Get("pair")
+		if pair == "" {
+			writeFieldError(w, apperrors.ErrMissingParameter, "pair")
+			return
+		}
+
+		data, err := service.GetPairStatus(ctx, pair)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(PairStatusResponse{Data: data})
+	}
+}
+
+// SetPairStatusHandler handles POST /api/admin/pairs/status
+func SetPairStatusHandler(service services.PairStatusService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		var req SetPairStatusRequest
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			log.Printf("Failed to decode request: %v", err)
+			writeError(w, apperrors.ErrInvalidRequestBody)
+			return
+		}
+
+		data, err := service.SetPairStatus(ctx, req.Pair, models.PairStatus(req.Status))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(PairStatusResponse{Data: data})
+	}
+}
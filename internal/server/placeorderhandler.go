@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
 	"mini-crypto-exchange/internal/services"
 	"mini-crypto-exchange/internal/util"
 	"net/http"
@@ -11,55 +12,55 @@ import (
 )
 
 type PlaceOrderRequest struct {
-	UserID   int64   `json:"user_id"`
-	Pair     string  `json:"pair"`
-	Side     string  `json:"side"`
-	Price    float64 `json:"price"`
-	Quantity float64 `json:"quantity"`
+	Pair          string  `json:"pair"`
+	Side          string  `json:"side"`
+	Price         float64 `json:"price"`
+	Quantity      float64 `json:"quantity"`
+	ClientOrderID string  `json:"client_order_id,omitempty"`
 }
 
 type PlaceOrderResponse struct {
 	Order  interface{} `json:"order,omitempty"`
 	Trades interface{} `json:"trades,omitempty"`
-	Error  string      `json:"error,omitempty"`
+	Error  *ErrorBody  `json:"error,omitempty"`
 }
 
 // PlaceOrderHandler handles POST /api/orders
 func PlaceOrderHandler(service services.PlaceOrderService, config *util.RouterConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, request *http.Request) {
 		ctx := request.Context()
+		userID, _ := auth.UserFromContext(ctx)
 
 		var req PlaceOrderRequest
 		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
 			log.Printf("Failed to decode request: %v", err)
-
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(PlaceOrderResponse{Error: "Invalid request body"})
+			writeError(w, apperrors.ErrInvalidRequestBody)
 			return
 		}
 
 		// Validate request
-		validationErrors := service.ValidateRequest(ctx, req.UserID, req.Pair, req.Side, req.Price, req.Quantity)
+		validationErrors := service.ValidateRequest(ctx, userID, req.Pair, req.Side, req.Price, req.Quantity, req.ClientOrderID)
 		if len(validationErrors) > 0 {
-			log.Println("Validation failed")
-
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(PlaceOrderResponse{Error: "Validation failed"})
+			writeValidationErrors(w, validationErrors)
 			return
 		}
 
 		// Process request
-		order, trades, err := service.ProcessRequest(ctx, req.UserID, req.Pair, req.Side, req.Price, req.Quantity)
+		order, trades, err := service.ProcessRequest(ctx, userID, req.Pair, req.Side, req.Price, req.Quantity, req.ClientOrderID)
 		if err != nil {
 			log.Printf("Failed to place order: %v", err)
 
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader( err.(*apperrors.ServerError).HTTPResponseCode)
-			json.NewEncoder(w).Encode(PlaceOrderResponse{Error: err.Error()})
+			// A retried request gets the order its client order ID already refers to
+			body, status := newErrorBody(err, "")
+			response := PlaceOrderResponse{Error: body}
+			if err == apperrors.ErrDuplicateClientOrderID {
+				response.Order = order
+			}
+
+			writeErrorResponse(w, status, err, response)
 			return
 		}
+		trackSessionOrders(ctx, config, order)
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"log"
+	"math"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/ratelimit"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Rate limit response headers
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// RequestWeight returns how many tokens a request costs
+type RequestWeight func(request *http.Request) int
+
+type RateLimitErrorResponse struct {
+	Error      *ErrorBody `json:"error,omitempty"`
+	RetryAfter int        `json:"retry_after,omitempty"` // seconds
+}
+
+// Weight charges a fixed number of tokens per request
+func Weight(tokens int) RequestWeight {
+	return func(request *http.Request) int {
+		return tokens
+	}
+}
+
+// OrderBookWeight charges more for deeper order book snapshots
+func OrderBookWeight(request *http.Request) int {
+	depth, err := strconv.Atoi(request.URL.Query().Get("depth"))
+	switch {
+	case err != nil || depth <= 50:
+		return 1
+	case depth <= 500:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// RateLimitMiddleware charges the request's weight to the client IP's bucket before running the
+// authenticate middlewares, so unauthenticated and badly signed requests are limited too, and then,
+// for authenticated requests, to the user's bucket. A request rejected with 429 by the user's
+// bucket gets its IP tokens back, so it costs neither. Headers describe the bucket closest to its limit.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, weight RequestWeight, config *util.RouterConfig, authenticate ...mux.MiddlewareFunc) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		limited := http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+			result := ipRateLimitFromContext(request.Context())
+			if userID, ok := auth.UserFromContext(request.Context()); ok {
+				taken := limiter.Take(ratelimit.UserKey(userID), result.cost)
+				if !taken.Allowed {
+					limiter.Refund(result.key, result.cost)
+					writeRateLimited(w, request, taken)
+					return
+				}
+				if taken.Remaining < result.Remaining {
+					result.Result = taken
+				}
+			}
+
+			writeRateLimitHeaders(w, result.Result)
+			next.ServeHTTP(w, request)
+		})
+
+		var handler http.Handler = limited
+		for i := len(authenticate) - 1; i >= 0; i-- {
+			handler = authenticate[i](handler)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodOptions {
+				next.ServeHTTP(w, request)
+				return
+			}
+
+			cost := weight(request)
+			key := ratelimit.IPKey(auth.RemoteIP(request.RemoteAddr))
+			taken := limiter.Take(key, cost)
+			if !taken.Allowed {
+				writeRateLimited(w, request, taken)
+				return
+			}
+
+			ctx := withIPRateLimit(request.Context(), ipRateLimit{Result: taken, key: key, cost: cost})
+			handler.ServeHTTP(w, request.WithContext(ctx))
+		})
+	}
+}
+
+// ipRateLimit is the IP bucket charge of a request, carried past authentication
+type ipRateLimit struct {
+	ratelimit.Result
+	key  string
+	cost int
+}
+
+type ipRateLimitContextKey struct{}
+
+func withIPRateLimit(ctx context.Context, charge ipRateLimit) context.Context {
+	return context.WithValue(ctx, ipRateLimitContextKey{}, charge)
+}
+
+func ipRateLimitFromContext(ctx context.Context) ipRateLimit {
+	charge, _ := ctx.Value(ipRateLimitContextKey{}).(ipRateLimit)
+	return charge
+}
+
+func writeRateLimitHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+	w.Header().Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+	w.Header().Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+func writeRateLimited(w http.ResponseWriter, request *http.Request, result ratelimit.Result) {
+	writeRateLimitHeaders(w, result)
+	retryAfter := ceilSeconds(result.RetryAfter)
+	log.Printf("Rate limited %s %s, retry after %ds", request.Method, request.URL.Path, retryAfter)
+	w.Header().Set(HeaderRetryAfter, strconv.Itoa(retryAfter))
+	body, status := newErrorBody(apperrors.ErrRateLimited, "")
+	writeErrorResponse(w, status, apperrors.ErrRateLimited, RateLimitErrorResponse{Error: body, RetryAfter: retryAfter})
+}
+
+// ceilSeconds rounds a duration up to whole seconds, at least 1 for any positive duration
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
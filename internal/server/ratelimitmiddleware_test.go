@@ -0,0 +1,72 @@
+package server
+
+import (
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/ratelimit"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newRateLimitedHandler(limiter *ratelimit.Limiter, authenticator *auth.Authenticator) http.Handler {
+	config := &util.RouterConfig{}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return RateLimitMiddleware(limiter, Weight(1), config, AuthMiddleware(authenticator, config))(ok)
+}
+
+func signedRequest(apiKey *auth.APIKey, nonce string) *http.Request {
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/keys", nil)
+	request.RemoteAddr = "203.0.113.7:1234"
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	request.Header.Set(auth.HeaderAPIKey, apiKey.Key)
+	request.Header.Set(auth.HeaderTimestamp, timestamp)
+	request.Header.Set(auth.HeaderNonce, nonce)
+	request.Header.Set(auth.HeaderSignature, auth.Sign(apiKey.Secret, timestamp, nonce, request.Method, request.URL.RequestURI(), nil))
+	return request
+}
+
+func serve(handler http.Handler, request *http.Request) int {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func TestRateLimitChargesIPBeforeAuthentication(t *testing.T) {
+	keys := auth.NewKeyStore()
+	apiKey, _ := keys.Issue(1, auth.DefaultPermissions, nil)
+	handler := newRateLimitedHandler(ratelimit.NewLimiter(2, 0.001), auth.NewAuthenticator(keys))
+
+	// Badly signed requests are rejected by authentication but still drain the IP's bucket
+	for i := 0; i < 2; i++ {
+		request := signedRequest(apiKey, "bad-"+strconv.Itoa(i))
+		request.Header.Set(auth.HeaderSignature, "00")
+		if status := serve(handler, request); status != http.StatusUnauthorized {
+			t.Fatalf("bad signature %d: got %d, want 401", i, status)
+		}
+	}
+	if status := serve(handler, signedRequest(apiKey, "good")); status != http.StatusTooManyRequests {
+		t.Fatalf("after the IP bucket is empty: got %d, want 429", status)
+	}
+}
+
+func TestRateLimitUserRejectionRefundsIP(t *testing.T) {
+	keys := auth.NewKeyStore()
+	apiKey, _ := keys.Issue(1, auth.DefaultPermissions, nil)
+	limiter := ratelimit.NewLimiter(2, 0.001)
+	handler := newRateLimitedHandler(limiter, auth.NewAuthenticator(keys))
+
+	limiter.Take(ratelimit.UserKey(apiKey.UserID), 2)
+	if status := serve(handler, signedRequest(apiKey, "n1")); status != http.StatusTooManyRequests {
+		t.Fatalf("empty user bucket: got %d, want 429", status)
+	}
+
+	// The rejected request did not spend the IP's tokens
+	if result := limiter.Take(ratelimit.IPKey(auth.RemoteIP("203.0.113.7:1234")), 2); !result.Allowed {
+		t.Fatalf("IP bucket was charged for a request the user bucket rejected: %+v", result)
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+)
+
+type SetPairRiskRequest struct {
+	Pair string `json:"pair"`
+	engine.RiskConfig
+	IndexPrice float64 `json:"index_price"`
+}
+
+type RiskResponse struct {
+	Data interface{} `json:"data,omitempty"`
+}
+
+// GetPairRiskHandler handles GET /api/pairs/risk
+func GetPairRiskHandler(service services.RiskService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		pair := request.URL.Query().Get("pair")
+		if pair == "" {
+			writeFieldError(w, apperrors.ErrMissingParameter, "pair")
+			return
+		}
+
+		data, err := service.GetPairRisk(ctx, pair)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(RiskResponse{Data: data})
+	}
+}
+
+// SetPairRiskHandler handles PUT /api/admin/pairs/risk
+func SetPairRiskHandler(service services.RiskService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		var req SetPairRiskRequest
+		if err := json.NewDecoder(request.Body).Decode(&req); err != nil {
+			log.Printf("Failed to decode request: %v", err)
+			writeError(w, apperrors.ErrInvalidRequestBody)
+			return
+		}
+
+		data, err := service.SetPairRisk(ctx, req.Pair, req.RiskConfig, req.IndexPrice)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(RiskResponse{Data: data})
+	}
+}
+
+// CircuitBreakersHandler handles GET /api/circuit-breakers
+func CircuitBreakersHandler(service services.RiskService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		events, err := service.GetCircuitBreakerEvents(ctx, request.URL.Query().Get("pair"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(RiskResponse{Data: events})
+	}
+}
@@ -1,7 +1,12 @@
 package server
 
 import (
-	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/audit"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/idempotency"
+	"mini-crypto-exchange/internal/marketdata"
+	"mini-crypto-exchange/internal/ratelimit"
 	"mini-crypto-exchange/internal/services"
 	"mini-crypto-exchange/internal/util"
 	"net/http"
@@ -9,10 +14,34 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// API path prefixes. Every route is served under the versioned prefix and, for existing clients,
+// under the unversioned legacy prefix.
+const (
+	APIPrefix       = "/api/v1"
+	LegacyAPIPrefix = "/api"
+)
+
 type Router struct {
 	*mux.Router
 }
 
+// apiRoute is a route mounted under both the versioned and the legacy prefix
+type apiRoute []*mux.Route
+
+func (routes apiRoute) Methods(methods ...string) apiRoute {
+	for _, route := range routes {
+		route.Methods(methods...)
+	}
+	return routes
+}
+
+// Name names the versioned route; its legacy alias gets the name with a Legacy prefix
+func (routes apiRoute) Name(name string) apiRoute {
+	routes[0].Name(name)
+	routes[1].Name("Legacy" + name)
+	return routes
+}
+
 // NewRouter ...
 func NewRouter() *Router {
 	return &Router{mux.NewRouter()}
@@ -21,30 +50,208 @@ func NewRouter() *Router {
 // InitializeRouter ...
 func (r *Router) InitializeRouter(routerConfig *util.RouterConfig) {
 	r.initializeRoutes(routerConfig)
+
+	// Unknown routes and methods answer with the same error envelope as the handlers
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		writeError(w, apperrors.ErrRouteNotFound)
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		writeError(w, apperrors.ErrMethodNotAllowed)
+	})
 }
 
 // initializeRoutes ...
 func (r *Router) initializeRoutes(routerConfig *util.RouterConfig) {
 	s := (*r).PathPrefix("").Subrouter()
 
+	// handle mounts one handler under both prefixes, so aliases share their handler's state
+	handle := func(path string, handler http.Handler) apiRoute {
+		return apiRoute{s.Handle(APIPrefix+path, handler), s.Handle(LegacyAPIPrefix+path, handler)}
+	}
+
+	authenticator := routerConfig.Authenticator.(*auth.Authenticator)
+	// Each route lists the API key permissions it requires; key management needs a valid key only
+	authenticated := func(permissions ...auth.Permission) mux.MiddlewareFunc {
+		return AuthMiddleware(authenticator, routerConfig, permissions...)
+	}
+	optionallyAuthenticated := OptionalAuthMiddleware(authenticator, routerConfig)
+
+	// Admin routes require the admin permission and are recorded in the audit log under their action name
+	auditLog := routerConfig.AuditLog.(*audit.Log)
+	admin := func(action string) mux.MiddlewareFunc {
+		return AdminMiddleware(authenticator, auditLog, action, routerConfig)
+	}
+
+	// Mutating routes replay the stored response to retries that carry the same Idempotency-Key
+	idempotent := IdempotencyMiddleware(routerConfig.IdempotencyStore.(*idempotency.Store), routerConfig)
+
+	// Order entry and queries draw from separate token buckets, per user once authenticated and per IP
+	limiters := routerConfig.RateLimiters.(*ratelimit.Limiters)
+	// The IP is charged before the given authentication runs, the user after it
+	orderLimited := func(weight RequestWeight, authenticate ...mux.MiddlewareFunc) mux.MiddlewareFunc {
+		return RateLimitMiddleware(limiters.Orders, weight, routerConfig, authenticate...)
+	}
+	queryLimited := func(weight RequestWeight, authenticate ...mux.MiddlewareFunc) mux.MiddlewareFunc {
+		return RateLimitMiddleware(limiters.Queries, weight, routerConfig, authenticate...)
+	}
+
+	// API key routes. Issued keys carry their secret, so their responses are never stored for replay.
+	handle("/keys",
+		queryLimited(Weight(1), optionallyAuthenticated)(IssueAPIKeyHandler(services.GetAPIKeyService(), routerConfig))).
+		Methods(http.MethodOptions, http.MethodPost).
+		Name("IssueAPIKeyAPI")
+
+	handle("/keys",
+		queryLimited(Weight(1), authenticated())(ListAPIKeysHandler(services.GetAPIKeyService(), routerConfig))).
+		Methods(http.MethodGet).
+		Name("ListAPIKeysAPI")
+
+	handle("/keys/{key}",
+		queryLimited(Weight(1), authenticated())(idempotent(RevokeAPIKeyHandler(services.GetAPIKeyService(), routerConfig)))).
+		Methods(http.MethodOptions, http.MethodDelete).
+		Name("RevokeAPIKeyAPI")
+
+	// Admin routes
+	handle("/admin/audit",
+		queryLimited(Weight(1), authenticated(auth.PermissionAdmin))(AuditLogHandler(auditLog, routerConfig))).
+		Methods(http.MethodGet).
+		Name("AuditLogAPI")
+
+	handle("/admin/users/{user_id}/limits",
+		queryLimited(Weight(1), authenticated(auth.PermissionAdmin))(OrderLimitsHandler(services.GetOrderLimitsService(), routerConfig))).
+		Methods(http.MethodGet).
+		Name("GetOrderLimitsAPI")
+
+	handle("/admin/users/{user_id}/limits",
+		queryLimited(Weight(1), admin("set_user_limits"))(idempotent(OrderLimitsHandler(services.GetOrderLimitsService(), routerConfig)))).
+		Methods(http.MethodOptions, http.MethodPut).
+		Name("SetOrderLimitsAPI")
+
+	handle("/admin/users/{user_id}/limits",
+		queryLimited(Weight(1), admin("clear_user_limits"))(idempotent(OrderLimitsHandler(services.GetOrderLimitsService(), routerConfig)))).
+		Methods(http.MethodDelete).
+		Name("ClearOrderLimitsAPI")
+
+	handle("/admin/assets",
+		queryLimited(Weight(1), admin("register_asset"))(idempotent(RegisterAssetHandler(services.GetAssetService(), routerConfig)))).
+		Methods(http.MethodOptions, http.MethodPut).
+		Name("RegisterAssetAPI")
+
+	handle("/admin/pairs/status",
+		queryLimited(Weight(1), admin("set_pair_status"))(idempotent(SetPairStatusHandler(services.GetPairStatusService(), routerConfig)))).
+		Methods(http.MethodOptions, http.MethodPost).
+		Name("SetPairStatusAPI")
+
+	handle("/admin/pairs/risk",
+		queryLimited(Weight(1), admin("set_pair_risk"))(idempotent(SetPairRiskHandler(services.GetRiskService(), routerConfig)))).
+		Methods(http.MethodOptions, http.MethodPut).
+		Name("SetPairRiskAPI")
+
+	handle("/admin/pairs/auction",
+		queryLimited(Weight(1), admin("start_auction"))(idempotent(StartAuctionHandler(services.GetAuctionService(), routerConfig)))).
+		Methods(http.MethodOptions, http.MethodPost).
+		Name("StartAuctionAPI")
+
 	// Order matching routes
-	s.HandleFunc("/api/pairs",
-		CreatePairHandler(routerConfig.MatchingEngine.(*engine.MatchingEngine), routerConfig)).
+	handle("/assets",
+		queryLimited(Weight(1))(ListAssetsHandler(services.GetAssetService(), routerConfig))).
+		Methods(http.MethodOptions, http.MethodGet).
+		Name("ListAssetsAPI")
+
+	handle("/pairs",
+		queryLimited(Weight(1), admin("create_pair"))(idempotent(CreatePairHandler(services.GetPairService(), routerConfig)))).
 		Methods(http.MethodOptions, http.MethodPost).
 		Name("CreatePairAPI")
 
-	s.HandleFunc("/api/orders",
-		PlaceOrderHandler(services.GetPlaceOrderService(), routerConfig)).
+	handle("/pairs",
+		queryLimited(Weight(1))(ListPairsHandler(services.GetPairService(), routerConfig))).
+		Methods(http.MethodGet).
+		Name("ListPairsAPI")
+
+	handle("/pairs/status",
+		queryLimited(Weight(1))(GetPairStatusHandler(services.GetPairStatusService(), routerConfig))).
+		Methods(http.MethodOptions, http.MethodGet).
+		Name("GetPairStatusAPI")
+
+	handle("/pairs/risk",
+		queryLimited(Weight(1))(GetPairRiskHandler(services.GetRiskService(), routerConfig))).
+		Methods(http.MethodOptions, http.MethodGet).
+		Name("GetPairRiskAPI")
+
+	handle("/pairs/auction",
+		queryLimited(Weight(1))(GetAuctionHandler(services.GetAuctionService(), routerConfig))).
+		Methods(http.MethodOptions, http.MethodGet).
+		Name("GetAuctionAPI")
+
+	handle("/circuit-breakers",
+		queryLimited(Weight(1))(CircuitBreakersHandler(services.GetRiskService(), routerConfig))).
+		Methods(http.MethodOptions, http.MethodGet).
+		Name("CircuitBreakersAPI")
+
+	handle("/orders",
+		orderLimited(Weight(1), authenticated(auth.PermissionTrade))(idempotent(PlaceOrderHandler(services.GetPlaceOrderService(), routerConfig)))).
 		Methods(http.MethodOptions, http.MethodPost).
 		Name("PlaceOrderAPI")
 
-	s.HandleFunc("/api/orders",
-		GetOrdersHandler(services.GetOrderBookService(), routerConfig)).
+	handle("/orders",
+		queryLimited(Weight(5), authenticated(auth.PermissionRead))(GetOrdersHandler(services.GetOrderBookService(), routerConfig))).
 		Methods(http.MethodGet).
 		Name("GetOrdersAPI")
 
-	s.HandleFunc("/api/orderbook",
-		OrderBookHandler(services.GetOrderBookService(), routerConfig)).
+	handle("/orders",
+		orderLimited(Weight(1), authenticated(auth.PermissionTrade))(idempotent(CancelAllOrdersHandler(services.GetCancelOrderService(), routerConfig)))).
+		Methods(http.MethodDelete).
+		Name("CancelAllOrdersAPI")
+
+	handle("/orders/{id:[0-9]+}",
+		queryLimited(Weight(1), authenticated(auth.PermissionRead))(GetOrderHandler(services.GetOrderBookService(), routerConfig))).
+		Methods(http.MethodGet).
+		Name("GetOrderAPI")
+
+	handle("/orders/client/{client_order_id}",
+		queryLimited(Weight(1), authenticated(auth.PermissionRead))(GetOrderByClientIDHandler(services.GetOrderBookService(), routerConfig))).
+		Methods(http.MethodGet).
+		Name("GetOrderByClientIDAPI")
+
+	handle("/orders/client/{client_order_id}",
+		orderLimited(Weight(1), authenticated(auth.PermissionTrade))(idempotent(CancelOrderByClientIDHandler(services.GetCancelOrderService(), routerConfig)))).
+		Methods(http.MethodOptions, http.MethodDelete).
+		Name("CancelOrderByClientIDAPI")
+
+	handle("/orders/batch",
+		orderLimited(BatchOrderWeight, authenticated(auth.PermissionTrade))(idempotent(BatchOrderHandler(services.GetBatchOrderService(), routerConfig)))).
+		Methods(http.MethodOptions, http.MethodPost).
+		Name("BatchOrderAPI")
+
+	handle("/orders/cancel-all-after",
+		orderLimited(Weight(1), authenticated(auth.PermissionTrade))(idempotent(CancelAllAfterHandler(services.GetCancelOrderService(), routerConfig)))).
+		Methods(http.MethodOptions, http.MethodPost).
+		Name("CancelAllAfterAPI")
+
+	handle("/orderbook",
+		queryLimited(OrderBookWeight)(OrderBookHandler(services.GetOrderBookService(), routerConfig))).
 		Methods(http.MethodOptions, http.MethodGet).
 		Name("OrderBookAPI")
+
+	// Market data routes
+	handle("/candles",
+		queryLimited(Weight(2))(CandlesHandler(services.GetCandleService(), routerConfig))).
+		Methods(http.MethodOptions, http.MethodGet).
+		Name("CandlesAPI")
+
+	handle("/ticker",
+		queryLimited(Weight(1))(TickerHandler(services.GetTickerService(), routerConfig))).
+		Methods(http.MethodOptions, http.MethodGet).
+		Name("TickerAPI")
+
+	handle("/stream",
+		queryLimited(Weight(1), optionallyAuthenticated)(MarketDataStreamHandler(routerConfig.MarketDataHub.(*marketdata.Hub), routerConfig))).
+		Methods(http.MethodGet).
+		Name("MarketDataStreamAPI")
+
+	// API description, versioned only
+	s.Handle(APIPrefix+"/openapi.json",
+		queryLimited(Weight(1))(OpenAPIHandler(routerConfig))).
+		Methods(http.MethodOptions, http.MethodGet).
+		Name("OpenAPIAPI")
 }
@@ -2,7 +2,15 @@ package server
 
 import (
 	"log"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/audit"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/bingateway"
 	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/fix"
+	"mini-crypto-exchange/internal/idempotency"
+	"mini-crypto-exchange/internal/marketdata"
+	"mini-crypto-exchange/internal/ratelimit"
 	"mini-crypto-exchange/internal/services"
 	"mini-crypto-exchange/internal/util"
 	"net"
@@ -17,13 +25,84 @@ func RunServer() error {
 
 	// Initialize matching engine
 	matchingEngine := engine.NewMatchingEngine()
+	matchingEngine.SetDefaultLimits(engine.LimitsFromEnv())
+	matchingEngine.SetDefaultRiskConfig(engine.RiskConfigFromEnv())
+	assetRegistry := assets.NewRegistry(assets.ConfigFromEnv())
+
+	// Initialize market data
+	marketDataHub := marketdata.NewHub()
+	candleAggregator := marketdata.NewCandleAggregator(marketDataHub)
+	tickerTracker := marketdata.NewTickerTracker()
+	matchingEngine.AddTradeListener(candleAggregator)
+	matchingEngine.AddTradeListener(tickerTracker)
+	publisher := marketdata.NewPublisher(marketDataHub)
+	matchingEngine.AddTradeListener(publisher)
+	matchingEngine.AddOrderBookListener(publisher)
+	matchingEngine.AddAuctionListener(publisher)
+
+	// Initialize authentication
+	keyStore := auth.NewKeyStore()
+	authenticator := auth.NewAuthenticator(keyStore)
+	auditLog, err := audit.NewLog(audit.ConfigFromEnv())
+	if err != nil {
+		return err
+	}
+	defer auditLog.Close()
+
+	rateLimiters := ratelimit.NewLimiters(ratelimit.ConfigFromEnv())
+
 	routerConfigs := util.RouterConfig{
-		MatchingEngine: matchingEngine,
+		MatchingEngine:   matchingEngine,
+		MarketDataHub:    marketDataHub,
+		Authenticator:    authenticator,
+		AuditLog:         auditLog,
+		RateLimiters:     rateLimiters,
+		IdempotencyStore: idempotency.NewStore(idempotency.ConfigFromEnv()),
 	}
 
 	// Initialize services
 	services.InitPlaceOrderService(matchingEngine, &routerConfigs)
 	services.InitOrderBookService(matchingEngine, &routerConfigs)
+	services.InitCandleService(matchingEngine, candleAggregator, &routerConfigs)
+	services.InitTickerService(matchingEngine, tickerTracker, &routerConfigs)
+	services.InitAPIKeyService(authenticator, auth.ConfigFromEnv(), &routerConfigs)
+	services.InitOrderLimitsService(matchingEngine, &routerConfigs)
+	services.InitPairStatusService(matchingEngine, &routerConfigs)
+	services.InitRiskService(matchingEngine, &routerConfigs)
+	services.InitAuctionService(matchingEngine, &routerConfigs)
+	services.InitCancelOrderService(matchingEngine, &routerConfigs)
+	services.InitBatchOrderService(services.GetPlaceOrderService(), matchingEngine, &routerConfigs)
+	services.InitAssetService(assetRegistry, matchingEngine, &routerConfigs)
+	services.InitPairService(matchingEngine, assetRegistry, &routerConfigs)
+
+	// Orders placed over REST and gRPC join their key's cancel-on-disconnect stream session
+	routerConfigs.StreamSessions = newCancelOnDisconnect(matchingEngine)
+
+	// Start FIX gateway when sessions are configured
+	fixConfig, err := fix.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if len(fixConfig.Sessions) > 0 {
+		fixAcceptor := fix.NewAcceptor(fixConfig, matchingEngine, services.GetPlaceOrderService(), services.GetAPIKeyService(), rateLimiters)
+		matchingEngine.AddTradeListener(fixAcceptor)
+		matchingEngine.AddOrderCancelListener(fixAcceptor)
+		if err := fixAcceptor.Start(); err != nil {
+			return err
+		}
+		defer fixAcceptor.Stop()
+	}
+
+	// Start binary order entry gateway when a port is configured
+	if binaryConfig := bingateway.ConfigFromEnv(); binaryConfig.Enabled() {
+		binaryGateway := bingateway.NewGateway(binaryConfig, matchingEngine, services.GetPlaceOrderService(), services.GetAPIKeyService(), rateLimiters)
+		matchingEngine.AddTradeListener(binaryGateway)
+		matchingEngine.AddOrderCancelListener(binaryGateway)
+		if err := binaryGateway.Start(); err != nil {
+			return err
+		}
+		defer binaryGateway.Stop()
+	}
 
 	// Setup router
 	router := NewRouter()
@@ -44,8 +123,15 @@ func RunServer() error {
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
+	grpcServer := NewGRPCServer(&routerConfigs)
+
+	// Match gRPC (HTTP/2) first, everything else falls through to HTTP/1
+	grpcListener := mux.Match(cmux.HTTP2())
+	httpListener := mux.Match(cmux.HTTP1())
+
 	// Start servers
-	go httpServer.Serve(mux.Match(cmux.HTTP1()))
+	go grpcServer.Serve(grpcListener)
+	go httpServer.Serve(httpListener)
 
 	log.Printf("Starting HTTP and gRPC server on port %s", port)
 	return mux.Serve()
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"encoding/json"
+	"mini-crypto-exchange/internal/services"
+	"mini-crypto-exchange/internal/util"
+	"net/http"
+)
+
+type TickerResponse struct {
+	Data interface{} `json:"data,omitempty"`
+}
+
+// TickerHandler handles GET /api/ticker and GET /api/ticker?pair=X
+func TickerHandler(service services.TickerService, config *util.RouterConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		var data interface{}
+		var err error
+		if pair := request.URL.Query().Get("pair"); pair != "" {
+			data, err = service.GetTicker(ctx, pair)
+		} else {
+			data, err = service.GetTickers(ctx)
+		}
+
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(TickerResponse{Data: data})
+	}
+}
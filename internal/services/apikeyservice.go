@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"mini-crypto-exchange/internal/util"
+	"net"
+	"sync"
+
+	"log"
+)
+
+// APIKeyService defines the interface for managing API keys
+type APIKeyService interface {
+	IssueKey(ctx context.Context, userID int64, permissions []string, allowedIPs []string, bootstrapSecret string) (*auth.APIKey, error)
+	ListKeys(ctx context.Context) ([]auth.APIKey, error)
+	RevokeKey(ctx context.Context, key string) error
+	Authenticate(ctx context.Context, credentials auth.Credentials, method string, path string, body []byte, ip net.IP, permissions ...auth.Permission) (*auth.APIKey, error)
+}
+
+var apiKeySvcStruct APIKeyService
+var apiKeyServiceOnce sync.Once
+
+type apiKeyService struct {
+	authenticator *auth.Authenticator
+	keys          *auth.KeyStore
+	authConfig    auth.Config
+	config        *util.RouterConfig
+
+	// issueMu makes the first-key check and issuance atomic
+	issueMu sync.Mutex
+}
+
+// InitAPIKeyService initializes the API key service
+func InitAPIKeyService(authenticator *auth.Authenticator, authConfig auth.Config, config *util.RouterConfig) APIKeyService {
+	apiKeyServiceOnce.Do(func() {
+		apiKeySvcStruct = &apiKeyService{
+			authenticator: authenticator,
+			keys:          authenticator.Keys(),
+			authConfig:    authConfig,
+			config:        config,
+		}
+	})
+	return apiKeySvcStruct
+}
+
+// GetAPIKeyService returns the singleton instance
+func GetAPIKeyService() APIKeyService {
+	if apiKeySvcStruct == nil {
+		panic("APIKeyService not initialized")
+	}
+	return apiKeySvcStruct
+}
+
+// IssueKey issues a key for userID. A signed request issues keys for the signing key's user and may
+// only grant permissions that key has; a key with the admin permission may issue keys with any
+// permissions for any user. Keys issued for the signing key's own user cannot be used from IPs
+// outside its allowlist. Unsigned requests must carry the configured bootstrap secret and can
+// only issue a user's first key, with the admin permission only for configured admin users.
+func (s *apiKeyService) IssueKey(ctx context.Context, userID int64, permissionNames []string, allowedIPs []string, bootstrapSecret string) (*auth.APIKey, error) {
+	signingKey, authenticated := auth.APIKeyFromContext(ctx)
+	if !authenticated && !s.validBootstrapSecret(bootstrapSecret) {
+		log.Printf("Unsigned key issuance for user %d without a valid bootstrap secret", userID)
+		return nil, apperrors.ErrInvalidBootstrapSecret
+	}
+
+	admin := authenticated && signingKey.HasPermission(auth.PermissionAdmin)
+	if authenticated && (!admin || userID == 0) {
+		userID = signingKey.UserID
+	}
+
+	if userID <= 0 {
+		log.Println("Invalid user ID")
+		return nil, apperrors.ErrInvalidUserID
+	}
+
+	permissions, err := auth.ParsePermissions(permissionNames)
+	if err != nil {
+		return nil, err
+	}
+	if len(permissions) == 0 {
+		permissions = auth.DefaultPermissions
+	}
+	allowed, err := auth.ParseAllowedIPs(allowedIPs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permission := range permissions {
+		if authenticated && !admin && !signingKey.HasPermission(permission) {
+			log.Printf("User %d cannot grant %s with key %s", userID, permission, signingKey.Key)
+			return nil, apperrors.ErrPermissionEscalation
+		}
+		if !authenticated && permission == auth.PermissionAdmin && !s.authConfig.AdminUserIDs[userID] {
+			log.Printf("User %d is not allowed admin keys", userID)
+			return nil, apperrors.ErrPermissionEscalation
+		}
+	}
+
+	if authenticated && userID == signingKey.UserID && !signingKey.AllowsIPs(allowed) {
+		log.Printf("Key %s cannot issue a key allowing %v", signingKey.Key, allowed)
+		return nil, apperrors.ErrAllowlistEscalation
+	}
+
+	s.issueMu.Lock()
+	defer s.issueMu.Unlock()
+
+	if !authenticated && s.keys.HasKeys(userID) {
+		log.Printf("Unsigned key issuance refused for user %d", userID)
+		return nil, apperrors.ErrKeyIssuanceForbidden
+	}
+
+	return s.keys.Issue(userID, permissions, allowed)
+}
+
+// validBootstrapSecret compares secret with the configured bootstrap secret in constant time.
+// Unsigned issuance is disabled when no bootstrap secret is configured.
+func (s *apiKeyService) validBootstrapSecret(secret string) bool {
+	if s.authConfig.BootstrapSecret == "" || secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(s.authConfig.BootstrapSecret)) == 1
+}
+
+// ListKeys returns the authenticated user's keys without secrets
+func (s *apiKeyService) ListKeys(ctx context.Context) ([]auth.APIKey, error) {
+	userID, _ := auth.UserFromContext(ctx)
+	return s.keys.List(userID), nil
+}
+
+// RevokeKey deletes one of the authenticated user's keys. A key can only revoke keys whose
+// permissions it also has, so a read-only key cannot disable a trading key.
+func (s *apiKeyService) RevokeKey(ctx context.Context, key string) error {
+	signingKey, _ := auth.APIKeyFromContext(ctx)
+
+	target, exists := s.keys.Get(key)
+	if !exists || target.UserID != signingKey.UserID {
+		log.Printf("API key %s not found for user %d", key, signingKey.UserID)
+		return apperrors.ErrAPIKeyNotFound
+	}
+	for _, permission := range target.Permissions {
+		if !signingKey.HasPermission(permission) {
+			log.Printf("Key %s cannot revoke key %s", signingKey.Key, key)
+			return apperrors.ErrPermissionEscalation
+		}
+	}
+
+	if !s.keys.Revoke(signingKey.UserID, key) {
+		return apperrors.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate verifies signed credentials for a request from ip and checks the key's allowlist and
+// permissions. It backs the order entry gateways that do not carry HTTP headers.
+func (s *apiKeyService) Authenticate(ctx context.Context, credentials auth.Credentials, method string, path string, body []byte, ip net.IP, permissions ...auth.Permission) (*auth.APIKey, error) {
+	apiKey, err := s.authenticator.Verify(credentials.APIKey, credentials.Timestamp, credentials.Nonce, credentials.Signature, method, path, body)
+	if err != nil {
+		log.Printf("Authentication failed: %v", err)
+		return nil, err
+	}
+	if err := apiKey.Authorize(ip, permissions...); err != nil {
+		log.Printf("Key %s not authorized: %v", apiKey.Key, err)
+		return nil, err
+	}
+	return apiKey, nil
+}
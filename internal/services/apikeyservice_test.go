@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/auth"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testBootstrapSecret = "bootstrap"
+
+func newTestAPIKeyService(bootstrapSecret string) *apiKeyService {
+	authenticator := auth.NewAuthenticator(auth.NewKeyStore())
+	return &apiKeyService{
+		authenticator: authenticator,
+		keys:          authenticator.Keys(),
+		authConfig:    auth.Config{BootstrapSecret: bootstrapSecret, AdminUserIDs: map[int64]bool{1: true}},
+	}
+}
+
+func TestIssueKeyUnsignedRequiresBootstrapSecret(t *testing.T) {
+	s := newTestAPIKeyService(testBootstrapSecret)
+	ctx := context.Background()
+
+	if _, err := s.IssueKey(ctx, 2, nil, nil, ""); err != apperrors.ErrInvalidBootstrapSecret {
+		t.Fatalf("without secret: got %v, want %v", err, apperrors.ErrInvalidBootstrapSecret)
+	}
+	if _, err := s.IssueKey(ctx, 2, nil, nil, "wrong"); err != apperrors.ErrInvalidBootstrapSecret {
+		t.Fatalf("wrong secret: got %v, want %v", err, apperrors.ErrInvalidBootstrapSecret)
+	}
+
+	key, err := s.IssueKey(ctx, 2, nil, nil, testBootstrapSecret)
+	if err != nil {
+		t.Fatalf("first key: %v", err)
+	}
+	if key.UserID != 2 || key.HasPermission(auth.PermissionAdmin) {
+		t.Fatalf("unexpected first key %+v", key)
+	}
+
+	if _, err := s.IssueKey(ctx, 2, nil, nil, testBootstrapSecret); err != apperrors.ErrKeyIssuanceForbidden {
+		t.Fatalf("second unsigned key: got %v, want %v", err, apperrors.ErrKeyIssuanceForbidden)
+	}
+}
+
+func TestIssueKeyUnsignedDisabledWithoutBootstrapSecret(t *testing.T) {
+	s := newTestAPIKeyService("")
+
+	if _, err := s.IssueKey(context.Background(), 2, nil, nil, ""); err != apperrors.ErrInvalidBootstrapSecret {
+		t.Fatalf("got %v, want %v", err, apperrors.ErrInvalidBootstrapSecret)
+	}
+}
+
+func TestIssueKeyUnsignedAdminOnlyForAdminUsers(t *testing.T) {
+	s := newTestAPIKeyService(testBootstrapSecret)
+	ctx := context.Background()
+	admin := []string{"read", "admin"}
+
+	if _, err := s.IssueKey(ctx, 2, admin, nil, testBootstrapSecret); err != apperrors.ErrPermissionEscalation {
+		t.Fatalf("non-admin user: got %v, want %v", err, apperrors.ErrPermissionEscalation)
+	}
+	if _, err := s.IssueKey(ctx, 1, admin, nil, testBootstrapSecret); err != nil {
+		t.Fatalf("admin user: %v", err)
+	}
+}
+
+func TestIssueKeySigned(t *testing.T) {
+	s := newTestAPIKeyService("")
+	adminKey, _ := s.keys.Issue(1, []auth.Permission{auth.PermissionAdmin}, nil)
+	traderKey, _ := s.keys.Issue(2, []auth.Permission{auth.PermissionRead, auth.PermissionTrade}, nil)
+
+	// An admin key issues first keys for other users
+	adminCtx := auth.WithAPIKey(context.Background(), adminKey)
+	key, err := s.IssueKey(adminCtx, 3, []string{"read", "trade"}, nil, "")
+	if err != nil {
+		t.Fatalf("admin issuing for user 3: %v", err)
+	}
+	if key.UserID != 3 {
+		t.Fatalf("admin issued key for user %d, want 3", key.UserID)
+	}
+
+	// Other keys issue keys for their own user only, with permissions they have
+	traderCtx := auth.WithAPIKey(context.Background(), traderKey)
+	key, err = s.IssueKey(traderCtx, 3, []string{"read"}, nil, "")
+	if err != nil {
+		t.Fatalf("trader issuing read key: %v", err)
+	}
+	if key.UserID != 2 {
+		t.Fatalf("trader issued key for user %d, want 2", key.UserID)
+	}
+	if _, err := s.IssueKey(traderCtx, 0, []string{"admin"}, nil, ""); err != apperrors.ErrPermissionEscalation {
+		t.Fatalf("trader granting admin: got %v, want %v", err, apperrors.ErrPermissionEscalation)
+	}
+}
+
+func TestIssueKeySignedOnlyGrantsTheSigningKeysPermissions(t *testing.T) {
+	s := newTestAPIKeyService("")
+	readKey, _ := s.keys.Issue(2, []auth.Permission{auth.PermissionRead}, nil)
+	tradeKey, _ := s.keys.Issue(2, []auth.Permission{auth.PermissionRead, auth.PermissionTrade}, nil)
+
+	tests := []struct {
+		signingKey  *auth.APIKey
+		permissions []string
+		want        error
+	}{
+		{readKey, []string{"read"}, nil},
+		{readKey, []string{"trade"}, apperrors.ErrPermissionEscalation},
+		{readKey, nil, apperrors.ErrPermissionEscalation}, // the defaults include trade
+		{tradeKey, nil, nil},
+		{tradeKey, []string{"read", "withdraw"}, apperrors.ErrPermissionEscalation},
+		{tradeKey, []string{"admin"}, apperrors.ErrPermissionEscalation},
+	}
+	for _, tt := range tests {
+		ctx := auth.WithAPIKey(context.Background(), tt.signingKey)
+		key, err := s.IssueKey(ctx, 0, tt.permissions, nil, "")
+		if err != tt.want {
+			t.Errorf("%v granting %v: got %v, want %v", tt.signingKey.Permissions, tt.permissions, err, tt.want)
+		}
+		if err == nil && key.UserID != 2 {
+			t.Errorf("%v granting %v: issued for user %d, want 2", tt.signingKey.Permissions, tt.permissions, key.UserID)
+		}
+	}
+}
+
+func TestIssueKeySignedStaysWithinTheSigningKeysAllowlist(t *testing.T) {
+	s := newTestAPIKeyService("")
+	restricted, _ := s.keys.Issue(2, auth.DefaultPermissions, []string{"10.0.0.0/8", "203.0.113.7"})
+	unrestricted, _ := s.keys.Issue(2, auth.DefaultPermissions, nil)
+	restrictedAdmin, _ := s.keys.Issue(1, []auth.Permission{auth.PermissionAdmin}, []string{"203.0.113.7"})
+
+	tests := []struct {
+		name       string
+		signingKey *auth.APIKey
+		userID     int64
+		allowedIPs []string
+		want       error
+	}{
+		{"no allowlist from a restricted key", restricted, 0, nil, apperrors.ErrAllowlistEscalation},
+		{"address outside the allowlist", restricted, 0, []string{"192.0.2.1"}, apperrors.ErrAllowlistEscalation},
+		{"wider range", restricted, 0, []string{"10.0.0.0/7"}, apperrors.ErrAllowlistEscalation},
+		{"range around an allowed address", restricted, 0, []string{"203.0.113.0/24"}, apperrors.ErrAllowlistEscalation},
+		{"one range outside", restricted, 0, []string{"10.1.0.0/16", "172.16.0.0/12"}, apperrors.ErrAllowlistEscalation},
+		{"narrower range and address", restricted, 0, []string{"10.1.0.0/16", "10.2.3.4"}, nil},
+		{"same allowlist", restricted, 0, []string{"203.0.113.7/32", "10.0.0.0/8"}, nil},
+		{"unrestricted key", unrestricted, 0, []string{"192.0.2.1"}, nil},
+		{"unrestricted key without allowlist", unrestricted, 0, nil, nil},
+		{"admin for its own user", restrictedAdmin, 1, nil, apperrors.ErrAllowlistEscalation},
+		{"admin for another user", restrictedAdmin, 3, []string{"192.0.2.1"}, nil},
+	}
+	for _, tt := range tests {
+		ctx := auth.WithAPIKey(context.Background(), tt.signingKey)
+		if _, err := s.IssueKey(ctx, tt.userID, []string{"read"}, tt.allowedIPs, ""); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestAuthenticateEnforcesTheAllowlist(t *testing.T) {
+	s := newTestAPIKeyService("")
+	key, _ := s.keys.Issue(2, []auth.Permission{auth.PermissionRead}, []string{"10.0.0.0/8", "203.0.113.7"})
+
+	tests := []struct {
+		ip         string
+		permission auth.Permission
+		want       error
+	}{
+		{"203.0.113.7", auth.PermissionRead, nil},
+		{"10.20.30.40", auth.PermissionRead, nil},
+		{"203.0.113.8", auth.PermissionRead, apperrors.ErrIPNotAllowed},
+		{"", auth.PermissionRead, apperrors.ErrIPNotAllowed},
+		{"10.20.30.40", auth.PermissionTrade, apperrors.ErrPermissionDenied},
+	}
+	for i, tt := range tests {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		nonce := strconv.Itoa(i)
+		credentials := auth.Credentials{
+			APIKey:    key.Key,
+			Timestamp: timestamp,
+			Nonce:     nonce,
+			Signature: auth.Sign(key.Secret, timestamp, nonce, "GET", "/api/orders", nil),
+		}
+		if _, err := s.Authenticate(context.Background(), credentials, "GET", "/api/orders", nil, net.ParseIP(tt.ip), tt.permission); err != tt.want {
+			t.Errorf("%q with %s: got %v, want %v", tt.ip, tt.permission, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+
+	"log"
+)
+
+// AssetService defines the interface for the asset registry
+type AssetService interface {
+	ListAssets(ctx context.Context) ([]models.Asset, error)
+	RegisterAsset(ctx context.Context, asset models.Asset) (*models.Asset, error)
+}
+
+var assetSvcStruct AssetService
+var assetServiceOnce sync.Once
+
+type assetService struct {
+	registry *assets.Registry
+	engine   *engine.MatchingEngine
+	config   *util.RouterConfig
+}
+
+// InitAssetService initializes the asset service
+func InitAssetService(registry *assets.Registry, matchingEngine *engine.MatchingEngine, config *util.RouterConfig) AssetService {
+	assetServiceOnce.Do(func() {
+		assetSvcStruct = &assetService{registry: registry, engine: matchingEngine, config: config}
+	})
+	return assetSvcStruct
+}
+
+// GetAssetService returns the singleton instance
+func GetAssetService() AssetService {
+	if assetSvcStruct == nil {
+		panic("AssetService not initialized")
+	}
+	return assetSvcStruct
+}
+
+// ListAssets returns every registered asset sorted by symbol
+func (s *assetService) ListAssets(ctx context.Context) ([]models.Asset, error) {
+	return s.registry.List(), nil
+}
+
+// RegisterAsset adds an asset or updates the one with the same symbol. The decimals of an asset
+// traded in a pair are locked, since the pair's precision was derived from them.
+func (s *assetService) RegisterAsset(ctx context.Context, asset models.Asset) (*models.Asset, error) {
+	if existing, exists := s.registry.Get(assets.NormalizeSymbol(asset.Symbol)); exists && existing.Decimals != asset.Decimals {
+		if pair, listed := s.listedPair(existing.Symbol); listed {
+			log.Printf("Cannot change the decimals of %s, it is traded in %s", existing.Symbol, pair)
+			return nil, apperrors.ErrAssetDecimalsLocked
+		}
+	}
+	registered, err := s.registry.Register(asset)
+	if err != nil {
+		log.Printf("Failed to register asset %q: %v", asset.Symbol, err)
+		return nil, err
+	}
+	log.Printf("Registered asset %s (%d decimals, %s)", registered.Symbol, registered.Decimals, registered.Status)
+	return &registered, nil
+}
+
+// listedPair returns a pair with symbol as its base or quote asset, delisted ones included
+func (s *assetService) listedPair(symbol string) (string, bool) {
+	for _, pair := range s.engine.GetPairs() {
+		if tradingPair, _ := s.engine.GetTradingPair(pair); tradingPair.Base == symbol || tradingPair.Quote == symbol {
+			return pair, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+	"time"
+
+	"log"
+)
+
+// AuctionService defines the interface for opening and re-opening call auctions
+type AuctionService interface {
+	GetAuctionState(ctx context.Context, pair string) (*models.AuctionState, error)
+	StartAuction(ctx context.Context, pair string, duration time.Duration) (*models.AuctionState, error)
+}
+
+var auctionSvcStruct AuctionService
+var auctionServiceOnce sync.Once
+
+type auctionService struct {
+	engine *engine.MatchingEngine
+	config *util.RouterConfig
+}
+
+// InitAuctionService initializes the auction service
+func InitAuctionService(matchingEngine *engine.MatchingEngine, config *util.RouterConfig) AuctionService {
+	auctionServiceOnce.Do(func() {
+		auctionSvcStruct = &auctionService{engine: matchingEngine, config: config}
+	})
+	return auctionSvcStruct
+}
+
+// GetAuctionService returns the singleton instance
+func GetAuctionService() AuctionService {
+	if auctionSvcStruct == nil {
+		panic("AuctionService not initialized")
+	}
+	return auctionSvcStruct
+}
+
+// GetAuctionState returns a pair's indicative auction outcome
+func (s *auctionService) GetAuctionState(ctx context.Context, pair string) (*models.AuctionState, error) {
+	pair = assets.NormalizePair(pair)
+	state, err := s.engine.GetAuctionState(pair)
+	if err != nil {
+		log.Printf("Failed to get auction state for %s: %v", pair, err)
+		return nil, err
+	}
+	return &state, nil
+}
+
+// StartAuction moves a pair into a call auction, ending after duration when it is positive
+func (s *auctionService) StartAuction(ctx context.Context, pair string, duration time.Duration) (*models.AuctionState, error) {
+	pair = assets.NormalizePair(pair)
+	state, err := s.engine.StartAuction(pair, duration)
+	if err != nil {
+		log.Printf("Failed to start auction for %s: %v", pair, err)
+		return nil, err
+	}
+	return &state, nil
+}
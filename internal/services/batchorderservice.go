@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+
+	"log"
+)
+
+// Batch modes
+const (
+	BatchModeAtomic     = "atomic"      // nothing is kept unless every order is valid and accepted by the engine
+	BatchModeBestEffort = "best_effort" // valid orders are placed, invalid ones are reported
+)
+
+// MaxBatchOrders is the largest number of orders accepted in one batch
+const MaxBatchOrders = 50
+
+// BatchOrderEntry is one order of a batch
+type BatchOrderEntry struct {
+	Pair          string
+	Side          string
+	Price         float64
+	Quantity      float64
+	ClientOrderID string
+}
+
+// BatchOrderResult is the outcome of one order of a batch, in request order
+type BatchOrderResult struct {
+	Index  int             `json:"index"`
+	Order  *models.Order   `json:"order,omitempty"`
+	Trades []*models.Trade `json:"trades,omitempty"`
+	Errors []*util.Error   `json:"errors,omitempty"`
+}
+
+// BatchOrderService defines the interface for placing several orders in one request
+type BatchOrderService interface {
+	PlaceOrders(ctx context.Context, userID int64, mode string, entries []BatchOrderEntry) ([]*BatchOrderResult, error)
+}
+
+var batchOrderSvcStruct BatchOrderService
+var batchOrderServiceOnce sync.Once
+
+type batchOrderService struct {
+	placeOrderService PlaceOrderService
+	engine            *engine.MatchingEngine
+	config            *util.RouterConfig
+}
+
+// InitBatchOrderService initializes the batch order service
+func InitBatchOrderService(placeOrderService PlaceOrderService, matchingEngine *engine.MatchingEngine, config *util.RouterConfig) BatchOrderService {
+	batchOrderServiceOnce.Do(func() {
+		batchOrderSvcStruct = &batchOrderService{placeOrderService: placeOrderService, engine: matchingEngine, config: config}
+	})
+	return batchOrderSvcStruct
+}
+
+// GetBatchOrderService returns the singleton instance
+func GetBatchOrderService() BatchOrderService {
+	if batchOrderSvcStruct == nil {
+		panic("BatchOrderService not initialized")
+	}
+	return batchOrderSvcStruct
+}
+
+// PlaceOrders validates every entry and then places the valid ones in request order. A client order
+// ID repeated within the batch or already used by the user fails validation with
+// ErrDuplicateClientOrderID, together with the original order when there is one. In atomic mode a
+// single invalid entry rejects the batch with ErrBatchRejected and the results list the validation
+// errors. Rejections by the engine itself, such as price bands or order limits, are reported per
+// order. In best effort mode orders placed before them stay placed; in atomic mode the batch stops
+// there, the orders already placed are cancelled and the batch fails with ErrBatchRolledBack. Fills
+// those orders got before being cancelled cannot be undone and are kept in their results.
+func (s *batchOrderService) PlaceOrders(ctx context.Context, userID int64, mode string, entries []BatchOrderEntry) ([]*BatchOrderResult, error) {
+	if mode == "" {
+		mode = BatchModeAtomic
+	}
+	if mode != BatchModeAtomic && mode != BatchModeBestEffort {
+		return nil, apperrors.ErrInvalidBatchMode
+	}
+	if len(entries) == 0 || len(entries) > MaxBatchOrders {
+		return nil, apperrors.ErrInvalidBatchSize
+	}
+
+	results := make([]*BatchOrderResult, len(entries))
+	invalid := 0
+	clientOrderIDs := make(map[string]bool, len(entries))
+	for i, entry := range entries {
+		results[i] = &BatchOrderResult{Index: i}
+		results[i].Errors = s.placeOrderService.ValidateRequest(ctx, userID, entry.Pair, entry.Side, entry.Price, entry.Quantity, entry.ClientOrderID)
+		if s.engine.GetOrderBook(assets.NormalizePair(entry.Pair)) == nil {
+			results[i].Errors = append(results[i].Errors, util.ServerToFieldError(apperrors.ErrPairNotFound, "pair"))
+		}
+		if entry.ClientOrderID != "" {
+			if original, exists := s.engine.GetOrderByClientID(userID, entry.ClientOrderID); exists {
+				results[i].Order = &original
+				results[i].Errors = append(results[i].Errors, util.ServerToFieldError(apperrors.ErrDuplicateClientOrderID, "client_order_id"))
+			} else if clientOrderIDs[entry.ClientOrderID] {
+				results[i].Errors = append(results[i].Errors, util.ServerToFieldError(apperrors.ErrDuplicateClientOrderID, "client_order_id"))
+			}
+			clientOrderIDs[entry.ClientOrderID] = true
+		}
+		if len(results[i].Errors) > 0 {
+			invalid++
+		}
+	}
+	if mode == BatchModeAtomic && invalid > 0 {
+		log.Printf("Atomic batch of %d orders from user %d rejected, %d invalid", len(entries), userID, invalid)
+		return results, apperrors.ErrBatchRejected
+	}
+
+	placed := make([]int, 0, len(entries))
+	for i, entry := range entries {
+		if len(results[i].Errors) > 0 {
+			continue
+		}
+		order, trades, err := s.placeOrderService.ProcessRequest(ctx, userID, entry.Pair, entry.Side, entry.Price, entry.Quantity, entry.ClientOrderID)
+		if err != nil {
+			log.Printf("Failed to place batch order %d: %v", i, err)
+			results[i].Errors = []*util.Error{util.ServerToError(err)}
+			if err == apperrors.ErrDuplicateClientOrderID {
+				results[i].Order = order
+			}
+			if mode == BatchModeAtomic {
+				s.rollBack(userID, results, placed, i)
+				return results, apperrors.ErrBatchRolledBack
+			}
+			continue
+		}
+		results[i].Order = order
+		results[i].Trades = trades
+		placed = append(placed, i)
+	}
+	return results, nil
+}
+
+// rollBack cancels the orders of an atomic batch placed before the entry at failed was rejected, and
+// marks them and the entries that were never attempted as rolled back
+func (s *batchOrderService) rollBack(userID int64, results []*BatchOrderResult, placed []int, failed int) {
+	rolledBack := []*util.Error{util.ServerToError(apperrors.ErrBatchRolledBack)}
+	orderIDs := make([]int64, len(placed))
+	for i, index := range placed {
+		orderIDs[i] = results[index].Order.ID
+	}
+	cancelled := s.engine.CancelOrders(userID, orderIDs)
+
+	for _, index := range placed {
+		if snapshot, exists := s.engine.GetOrder(results[index].Order.ID); exists {
+			results[index].Order = &snapshot
+		}
+		results[index].Errors = rolledBack
+	}
+	for index := failed + 1; index < len(results); index++ {
+		results[index].Errors = rolledBack
+	}
+	log.Printf("Atomic batch of %d orders from user %d rolled back after order %d was rejected, %d orders cancelled", len(results), userID, failed, len(cancelled))
+}
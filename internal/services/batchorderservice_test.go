@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"testing"
+)
+
+func newTestBatchOrderService(t *testing.T) *batchOrderService {
+	t.Helper()
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	return &batchOrderService{placeOrderService: &placeOrderService{engine: matchingEngine}, engine: matchingEngine}
+}
+
+func TestAtomicBatchRejectsClientOrderIDRepeatedInBatch(t *testing.T) {
+	s := newTestBatchOrderService(t)
+	entries := []BatchOrderEntry{
+		{Pair: "BTC/USDT", Side: "buy", Price: 100, Quantity: 1, ClientOrderID: "bid"},
+		{Pair: "BTC/USDT", Side: "buy", Price: 99, Quantity: 1, ClientOrderID: "bid"},
+	}
+
+	results, err := s.PlaceOrders(context.Background(), 1, BatchModeAtomic, entries)
+	if err != apperrors.ErrBatchRejected {
+		t.Fatalf("got %v, want %v", err, apperrors.ErrBatchRejected)
+	}
+	if len(results[0].Errors) != 0 || len(results[1].Errors) != 1 || results[1].Errors[0].Code != apperrors.ErrDuplicateClientOrderID.Code {
+		t.Fatalf("got errors %v and %v, want the repeated ID rejected", results[0].Errors, results[1].Errors)
+	}
+	if orders := s.engine.GetOrdersByUser(1); len(orders) != 0 {
+		t.Fatalf("rejected batch placed %d orders", len(orders))
+	}
+}
+
+func TestAtomicBatchRejectsClientOrderIDInUse(t *testing.T) {
+	s := newTestBatchOrderService(t)
+	original, _, err := s.engine.PlaceOrderWithClientID(1, "BTC/USDT", "buy", 100, 1, "bid")
+	if err != nil {
+		t.Fatalf("PlaceOrderWithClientID: %v", err)
+	}
+	entries := []BatchOrderEntry{
+		{Pair: "BTC/USDT", Side: "sell", Price: 110, Quantity: 1},
+		{Pair: "BTC/USDT", Side: "buy", Price: 99, Quantity: 1, ClientOrderID: "bid"},
+	}
+
+	results, err := s.PlaceOrders(context.Background(), 1, BatchModeAtomic, entries)
+	if err != apperrors.ErrBatchRejected {
+		t.Fatalf("got %v, want %v", err, apperrors.ErrBatchRejected)
+	}
+	if results[1].Order == nil || results[1].Order.ID != original.ID {
+		t.Fatalf("got order %+v, want the original order %d", results[1].Order, original.ID)
+	}
+	if orders := s.engine.GetOrdersByUser(1); len(orders) != 1 {
+		t.Fatalf("rejected batch placed %d orders", len(orders)-1)
+	}
+}
+
+// engineRejectedBatch has its fourth order rejected by the engine once the user may only keep two
+// orders open, after the first one filled against a resting order of user 2
+func engineRejectedBatch(t *testing.T, s *batchOrderService) []BatchOrderEntry {
+	t.Helper()
+	if _, _, err := s.engine.PlaceOrder(2, "BTC/USDT", "sell", 100, 1); err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	s.engine.SetUserLimits(1, engine.OrderLimits{MaxOpenOrdersPerPair: 2})
+	return []BatchOrderEntry{
+		{Pair: "BTC/USDT", Side: "buy", Price: 100, Quantity: 1},
+		{Pair: "BTC/USDT", Side: "buy", Price: 99, Quantity: 1},
+		{Pair: "BTC/USDT", Side: "buy", Price: 98, Quantity: 1},
+		{Pair: "BTC/USDT", Side: "buy", Price: 97, Quantity: 1},
+		{Pair: "BTC/USDT", Side: "sell", Price: 120, Quantity: 1},
+	}
+}
+
+func TestAtomicBatchRollsBackWhenTheEngineRejectsAnOrder(t *testing.T) {
+	s := newTestBatchOrderService(t)
+	entries := engineRejectedBatch(t, s)
+
+	results, err := s.PlaceOrders(context.Background(), 1, BatchModeAtomic, entries)
+	if err != apperrors.ErrBatchRolledBack {
+		t.Fatalf("got %v, want %v", err, apperrors.ErrBatchRolledBack)
+	}
+
+	wantStatus := []string{"filled", "cancelled", "cancelled"}
+	for i, status := range wantStatus {
+		result := results[i]
+		if result.Order == nil || result.Order.Status != status {
+			t.Fatalf("order %d: got %+v, want it %s", i, result.Order, status)
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Code != apperrors.ErrBatchRolledBack.Code {
+			t.Fatalf("order %d: got errors %v, want it rolled back", i, result.Errors)
+		}
+	}
+	if len(results[0].Trades) != 1 {
+		t.Fatalf("got %d trades for the filled order, want its fill kept", len(results[0].Trades))
+	}
+	if len(results[3].Errors) != 1 || results[3].Errors[0].Code != apperrors.ErrTooManyOpenOrders.Code || results[3].Order != nil {
+		t.Fatalf("rejected order: got %+v, want the engine's error", results[3])
+	}
+	if len(results[4].Errors) != 1 || results[4].Errors[0].Code != apperrors.ErrBatchRolledBack.Code || results[4].Order != nil {
+		t.Fatalf("order after the rejection: got %+v, want it rolled back without being placed", results[4])
+	}
+
+	for _, order := range s.engine.GetOrdersByUser(1) {
+		if order.Status == "open" || order.Status == "partial" {
+			t.Fatalf("order %d at %v is still %s after the rollback", order.ID, order.Price, order.Status)
+		}
+	}
+	if orders := s.engine.GetOrdersByUser(1); len(orders) != 3 {
+		t.Fatalf("user has %d orders, want the 3 placed before the rejection", len(orders))
+	}
+	// The cancelled orders freed their open order slots
+	if _, _, err := s.engine.PlaceOrder(1, "BTC/USDT", "buy", 96, 1); err != nil {
+		t.Fatalf("order after the rollback: %v", err)
+	}
+}
+
+func TestBestEffortBatchKeepsOrdersPlacedBeforeAnEngineRejection(t *testing.T) {
+	s := newTestBatchOrderService(t)
+	entries := engineRejectedBatch(t, s)
+
+	results, err := s.PlaceOrders(context.Background(), 1, BatchModeBestEffort, entries)
+	if err != nil {
+		t.Fatalf("PlaceOrders: %v", err)
+	}
+	wantStatus := []string{"filled", "open", "open", "", ""}
+	for i, status := range wantStatus {
+		if status == "" {
+			if len(results[i].Errors) != 1 || results[i].Errors[0].Code != apperrors.ErrTooManyOpenOrders.Code {
+				t.Fatalf("order %d: got errors %v, want the engine's error", i, results[i].Errors)
+			}
+			continue
+		}
+		if results[i].Order == nil || results[i].Order.Status != status || len(results[i].Errors) != 0 {
+			t.Fatalf("order %d: got %+v, want it %s", i, results[i], status)
+		}
+	}
+}
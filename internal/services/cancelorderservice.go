@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"math"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+	"time"
+
+	"log"
+)
+
+// MaxCancelAllAfter bounds the dead man's switch countdown
+const MaxCancelAllAfter = 10 * time.Minute
+
+// CancelOrderService defines the interface for bulk order cancellation
+type CancelOrderService interface {
+	CancelAllOrders(ctx context.Context, userID int64, pair string, side string) ([]*models.Order, error)
+	CancelAllAfter(ctx context.Context, userID int64, timeout time.Duration) (*models.DeadMansSwitch, error)
+	CancelOrderByClientID(ctx context.Context, userID int64, clientOrderID string) (*models.Order, error)
+}
+
+var cancelOrderSvcStruct CancelOrderService
+var cancelOrderServiceOnce sync.Once
+
+type cancelOrderService struct {
+	engine *engine.MatchingEngine
+	config *util.RouterConfig
+
+	// mu guards the armed dead man's switches
+	mu       sync.Mutex
+	switches map[int64]*time.Timer
+}
+
+// InitCancelOrderService initializes the cancel order service
+func InitCancelOrderService(matchingEngine *engine.MatchingEngine, config *util.RouterConfig) CancelOrderService {
+	cancelOrderServiceOnce.Do(func() {
+		cancelOrderSvcStruct = &cancelOrderService{
+			engine:   matchingEngine,
+			config:   config,
+			switches: make(map[int64]*time.Timer),
+		}
+	})
+	return cancelOrderSvcStruct
+}
+
+// GetCancelOrderService returns the singleton instance
+func GetCancelOrderService() CancelOrderService {
+	if cancelOrderSvcStruct == nil {
+		panic("CancelOrderService not initialized")
+	}
+	return cancelOrderSvcStruct
+}
+
+// CancelAllOrders atomically cancels the user's open orders, limited to pair and side when they
+// are not empty
+func (s *cancelOrderService) CancelAllOrders(ctx context.Context, userID int64, pair string, side string) ([]*models.Order, error) {
+	pair = assets.NormalizePair(pair)
+	if side != "" && side != "buy" && side != "sell" {
+		return nil, apperrors.ErrInvalidSide
+	}
+	if pair != "" {
+		if _, exists := s.engine.GetPairStatus(pair); !exists {
+			return nil, apperrors.ErrPairNotFound
+		}
+	}
+
+	cancelled := s.engine.CancelAllOrders(userID, pair, side)
+	if len(cancelled) > 0 {
+		log.Printf("Cancelled %d open orders of user %d", len(cancelled), userID)
+	}
+	return cancelled, nil
+}
+
+// CancelOrderByClientID cancels the user's open order with the given client order ID
+func (s *cancelOrderService) CancelOrderByClientID(ctx context.Context, userID int64, clientOrderID string) (*models.Order, error) {
+	order, err := s.engine.CancelOrderByClientID(userID, clientOrderID)
+	if err != nil {
+		log.Printf("Failed to cancel order %q of user %d: %v", clientOrderID, userID, err)
+		return nil, err
+	}
+	return order, nil
+}
+
+// CancelAllAfter arms, refreshes or, with a zero timeout, disarms the user's dead man's switch.
+// When the timeout elapses without another call, every open order of the user is cancelled,
+// including orders in halted pairs. Orders placed before the switch fired that are still open
+// afterwards are logged as skipped.
+func (s *cancelOrderService) CancelAllAfter(ctx context.Context, userID int64, timeout time.Duration) (*models.DeadMansSwitch, error) {
+	if timeout < 0 || timeout > MaxCancelAllAfter {
+		return nil, apperrors.ErrInvalidCancelTimeout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if timer, armed := s.switches[userID]; armed {
+		timer.Stop()
+		delete(s.switches, userID)
+	}
+	if timeout == 0 {
+		return &models.DeadMansSwitch{CurrentTime: now}, nil
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		s.mu.Lock()
+		if s.switches[userID] != timer {
+			s.mu.Unlock()
+			return
+		}
+		delete(s.switches, userID)
+		s.mu.Unlock()
+
+		firedAt := time.Now()
+		cancelled := s.engine.CancelAllOrders(userID, "", "")
+		log.Printf("Dead man's switch of user %d triggered, cancelled %d open orders", userID, len(cancelled))
+		for _, status := range []string{"open", "partial"} {
+			skipped, _ := s.engine.QueryOrders(userID, engine.OrderQuery{Status: status, End: firedAt, Limit: math.MaxInt})
+			for _, order := range skipped {
+				log.Printf("Dead man's switch of user %d skipped order %d in %s", userID, order.ID, order.Pair)
+			}
+		}
+	})
+	s.switches[userID] = timer
+
+	triggerTime := now.Add(timeout)
+	return &models.DeadMansSwitch{
+		TimeoutMs:   timeout.Milliseconds(),
+		CurrentTime: now,
+		TriggerTime: &triggerTime,
+	}, nil
+}
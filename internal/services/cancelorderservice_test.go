@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"testing"
+	"time"
+)
+
+func newTestCancelOrderService(t *testing.T) *cancelOrderService {
+	t.Helper()
+	matchingEngine := engine.NewMatchingEngine()
+	for _, pair := range []models.TradingPair{{Base: "BTC", Quote: "USDT"}, {Base: "ETH", Quote: "USDT"}} {
+		if err := matchingEngine.CreatePair(pair); err != nil {
+			t.Fatalf("CreatePair: %v", err)
+		}
+	}
+	return &cancelOrderService{engine: matchingEngine, switches: make(map[int64]*time.Timer)}
+}
+
+func TestDeadMansSwitchCancelsOrdersInHaltedPairs(t *testing.T) {
+	s := newTestCancelOrderService(t)
+	halted, _, _ := s.engine.PlaceOrder(1, "BTC/USDT", "buy", 100, 1)
+	trading, _, _ := s.engine.PlaceOrder(1, "ETH/USDT", "sell", 10, 1)
+	if _, err := s.engine.SetPairStatus("BTC/USDT", models.PairStatusHalted); err != nil {
+		t.Fatalf("SetPairStatus: %v", err)
+	}
+
+	if _, err := s.CancelAllAfter(context.Background(), 1, 10*time.Millisecond); err != nil {
+		t.Fatalf("CancelAllAfter: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for _, order := range []*models.Order{halted, trading} {
+		for {
+			if snapshot, _ := s.engine.GetOrder(order.ID); snapshot.Status == "cancelled" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("order %d in %s survived the dead man's switch", order.ID, order.Pair)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestCancelAllOrdersFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		pair string
+		side string
+		want []int // indexes of the cancelled orders
+	}{
+		{"all", "", "", []int{0, 1, 2, 3, 4}},
+		{"pair", "BTC/USDT", "", []int{0, 1, 2}},
+		{"side", "", "buy", []int{0, 1, 3}},
+		{"pair and side", "ETH/USDT", "sell", []int{4}},
+		{"pair in lower case", " btc/usdt ", "sell", []int{2}},
+	}
+	for _, tt := range tests {
+		s := newTestCancelOrderService(t)
+		place := func(userID int64, pair string, side string, price float64, quantity float64) *models.Order {
+			order, _, err := s.engine.PlaceOrder(userID, pair, side, price, quantity)
+			if err != nil {
+				t.Fatalf("%s: PlaceOrder: %v", tt.name, err)
+			}
+			return order
+		}
+		orders := []*models.Order{
+			place(1, "BTC/USDT", "buy", 100, 1),
+			place(1, "BTC/USDT", "buy", 101, 2), // partially filled below
+			place(1, "BTC/USDT", "sell", 110, 1),
+			place(1, "ETH/USDT", "buy", 10, 1),
+			place(1, "ETH/USDT", "sell", 11, 1),
+		}
+		place(2, "BTC/USDT", "sell", 101, 1)
+		other := place(2, "BTC/USDT", "buy", 99, 1)
+
+		cancelled, err := s.CancelAllOrders(context.Background(), 1, tt.pair, tt.side)
+		if err != nil {
+			t.Fatalf("%s: CancelAllOrders: %v", tt.name, err)
+		}
+		if len(cancelled) != len(tt.want) {
+			t.Fatalf("%s: cancelled %d orders, want %d", tt.name, len(cancelled), len(tt.want))
+		}
+		wanted := make(map[int64]bool)
+		for i, index := range tt.want {
+			wanted[orders[index].ID] = true
+			if cancelled[i].ID != orders[index].ID || cancelled[i].Status != "cancelled" {
+				t.Fatalf("%s: cancelled order %+v, want order %d cancelled", tt.name, cancelled[i], orders[index].ID)
+			}
+		}
+		for _, order := range append(orders, other) {
+			snapshot, _ := s.engine.GetOrder(order.ID)
+			if (snapshot.Status == "cancelled") != wanted[order.ID] {
+				t.Fatalf("%s: order %d in %s %s is %s", tt.name, order.ID, order.Pair, order.Side, snapshot.Status)
+			}
+		}
+	}
+}
+
+func TestCancelAllOrdersRejectsInvalidFilters(t *testing.T) {
+	s := newTestCancelOrderService(t)
+	order, _, _ := s.engine.PlaceOrder(1, "BTC/USDT", "buy", 100, 1)
+
+	if _, err := s.CancelAllOrders(context.Background(), 1, "", "hold"); err != apperrors.ErrInvalidSide {
+		t.Fatalf("invalid side: got %v, want %v", err, apperrors.ErrInvalidSide)
+	}
+	if _, err := s.CancelAllOrders(context.Background(), 1, "DOGE/USDT", ""); err != apperrors.ErrPairNotFound {
+		t.Fatalf("unknown pair: got %v, want %v", err, apperrors.ErrPairNotFound)
+	}
+	if snapshot, _ := s.engine.GetOrder(order.ID); snapshot.Status != "open" {
+		t.Fatalf("order is %s after rejected mass cancels, want open", snapshot.Status)
+	}
+
+	// Filled and already cancelled orders are left alone
+	s.engine.PlaceOrder(2, "BTC/USDT", "sell", 100, 1)
+	s.engine.PlaceOrder(1, "ETH/USDT", "buy", 10, 1)
+	if cancelled, _ := s.CancelAllOrders(context.Background(), 1, "", ""); len(cancelled) != 1 || cancelled[0].Pair != "ETH/USDT" {
+		t.Fatalf("cancelled %+v, want only the open ETH/USDT order", cancelled)
+	}
+	if cancelled, _ := s.CancelAllOrders(context.Background(), 1, "", ""); len(cancelled) != 0 {
+		t.Fatalf("cancelled %d orders again, want none", len(cancelled))
+	}
+}
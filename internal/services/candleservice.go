@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/marketdata"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+	"time"
+
+	"log"
+)
+
+// CandleService defines the interface for querying OHLCV candles
+type CandleService interface {
+	GetCandles(ctx context.Context, pair string, interval string, start time.Time, end time.Time) ([]models.Candle, error)
+}
+
+var candleSvcStruct CandleService
+var candleServiceOnce sync.Once
+
+type candleService struct {
+	engine     *engine.MatchingEngine
+	aggregator *marketdata.CandleAggregator
+	config     *util.RouterConfig
+}
+
+// InitCandleService initializes the candle service
+func InitCandleService(matchingEngine *engine.MatchingEngine, aggregator *marketdata.CandleAggregator, config *util.RouterConfig) CandleService {
+	candleServiceOnce.Do(func() {
+		candleSvcStruct = &candleService{engine: matchingEngine, aggregator: aggregator, config: config}
+	})
+	return candleSvcStruct
+}
+
+// GetCandleService returns the singleton instance
+func GetCandleService() CandleService {
+	if candleSvcStruct == nil {
+		panic("CandleService not initialized")
+	}
+	return candleSvcStruct
+}
+
+// GetCandles returns the candles for a pair and interval within the time range
+func (s *candleService) GetCandles(ctx context.Context, pair string, interval string, start time.Time, end time.Time) ([]models.Candle, error) {
+	pair = assets.NormalizePair(pair)
+	if s.engine.GetOrderBook(pair) == nil {
+		log.Printf("Order book not found for pair: %s", pair)
+		return nil, apperrors.ErrPairNotFound
+	}
+
+	if !marketdata.IsValidInterval(interval) {
+		log.Printf("Invalid candle interval: %s", interval)
+		return nil, apperrors.ErrInvalidInterval
+	}
+
+	if !start.IsZero() && !end.IsZero() && start.After(end) {
+		log.Println("Candle start is after end")
+		return nil, apperrors.ErrInvalidTimeRange
+	}
+
+	return s.aggregator.GetCandles(pair, interval, start, end), nil
+}
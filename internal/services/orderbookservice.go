@@ -2,10 +2,15 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
 	"mini-crypto-exchange/internal/engine"
 	"mini-crypto-exchange/internal/models"
 	"mini-crypto-exchange/internal/util"
+	"strconv"
 	"sync"
+	"time"
 
 	"log"
 )
@@ -14,6 +19,27 @@ import (
 type OrderBookService interface {
 	GetOrderBook(ctx context.Context, pair string, depth int) (map[string]interface{}, error)
 	GetOrdersByUser(ctx context.Context, userID int64) ([]*models.Order, error)
+	GetOrderByClientID(ctx context.Context, userID int64, clientOrderID string) (*models.Order, error)
+	GetOrder(ctx context.Context, userID int64, orderID int64) (*models.Order, error)
+	QueryOrders(ctx context.Context, userID int64, query OrderHistoryQuery) (*models.OrderPage, error)
+}
+
+// Order history page sizes
+const (
+	DefaultOrderPageLimit = 100
+	MaxOrderPageLimit     = 1000
+)
+
+// OrderHistoryQuery filters and pages a user's orders. Empty fields and zero times match everything.
+type OrderHistoryQuery struct {
+	Pair   string
+	Side   string
+	Status string
+	Start  time.Time
+	End    time.Time
+	Sort   string // "desc" (default, newest first) or "asc"
+	Limit  int    // 0 means DefaultOrderPageLimit
+	Cursor string // next_cursor of the previous page
 }
 
 var orderBookSvcStruct OrderBookService
@@ -40,21 +66,26 @@ func GetOrderBookService() OrderBookService {
 	return orderBookSvcStruct
 }
 
-// GetOrderBook returns the order book for a pair
+// GetOrderBook returns the order book for a pair, or ErrPairNotFound for unknown pairs
 func (s *orderBookService) GetOrderBook(ctx context.Context, pair string, depth int) (map[string]interface{}, error) {
-
+	pair = assets.NormalizePair(pair)
 	ob := s.engine.GetOrderBook(pair)
 	if ob == nil {
 		log.Printf("Order book not found for pair: %s", pair)
-		return nil, nil
+		return nil, apperrors.ErrPairNotFound
 	}
 
 	buys, sells := ob.GetDepth(depth)
+	status, _ := s.engine.GetPairStatus(pair)
+	matching, _ := s.engine.GetMatchingConfig(pair)
 
 	return map[string]interface{}{
-		"pair": pair,
-		"buy":  buys,
-		"sell": sells,
+		"pair":          pair,
+		"status":        status,
+		"matching_mode": matching.Mode,
+		"allocation":    matching.Algorithm,
+		"buy":           buys,
+		"sell":          sells,
 	}, nil
 }
 
@@ -69,3 +100,89 @@ func (s *orderBookService) GetOrdersByUser(ctx context.Context, userID int64) ([
 
 	return orders, nil
 }
+
+// GetOrderByClientID returns the user's open or recent order with the given client order ID
+func (s *orderBookService) GetOrderByClientID(ctx context.Context, userID int64, clientOrderID string) (*models.Order, error) {
+	order, exists := s.engine.GetOrderByClientID(userID, clientOrderID)
+	if !exists {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	return &order, nil
+}
+
+// GetOrder returns one of the user's orders by ID
+func (s *orderBookService) GetOrder(ctx context.Context, userID int64, orderID int64) (*models.Order, error) {
+	order, exists := s.engine.GetOrder(orderID)
+	if !exists || order.UserID != userID {
+		return nil, apperrors.ErrOrderNotFound
+	}
+	return &order, nil
+}
+
+// QueryOrders returns a page of the user's orders matching the query
+func (s *orderBookService) QueryOrders(ctx context.Context, userID int64, query OrderHistoryQuery) (*models.OrderPage, error) {
+	engineQuery := engine.OrderQuery{
+		Pair:   assets.NormalizePair(query.Pair),
+		Side:   query.Side,
+		Status: query.Status,
+		Start:  query.Start,
+		End:    query.End,
+		Limit:  query.Limit,
+	}
+
+	if query.Side != "" && query.Side != "buy" && query.Side != "sell" {
+		return nil, apperrors.ErrInvalidSide
+	}
+	switch query.Status {
+	case "", "open", "partial", "filled", "cancelled":
+	default:
+		return nil, apperrors.ErrInvalidOrderStatus
+	}
+	if !query.Start.IsZero() && !query.End.IsZero() && query.Start.After(query.End) {
+		return nil, apperrors.ErrInvalidTimeRange
+	}
+	switch query.Sort {
+	case "", "desc":
+		engineQuery.Descending = true
+	case "asc":
+	default:
+		return nil, apperrors.ErrInvalidSortOrder
+	}
+	if engineQuery.Limit == 0 {
+		engineQuery.Limit = DefaultOrderPageLimit
+	}
+	if engineQuery.Limit < 0 || engineQuery.Limit > MaxOrderPageLimit {
+		return nil, apperrors.ErrInvalidPageLimit
+	}
+	if query.Cursor != "" {
+		afterID, err := decodeOrderCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		engineQuery.AfterID = afterID
+	}
+
+	orders, more := s.engine.QueryOrders(userID, engineQuery)
+	page := &models.OrderPage{Orders: orders}
+	if more {
+		page.NextCursor = encodeOrderCursor(orders[len(orders)-1].ID)
+	}
+	return page, nil
+}
+
+// Cursors are opaque to clients so their encoding can change
+func encodeOrderCursor(orderID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(orderID, 10)))
+}
+
+func decodeOrderCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, apperrors.ErrInvalidCursor
+	}
+	orderID, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || orderID <= 0 {
+		return 0, apperrors.ErrInvalidCursor
+	}
+	return orderID, nil
+}
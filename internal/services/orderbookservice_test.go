@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"testing"
+	"time"
+)
+
+func newTestOrderBookService(t *testing.T) (*orderBookService, []*models.Order) {
+	t.Helper()
+	matchingEngine := engine.NewMatchingEngine()
+	for _, pair := range []models.TradingPair{{Base: "BTC", Quote: "USDT"}, {Base: "ETH", Quote: "USDT"}} {
+		if err := matchingEngine.CreatePair(pair); err != nil {
+			t.Fatalf("CreatePair: %v", err)
+		}
+	}
+	var orders []*models.Order
+	for i := 0; i < 5; i++ {
+		for _, pair := range []string{"BTC/USDT", "ETH/USDT"} {
+			order, _, err := matchingEngine.PlaceOrder(1, pair, "buy", float64(100-i), 1)
+			if err != nil {
+				t.Fatalf("PlaceOrder: %v", err)
+			}
+			orders = append(orders, order)
+		}
+	}
+	return &orderBookService{engine: matchingEngine}, orders
+}
+
+func TestQueryOrdersPagesWithCursors(t *testing.T) {
+	s, orders := newTestOrderBookService(t)
+	ctx := context.Background()
+
+	for _, sort := range []string{"", "desc", "asc"} {
+		query := OrderHistoryQuery{Pair: "eth/usdt", Sort: sort, Limit: 2}
+		var got []int64
+		for pages := 1; ; pages++ {
+			page, err := s.QueryOrders(ctx, 1, query)
+			if err != nil {
+				t.Fatalf("sort %q page %d: %v", sort, pages, err)
+			}
+			for _, order := range page.Orders {
+				got = append(got, order.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			if pages > 5 {
+				t.Fatalf("sort %q: paging does not terminate", sort)
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		var want []int64
+		for _, order := range orders {
+			if order.Pair == "ETH/USDT" {
+				want = append(want, order.ID)
+			}
+		}
+		if sort != "asc" {
+			for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+				want[i], want[j] = want[j], want[i]
+			}
+		}
+		if len(got) != len(want) {
+			t.Fatalf("sort %q: got orders %v, want %v", sort, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("sort %q: got orders %v, want %v", sort, got, want)
+			}
+		}
+	}
+}
+
+func TestQueryOrdersRejectsInvalidQueries(t *testing.T) {
+	s, _ := newTestOrderBookService(t)
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		query OrderHistoryQuery
+		want  error
+	}{
+		{"side", OrderHistoryQuery{Side: "hold"}, apperrors.ErrInvalidSide},
+		{"status", OrderHistoryQuery{Status: "expired"}, apperrors.ErrInvalidOrderStatus},
+		{"time range", OrderHistoryQuery{Start: now, End: now.Add(-time.Second)}, apperrors.ErrInvalidTimeRange},
+		{"sort", OrderHistoryQuery{Sort: "newest"}, apperrors.ErrInvalidSortOrder},
+		{"negative limit", OrderHistoryQuery{Limit: -1}, apperrors.ErrInvalidPageLimit},
+		{"limit over the maximum", OrderHistoryQuery{Limit: MaxOrderPageLimit + 1}, apperrors.ErrInvalidPageLimit},
+		{"cursor that is not base64", OrderHistoryQuery{Cursor: "!!"}, apperrors.ErrInvalidCursor},
+		{"cursor that is not an order ID", OrderHistoryQuery{Cursor: encodeOrderCursor(0)}, apperrors.ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		if _, err := s.QueryOrders(context.Background(), 1, tt.query); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	page, err := s.QueryOrders(context.Background(), 1, OrderHistoryQuery{})
+	if err != nil {
+		t.Fatalf("default query: %v", err)
+	}
+	if len(page.Orders) != 10 || page.NextCursor != "" || page.Orders[0].ID < page.Orders[9].ID {
+		t.Fatalf("default query returned %d orders, cursor %q, want all 10 newest first", len(page.Orders), page.NextCursor)
+	}
+}
+
+func TestGetOrderBookOfAnUnknownPair(t *testing.T) {
+	s, _ := newTestOrderBookService(t)
+
+	if _, err := s.GetOrderBook(context.Background(), "DOGE/USDT", 10); err != apperrors.ErrPairNotFound {
+		t.Fatalf("got %v, want %v", err, apperrors.ErrPairNotFound)
+	}
+	book, err := s.GetOrderBook(context.Background(), "eth/usdt", 10)
+	if err != nil {
+		t.Fatalf("GetOrderBook: %v", err)
+	}
+	if book["pair"] != "ETH/USDT" || len(book["buy"].([]map[string]interface{})) != 5 {
+		t.Fatalf("order book %v, want the 5 bids of ETH/USDT", book)
+	}
+}
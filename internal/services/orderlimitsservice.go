@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+
+	"log"
+)
+
+// UserOrderLimits are the limits in force for a user
+type UserOrderLimits struct {
+	UserID   int64              `json:"user_id"`
+	Limits   engine.OrderLimits `json:"limits"`
+	Override bool               `json:"override"`
+}
+
+// OrderLimitsService defines the interface for managing per-user order limits
+type OrderLimitsService interface {
+	GetUserLimits(ctx context.Context, userID int64) (*UserOrderLimits, error)
+	SetUserLimits(ctx context.Context, userID int64, limits engine.OrderLimits) (*UserOrderLimits, error)
+	ClearUserLimits(ctx context.Context, userID int64) (*UserOrderLimits, error)
+}
+
+var orderLimitsSvcStruct OrderLimitsService
+var orderLimitsServiceOnce sync.Once
+
+type orderLimitsService struct {
+	engine *engine.MatchingEngine
+	config *util.RouterConfig
+}
+
+// InitOrderLimitsService initializes the order limits service
+func InitOrderLimitsService(matchingEngine *engine.MatchingEngine, config *util.RouterConfig) OrderLimitsService {
+	orderLimitsServiceOnce.Do(func() {
+		orderLimitsSvcStruct = &orderLimitsService{engine: matchingEngine, config: config}
+	})
+	return orderLimitsSvcStruct
+}
+
+// GetOrderLimitsService returns the singleton instance
+func GetOrderLimitsService() OrderLimitsService {
+	if orderLimitsSvcStruct == nil {
+		panic("OrderLimitsService not initialized")
+	}
+	return orderLimitsSvcStruct
+}
+
+// GetUserLimits returns the user's effective limits
+func (s *orderLimitsService) GetUserLimits(ctx context.Context, userID int64) (*UserOrderLimits, error) {
+	if userID <= 0 {
+		log.Println("Invalid user ID")
+		return nil, apperrors.ErrInvalidUserID
+	}
+
+	limits, override := s.engine.GetUserLimits(userID)
+	return &UserOrderLimits{UserID: userID, Limits: limits, Override: override}, nil
+}
+
+// SetUserLimits overrides the user's limits
+func (s *orderLimitsService) SetUserLimits(ctx context.Context, userID int64, limits engine.OrderLimits) (*UserOrderLimits, error) {
+	if userID <= 0 {
+		log.Println("Invalid user ID")
+		return nil, apperrors.ErrInvalidUserID
+	}
+	if err := limits.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.engine.SetUserLimits(userID, limits)
+	return s.GetUserLimits(ctx, userID)
+}
+
+// ClearUserLimits restores the default limits for the user
+func (s *orderLimitsService) ClearUserLimits(ctx context.Context, userID int64) (*UserOrderLimits, error) {
+	if userID <= 0 {
+		log.Println("Invalid user ID")
+		return nil, apperrors.ErrInvalidUserID
+	}
+
+	s.engine.ClearUserLimits(userID)
+	return s.GetUserLimits(ctx, userID)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+
+	"log"
+)
+
+// PairInfo describes a trading pair with the precision of its assets, the precision its orders
+// accept, its status and its matching
+type PairInfo struct {
+	Pair string `json:"pair"`
+	models.TradingPair
+	BaseDecimals  int `json:"base_decimals"`
+	QuoteDecimals int `json:"quote_decimals"`
+	engine.Precision
+	Status models.PairStatus `json:"status"`
+	engine.MatchingConfig
+}
+
+// PairService defines the interface for the pair registry
+type PairService interface {
+	CreatePair(ctx context.Context, base string, quote string, config engine.MatchingConfig) (*PairInfo, error)
+	ListPairs(ctx context.Context) ([]*PairInfo, error)
+}
+
+var pairSvcStruct PairService
+var pairServiceOnce sync.Once
+
+type pairService struct {
+	engine   *engine.MatchingEngine
+	registry *assets.Registry
+	config   *util.RouterConfig
+}
+
+// InitPairService initializes the pair service
+func InitPairService(matchingEngine *engine.MatchingEngine, registry *assets.Registry, config *util.RouterConfig) PairService {
+	pairServiceOnce.Do(func() {
+		pairSvcStruct = &pairService{engine: matchingEngine, registry: registry, config: config}
+	})
+	return pairSvcStruct
+}
+
+// GetPairService returns the singleton instance
+func GetPairService() PairService {
+	if pairSvcStruct == nil {
+		panic("PairService not initialized")
+	}
+	return pairSvcStruct
+}
+
+// CreatePair creates a pair of two distinct registered, active assets. Symbols are normalized, so
+// "btc"/"usdt" creates BTC/USDT, and creating an existing pair fails with ErrPairExists. Prices
+// take the quote asset's decimals and quantities the base asset's, up to engine.MaxDecimals.
+func (s *pairService) CreatePair(ctx context.Context, base string, quote string, config engine.MatchingConfig) (*PairInfo, error) {
+	tradingPair := models.TradingPair{Base: assets.NormalizeSymbol(base), Quote: assets.NormalizeSymbol(quote)}
+	if tradingPair.Base == tradingPair.Quote {
+		return nil, apperrors.ErrInvalidPair
+	}
+	decimals := make(map[string]int, 2)
+	for _, symbol := range []string{tradingPair.Base, tradingPair.Quote} {
+		asset, exists := s.registry.Get(symbol)
+		if !exists {
+			log.Printf("Cannot create %s, unknown asset %s", tradingPair, symbol)
+			return nil, apperrors.ErrAssetNotFound
+		}
+		if asset.Status != models.AssetStatusActive {
+			log.Printf("Cannot create %s, asset %s is %s", tradingPair, symbol, asset.Status)
+			return nil, apperrors.ErrAssetDisabled
+		}
+		decimals[symbol] = asset.Decimals
+	}
+	precision := engine.NewPrecision(decimals[tradingPair.Quote], decimals[tradingPair.Base])
+
+	if _, err := s.engine.CreatePairWithMatching(tradingPair, config, precision); err != nil {
+		log.Printf("Failed to create pair %s: %v", tradingPair, err)
+		return nil, err
+	}
+	log.Printf("Created pair %s", tradingPair)
+	return s.pairInfo(tradingPair.String()), nil
+}
+
+// ListPairs returns every pair sorted by name
+func (s *pairService) ListPairs(ctx context.Context) ([]*PairInfo, error) {
+	names := s.engine.GetPairs()
+	pairs := make([]*PairInfo, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, s.pairInfo(name))
+	}
+	return pairs, nil
+}
+
+// pairInfo describes an existing pair
+func (s *pairService) pairInfo(pair string) *PairInfo {
+	info := &PairInfo{Pair: pair}
+	info.TradingPair, _ = s.engine.GetTradingPair(pair)
+	info.Precision, _ = s.engine.GetPairPrecision(pair)
+	info.Status, _ = s.engine.GetPairStatus(pair)
+	info.MatchingConfig, _ = s.engine.GetMatchingConfig(pair)
+	if base, exists := s.registry.Get(info.Base); exists {
+		info.BaseDecimals = base.Decimals
+	}
+	if quote, exists := s.registry.Get(info.Quote); exists {
+		info.QuoteDecimals = quote.Decimals
+	}
+	return info
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/marketdata"
+	"mini-crypto-exchange/internal/models"
+	"testing"
+	"time"
+)
+
+func TestPairEntryPointsNormalizePairNames(t *testing.T) {
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	order, _, err := matchingEngine.PlaceOrder(1, "BTC/USDT", "buy", 100, 1)
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	ctx := context.Background()
+	const pair = " btc/usdt "
+
+	orderBooks := &orderBookService{engine: matchingEngine}
+	risk := &riskService{engine: matchingEngine}
+	auctions := &auctionService{engine: matchingEngine}
+	pairStatus := &pairStatusService{engine: matchingEngine}
+	tickers := &tickerService{engine: matchingEngine, tracker: marketdata.NewTickerTracker()}
+	candles := &candleService{engine: matchingEngine, aggregator: marketdata.NewCandleAggregator(marketdata.NewHub())}
+	cancels := &cancelOrderService{engine: matchingEngine, switches: make(map[int64]*time.Timer)}
+
+	checks := []struct {
+		name string
+		call func() error
+	}{
+		{"order book", func() error { _, err := orderBooks.GetOrderBook(ctx, pair, 10); return err }},
+		{"pair status", func() error { _, err := pairStatus.GetPairStatus(ctx, pair); return err }},
+		{"risk", func() error { _, err := risk.GetPairRisk(ctx, pair); return err }},
+		{"set risk", func() error { _, err := risk.SetPairRisk(ctx, pair, engine.RiskConfig{}, 0); return err }},
+		{"auction state", func() error { _, err := auctions.GetAuctionState(ctx, pair); return err }},
+		{"ticker", func() error { _, err := tickers.GetTicker(ctx, pair); return err }},
+		{"candles", func() error { _, err := candles.GetCandles(ctx, pair, "1m", time.Time{}, time.Time{}); return err }},
+		{"order query", func() error {
+			page, err := orderBooks.QueryOrders(ctx, 1, OrderHistoryQuery{Pair: pair})
+			if err == nil && len(page.Orders) != 1 {
+				t.Errorf("order query by %q found %d orders, want 1", pair, len(page.Orders))
+			}
+			return err
+		}},
+		{"set pair status", func() error { _, err := pairStatus.SetPairStatus(ctx, pair, models.PairStatusHalted); return err }},
+		{"mass cancel", func() error {
+			cancelled, err := cancels.CancelAllOrders(ctx, 1, pair, "")
+			if err == nil && (len(cancelled) != 1 || cancelled[0].ID != order.ID) {
+				t.Errorf("mass cancel of %q cancelled %d orders, want order %d", pair, len(cancelled), order.ID)
+			}
+			return err
+		}},
+		{"start auction", func() error { _, err := auctions.StartAuction(ctx, pair, 0); return err }},
+	}
+	for _, check := range checks {
+		if err := check.call(); err != nil {
+			t.Errorf("%s of %q: %v", check.name, pair, err)
+		}
+	}
+}
+
+func TestAssetDecimalsAreLockedOnceTraded(t *testing.T) {
+	matchingEngine := engine.NewMatchingEngine()
+	registry := assets.NewRegistry(assets.Config{Assets: []models.Asset{
+		{Symbol: "BTC", Decimals: 8},
+		{Symbol: "USDT", Decimals: 6},
+		{Symbol: "DOGE", Decimals: 8},
+	}})
+	s := &assetService{registry: registry, engine: matchingEngine}
+	pairs := &pairService{engine: matchingEngine, registry: registry}
+	ctx := context.Background()
+
+	info, err := pairs.CreatePair(ctx, "BTC", "USDT", engine.MatchingConfig{})
+	if err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	if info.BaseDecimals != 8 || info.QuoteDecimals != 6 || info.QuantityDecimals != 8 || info.PriceDecimals != 6 {
+		t.Fatalf("pair info %+v, want 8 base and quantity decimals, 6 quote and price decimals", info)
+	}
+
+	if _, err := s.RegisterAsset(ctx, models.Asset{Symbol: "usdt", Decimals: 2}); err != apperrors.ErrAssetDecimalsLocked {
+		t.Fatalf("changing a quote asset's decimals: got %v, want %v", err, apperrors.ErrAssetDecimalsLocked)
+	}
+	if _, err := s.RegisterAsset(ctx, models.Asset{Symbol: "BTC", Decimals: 6}); err != apperrors.ErrAssetDecimalsLocked {
+		t.Fatalf("changing a base asset's decimals: got %v, want %v", err, apperrors.ErrAssetDecimalsLocked)
+	}
+	if _, err := s.RegisterAsset(ctx, models.Asset{Symbol: "BTC", Name: "Bitcoin", Decimals: 8, Status: models.AssetStatusDisabled}); err != nil {
+		t.Fatalf("updating a traded asset without changing its decimals: %v", err)
+	}
+	if _, err := s.RegisterAsset(ctx, models.Asset{Symbol: "DOGE", Decimals: 2}); err != nil {
+		t.Fatalf("changing an untraded asset's decimals: %v", err)
+	}
+
+	listed, _ := pairs.ListPairs(ctx)
+	if len(listed) != 1 || listed[0].QuoteDecimals != listed[0].PriceDecimals {
+		t.Fatalf("listed pairs %+v, want the quote decimals to match the price decimals", listed)
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+
+	"log"
+)
+
+// PairStatusResult describes a pair's status and any orders cancelled by the last transition
+type PairStatusResult struct {
+	Pair            string            `json:"pair"`
+	Status          models.PairStatus `json:"status"`
+	CancelledOrders []int64           `json:"cancelled_orders,omitempty"`
+}
+
+// PairStatusService defines the interface for managing the trading pair lifecycle
+type PairStatusService interface {
+	GetPairStatus(ctx context.Context, pair string) (*PairStatusResult, error)
+	SetPairStatus(ctx context.Context, pair string, status models.PairStatus) (*PairStatusResult, error)
+}
+
+var pairStatusSvcStruct PairStatusService
+var pairStatusServiceOnce sync.Once
+
+type pairStatusService struct {
+	engine *engine.MatchingEngine
+	config *util.RouterConfig
+}
+
+// InitPairStatusService initializes the pair status service
+func InitPairStatusService(matchingEngine *engine.MatchingEngine, config *util.RouterConfig) PairStatusService {
+	pairStatusServiceOnce.Do(func() {
+		pairStatusSvcStruct = &pairStatusService{engine: matchingEngine, config: config}
+	})
+	return pairStatusSvcStruct
+}
+
+// GetPairStatusService returns the singleton instance
+func GetPairStatusService() PairStatusService {
+	if pairStatusSvcStruct == nil {
+		panic("PairStatusService not initialized")
+	}
+	return pairStatusSvcStruct
+}
+
+// GetPairStatus returns a pair's current status
+func (s *pairStatusService) GetPairStatus(ctx context.Context, pair string) (*PairStatusResult, error) {
+	pair = assets.NormalizePair(pair)
+	status, exists := s.engine.GetPairStatus(pair)
+	if !exists {
+		log.Printf("Pair not found: %s", pair)
+		return nil, apperrors.ErrPairNotFound
+	}
+	return &PairStatusResult{Pair: pair, Status: status}, nil
+}
+
+// SetPairStatus transitions a pair, reporting the orders cancelled when it is delisted
+func (s *pairStatusService) SetPairStatus(ctx context.Context, pair string, status models.PairStatus) (*PairStatusResult, error) {
+	pair = assets.NormalizePair(pair)
+	cancelled, err := s.engine.SetPairStatus(pair, status)
+	if err != nil {
+		log.Printf("Failed to set %s status to %s: %v", pair, status, err)
+		return nil, err
+	}
+
+	result := &PairStatusResult{Pair: pair, Status: status}
+	for _, order := range cancelled {
+		result.CancelledOrders = append(result.CancelledOrders, order.ID)
+	}
+	if len(cancelled) > 0 {
+		log.Printf("Delisting %s cancelled %d resting orders", pair, len(cancelled))
+	}
+	return result, nil
+}
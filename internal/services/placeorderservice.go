@@ -3,17 +3,19 @@ package services
 import (
 	"context"
 	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
 	"mini-crypto-exchange/internal/engine"
 	"mini-crypto-exchange/internal/models"
 	"mini-crypto-exchange/internal/util"
 	"sync"
 	"log"
+	"math"
 )
 
 // PlaceOrderService defines the interface for placing orders
 type PlaceOrderService interface {
-	ValidateRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64) []*util.Error
-	ProcessRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64) (*models.Order, []*models.Trade, error)
+	ValidateRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) []*util.Error
+	ProcessRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) (*models.Order, []*models.Trade, error)
 }
 
 var placeOrderSvcStruct PlaceOrderService
@@ -40,41 +42,81 @@ func GetPlaceOrderService() PlaceOrderService {
 	return placeOrderSvcStruct
 }
 
-// ValidateRequest validates the order request
-func (s *placeOrderService) ValidateRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64) []*util.Error {
+// ValidateRequest validates the order request. Price and quantity may have no more decimal places
+// than the pair's precision, DefaultPrecision for unknown pairs.
+func (s *placeOrderService) ValidateRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) []*util.Error {
 	var validationErrors []*util.Error
+	precision, exists := s.engine.GetPairPrecision(assets.NormalizePair(pair))
+	if !exists {
+		precision = engine.DefaultPrecision
+	}
 
 	if userID <= 0 {
 		
 		log.Println("Invalid user ID")
-		validationErrors = append(validationErrors, util.ServerToError(apperrors.ErrInvalidUserID))
+		validationErrors = append(validationErrors, util.ServerToFieldError(apperrors.ErrInvalidUserID, "user_id"))
 	}
 
-	if price <= 0 {
+	if !validAmount(price) {
 		log.Println("Invalid price")
-		validationErrors = append(validationErrors, util.ServerToError(apperrors.ErrInvalidPrice))
+		validationErrors = append(validationErrors, util.ServerToFieldError(apperrors.ErrInvalidPrice, "price"))
+	} else if !engine.HasDecimals(price, precision.PriceDecimals) {
+		log.Println("Invalid price increment")
+		validationErrors = append(validationErrors, util.ServerToFieldError(apperrors.ErrInvalidPriceIncrement, "price"))
 	}
 
-	if quantity <= 0 {
+	if !validAmount(quantity) {
 		log.Println("Invalid quantity")
-		validationErrors = append(validationErrors, util.ServerToError(apperrors.ErrInvalidQuantity))
+		validationErrors = append(validationErrors, util.ServerToFieldError(apperrors.ErrInvalidQuantity, "quantity"))
+	} else if !engine.HasDecimals(quantity, precision.QuantityDecimals) {
+		log.Println("Invalid quantity increment")
+		validationErrors = append(validationErrors, util.ServerToFieldError(apperrors.ErrInvalidQuantityIncrement, "quantity"))
 	}
 
 	if side != "buy" && side != "sell" {
 		log.Println("Invalid side")
-		validationErrors = append(validationErrors, util.ServerToError(apperrors.ErrInvalidSide))
+		validationErrors = append(validationErrors, util.ServerToFieldError(apperrors.ErrInvalidSide, "side"))
+	}
+
+	if !validClientOrderID(clientOrderID) {
+		log.Println("Invalid client order ID")
+		validationErrors = append(validationErrors, util.ServerToFieldError(apperrors.ErrInvalidClientOrderID, "client_order_id"))
 	}
 
 	return validationErrors
 }
 
-// ProcessRequest processes the order placement
-func (s *placeOrderService) ProcessRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64) (*models.Order, []*models.Trade, error) {
+// ProcessRequest processes the order placement. The pair name is normalized, so "btc/usdt" trades
+// BTC/USDT. A duplicate client order ID returns the original order with ErrDuplicateClientOrderID.
+func (s *placeOrderService) ProcessRequest(ctx context.Context, userID int64, pair string, side string, price float64, quantity float64, clientOrderID string) (*models.Order, []*models.Trade, error) {
+	pair = assets.NormalizePair(pair)
 	ob := s.engine.GetOrderBook(pair)
 	if ob == nil {
 		return nil, nil, apperrors.ErrPairNotFound
 	}
 
-	order, trades, err := s.engine.PlaceOrder(userID, pair, side, price, quantity)
+	order, trades, err := s.engine.PlaceOrderWithClientID(userID, pair, side, price, quantity, clientOrderID)
 	return order, trades, err
 }
+
+// validAmount accepts finite prices and quantities above zero. The binary and FIX gateways can
+// decode NaN, which fails the comparison, and infinities, which do not.
+func validAmount(amount float64) bool {
+	return amount > 0 && !math.IsInf(amount, 0)
+}
+
+// validClientOrderID accepts empty IDs and up to 36 letters, digits, '-', '_', '.' or ':'
+func validClientOrderID(clientOrderID string) bool {
+	if len(clientOrderID) > 36 {
+		return false
+	}
+	for _, c := range clientOrderID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
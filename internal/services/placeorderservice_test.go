@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"math"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"testing"
+)
+
+func TestValidateRequestRejectsNonFiniteAmounts(t *testing.T) {
+	s := &placeOrderService{engine: engine.NewMatchingEngine()}
+
+	for _, amount := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 0, -1} {
+		errs := s.ValidateRequest(context.Background(), 1, "BTC/USDT", "buy", amount, amount, "")
+		fields := map[string]bool{}
+		for _, err := range errs {
+			fields[err.Field] = true
+		}
+		if !fields["price"] || !fields["quantity"] {
+			t.Errorf("amount %v: got errors %v, want price and quantity rejected", amount, errs)
+		}
+	}
+
+	if errs := s.ValidateRequest(context.Background(), 1, "BTC/USDT", "buy", 100.5, 0.25, ""); len(errs) != 0 {
+		t.Errorf("finite amounts: got errors %v", errs)
+	}
+}
+
+func TestValidateRequestRejectsPartialLots(t *testing.T) {
+	s := &placeOrderService{engine: engine.NewMatchingEngine()}
+
+	errs := s.ValidateRequest(context.Background(), 1, "BTC/USDT", "buy", 100.000000001, 0.123456789, "")
+	if len(errs) != 2 || errs[0].Code != apperrors.ErrInvalidPriceIncrement.Code || errs[1].Code != apperrors.ErrInvalidQuantityIncrement.Code {
+		t.Fatalf("got errors %v, want price and quantity increments rejected", errs)
+	}
+}
+
+func TestValidateRequestUsesPairPrecision(t *testing.T) {
+	matchingEngine := engine.NewMatchingEngine()
+	if _, err := matchingEngine.CreatePairWithMatching(models.TradingPair{Base: "ETH", Quote: "USD"}, engine.MatchingConfig{}, engine.NewPrecision(2, 18)); err != nil {
+		t.Fatalf("CreatePairWithMatching: %v", err)
+	}
+	s := &placeOrderService{engine: matchingEngine}
+
+	errs := s.ValidateRequest(context.Background(), 1, "eth/usd", "buy", 100.001, 0.123456789, "")
+	if len(errs) != 2 || errs[0].Code != apperrors.ErrInvalidPriceIncrement.Code || errs[1].Code != apperrors.ErrInvalidQuantityIncrement.Code {
+		t.Fatalf("got errors %v, want price and quantity increments rejected", errs)
+	}
+	if errs := s.ValidateRequest(context.Background(), 1, "eth/usd", "buy", 100.01, 0.12345678, ""); len(errs) != 0 {
+		t.Fatalf("amounts within precision: got errors %v", errs)
+	}
+}
+
+func TestProcessRequestNormalizesPair(t *testing.T) {
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		t.Fatalf("CreatePair: %v", err)
+	}
+	s := &placeOrderService{engine: matchingEngine}
+
+	order, _, err := s.ProcessRequest(context.Background(), 1, " btc/usdt", "buy", 100, 1, "")
+	if err != nil {
+		t.Fatalf("ProcessRequest: %v", err)
+	}
+	if order.Pair != "BTC/USDT" {
+		t.Fatalf("got pair %q, want BTC/USDT", order.Pair)
+	}
+}
+
+func BenchmarkPlaceOrder(b *testing.B) {
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		b.Fatalf("CreatePair: %v", err)
+	}
+	s := &placeOrderService{engine: matchingEngine}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Alternate sides at non-crossing prices so every order rests
+		side, price := "buy", float64(100+i%50)
+		if i%2 == 1 {
+			side, price = "sell", float64(1000+i%50)
+		}
+		if errs := s.ValidateRequest(ctx, 1, "BTC/USDT", side, price, 1, ""); len(errs) != 0 {
+			b.Fatalf("ValidateRequest: %v", errs)
+		}
+		if _, _, err := s.ProcessRequest(ctx, 1, "BTC/USDT", side, price, 1, ""); err != nil {
+			b.Fatalf("ProcessRequest: %v", err)
+		}
+	}
+}
+
+func BenchmarkPlaceCrossingOrder(b *testing.B) {
+	matchingEngine := engine.NewMatchingEngine()
+	if err := matchingEngine.CreatePair(models.TradingPair{Base: "BTC", Quote: "USDT"}); err != nil {
+		b.Fatalf("CreatePair: %v", err)
+	}
+	s := &placeOrderService{engine: matchingEngine}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// A resting sell from one user is taken by a buy from another, so every pair of orders trades
+		if _, _, err := s.ProcessRequest(ctx, 1, "BTC/USDT", "sell", 100, 1, ""); err != nil {
+			b.Fatalf("ProcessRequest: %v", err)
+		}
+		if _, _, err := s.ProcessRequest(ctx, 2, "BTC/USDT", "buy", 100, 1, ""); err != nil {
+			b.Fatalf("ProcessRequest: %v", err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+
+	"log"
+)
+
+// PairRisk is a pair's risk settings with the reference price its bands are computed from
+type PairRisk struct {
+	Pair           string            `json:"pair"`
+	Config         engine.RiskConfig `json:"config"`
+	ReferencePrice float64           `json:"reference_price"` // 0 until the first trade unless an index price is set
+}
+
+// RiskService defines the interface for price bands and circuit breakers
+type RiskService interface {
+	GetPairRisk(ctx context.Context, pair string) (*PairRisk, error)
+	SetPairRisk(ctx context.Context, pair string, config engine.RiskConfig, indexPrice float64) (*PairRisk, error)
+	GetCircuitBreakerEvents(ctx context.Context, pair string) ([]models.CircuitBreakerEvent, error)
+}
+
+var riskSvcStruct RiskService
+var riskServiceOnce sync.Once
+
+type riskService struct {
+	engine *engine.MatchingEngine
+	config *util.RouterConfig
+}
+
+// InitRiskService initializes the risk service
+func InitRiskService(matchingEngine *engine.MatchingEngine, config *util.RouterConfig) RiskService {
+	riskServiceOnce.Do(func() {
+		riskSvcStruct = &riskService{engine: matchingEngine, config: config}
+	})
+	return riskSvcStruct
+}
+
+// GetRiskService returns the singleton instance
+func GetRiskService() RiskService {
+	if riskSvcStruct == nil {
+		panic("RiskService not initialized")
+	}
+	return riskSvcStruct
+}
+
+// GetPairRisk returns a pair's risk settings
+func (s *riskService) GetPairRisk(ctx context.Context, pair string) (*PairRisk, error) {
+	pair = assets.NormalizePair(pair)
+	config, reference, err := s.engine.GetRiskConfig(pair)
+	if err != nil {
+		log.Printf("Failed to get risk settings for %s: %v", pair, err)
+		return nil, err
+	}
+	return &PairRisk{Pair: pair, Config: config, ReferencePrice: reference}, nil
+}
+
+// SetPairRisk replaces a pair's risk settings
+func (s *riskService) SetPairRisk(ctx context.Context, pair string, config engine.RiskConfig, indexPrice float64) (*PairRisk, error) {
+	pair = assets.NormalizePair(pair)
+	if err := s.engine.SetRiskConfig(pair, config, indexPrice); err != nil {
+		log.Printf("Failed to set risk settings for %s: %v", pair, err)
+		return nil, err
+	}
+	return s.GetPairRisk(ctx, pair)
+}
+
+// GetCircuitBreakerEvents returns the breaker events for a pair, or for all pairs when pair is empty
+func (s *riskService) GetCircuitBreakerEvents(ctx context.Context, pair string) ([]models.CircuitBreakerEvent, error) {
+	pair = assets.NormalizePair(pair)
+	return s.engine.GetCircuitBreakerEvents(pair), nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"mini-crypto-exchange/internal/apperrors"
+	"mini-crypto-exchange/internal/assets"
+	"mini-crypto-exchange/internal/engine"
+	"mini-crypto-exchange/internal/marketdata"
+	"mini-crypto-exchange/internal/models"
+	"mini-crypto-exchange/internal/util"
+	"sync"
+
+	"log"
+)
+
+// TickerService defines the interface for querying 24 hour ticker statistics
+type TickerService interface {
+	GetTicker(ctx context.Context, pair string) (*models.Ticker, error)
+	GetTickers(ctx context.Context) ([]*models.Ticker, error)
+}
+
+var tickerSvcStruct TickerService
+var tickerServiceOnce sync.Once
+
+type tickerService struct {
+	engine  *engine.MatchingEngine
+	tracker *marketdata.TickerTracker
+	config  *util.RouterConfig
+}
+
+// InitTickerService initializes the ticker service
+func InitTickerService(matchingEngine *engine.MatchingEngine, tracker *marketdata.TickerTracker, config *util.RouterConfig) TickerService {
+	tickerServiceOnce.Do(func() {
+		tickerSvcStruct = &tickerService{engine: matchingEngine, tracker: tracker, config: config}
+	})
+	return tickerSvcStruct
+}
+
+// GetTickerService returns the singleton instance
+func GetTickerService() TickerService {
+	if tickerSvcStruct == nil {
+		panic("TickerService not initialized")
+	}
+	return tickerSvcStruct
+}
+
+// GetTicker returns the ticker for a single pair
+func (s *tickerService) GetTicker(ctx context.Context, pair string) (*models.Ticker, error) {
+	pair = assets.NormalizePair(pair)
+	ob := s.engine.GetOrderBook(pair)
+	if ob == nil {
+		log.Printf("Order book not found for pair: %s", pair)
+		return nil, apperrors.ErrPairNotFound
+	}
+
+	return s.buildTicker(ob), nil
+}
+
+// GetTickers returns the tickers for every pair
+func (s *tickerService) GetTickers(ctx context.Context) ([]*models.Ticker, error) {
+	tickers := make([]*models.Ticker, 0)
+
+	for _, pair := range s.engine.GetPairs() {
+		ob := s.engine.GetOrderBook(pair)
+		if ob == nil {
+			continue
+		}
+		tickers = append(tickers, s.buildTicker(ob))
+	}
+
+	return tickers, nil
+}
+
+// buildTicker combines rolling trade statistics with the current top of book
+func (s *tickerService) buildTicker(ob *engine.OrderBook) *models.Ticker {
+	ticker := s.tracker.GetTicker(ob.Pair)
+
+	if bestBid := ob.GetBestBid(); bestBid != nil {
+		ticker.BestBid = bestBid.Price
+	}
+	if bestAsk := ob.GetBestAsk(); bestAsk != nil {
+		ticker.BestAsk = bestAsk.Price
+	}
+
+	return &ticker
+}
@@ -1,20 +1,43 @@
 package util
 
-import "mini-crypto-exchange/internal/apperrors"
+import (
+	"errors"
+	"mini-crypto-exchange/internal/apperrors"
+)
 
 type Error struct {
 	Message string `json:"message,omitempty"`
 	Code    string `json:"code,omitempty"`
+	Field   string `json:"field,omitempty"`
 }
 
 type RouterConfig struct {
-	MatchingEngine interface{}
+	MatchingEngine   interface{}
+	MarketDataHub    interface{}
+	Authenticator    interface{}
+	AuditLog         interface{}
+	RateLimiters     interface{}
+	IdempotencyStore interface{}
+	StreamSessions   interface{}
 }
 
+// ServerToError converts err to an Error. Errors that are not ServerErrors become internal errors
+// so their text is not exposed to clients.
 func ServerToError(err error) *Error {
+	var serverErr *apperrors.ServerError
+	if !errors.As(err, &serverErr) {
+		serverErr = apperrors.ErrInternal
+	}
 	errorProto := &Error{
-		Code:    err.(*apperrors.ServerError).Code,
-		Message: err.Error(),
+		Code:    serverErr.Code,
+		Message: serverErr.Message,
 	}
 	return errorProto
 }
+
+// ServerToFieldError converts err to an Error about the given request field
+func ServerToFieldError(err error, field string) *Error {
+	errorProto := ServerToError(err)
+	errorProto.Field = field
+	return errorProto
+}
@@ -0,0 +1,257 @@
+package binproto
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	executionBuffer = 1024
+	loginTimeout    = 5 * time.Second
+)
+
+// ErrClosed is returned for requests on a closed client
+var ErrClosed = errors.New("binproto: client closed")
+
+// Client is a binary order entry client. Requests are synchronous and safe for
+// concurrent use; fills arrive on Executions, which must be drained by the caller.
+type Client struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	nextID  uint64
+
+	mu      sync.Mutex
+	pending map[uint64]chan interface{}
+	err     error
+
+	executions chan *Execution
+	closed     chan struct{}
+
+	heartbeatOnce sync.Once
+}
+
+// Dial connects to the exchange and logs in with an API key and its secret
+func Dial(addr string, apiKey string, secret string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetNoDelay(true)
+	}
+
+	// Client order IDs become the orders' client order IDs on the exchange, which must not repeat
+	// within a day, so a new client continues from the clock rather than from 1
+	c := &Client{
+		conn:       conn,
+		nextID:     uint64(time.Now().UnixNano()),
+		pending:    make(map[uint64]chan interface{}),
+		executions: make(chan *Execution, executionBuffer),
+		closed:     make(chan struct{}),
+	}
+	go c.readLoop()
+
+	// Login acks carry client order ID 0
+	login := &Login{APIKey: apiKey, Timestamp: time.Now().UnixMilli()}
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	login.Nonce = binary.BigEndian.Uint64(nonce[:])
+	login.Signature = SignLogin(secret, login.Timestamp, login.Nonce)
+	resp, err := c.roundTrip(0, login, loginTimeout)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if reject, ok := resp.(*Reject); ok {
+		conn.Close()
+		return nil, &RejectError{Code: reject.Code}
+	}
+
+	return c, nil
+}
+
+// PlaceOrder places a limit order and waits for its acknowledgement
+func (c *Client) PlaceOrder(pair string, side byte, price float64, quantity float64) (*Ack, error) {
+	id := atomic.AddUint64(&c.nextID, 1)
+	return c.request(id, &NewOrder{ClientOrderID: id, Pair: pair, Side: side, Price: price, Quantity: quantity})
+}
+
+// CancelOrder cancels an open order
+func (c *Client) CancelOrder(orderID int64) (*Ack, error) {
+	id := atomic.AddUint64(&c.nextID, 1)
+	return c.request(id, &CancelOrder{ClientOrderID: id, OrderID: orderID})
+}
+
+// AmendOrder replaces an open order's price and total quantity
+func (c *Client) AmendOrder(orderID int64, price float64, quantity float64) (*Ack, error) {
+	id := atomic.AddUint64(&c.nextID, 1)
+	return c.request(id, &AmendOrder{ClientOrderID: id, OrderID: orderID, Price: price, Quantity: quantity})
+}
+
+// SetSessionOptions configures the session. When a heartbeat interval is set the client sends
+// heartbeats at half that interval until it is closed.
+func (c *Client) SetSessionOptions(options SessionOptions) error {
+	resp, err := c.roundTrip(0, &options, loginTimeout)
+	if err != nil {
+		return err
+	}
+	if reject, ok := resp.(*Reject); ok {
+		return &RejectError{Code: reject.Code}
+	}
+
+	if options.HeartbeatIntervalMs > 0 {
+		c.heartbeatOnce.Do(func() {
+			go c.heartbeatLoop(time.Duration(options.HeartbeatIntervalMs) * time.Millisecond / 2)
+		})
+	}
+	return nil
+}
+
+// Executions delivers fills on this connection's orders
+func (c *Client) Executions() <-chan *Execution {
+	return c.executions
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) heartbeatLoop(interval time.Duration) {
+	frame, _ := Encode(&Heartbeat{})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			_, err := c.conn.Write(frame)
+			c.writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) request(id uint64, msg interface{}) (*Ack, error) {
+	resp, err := c.roundTrip(id, msg, 0)
+	if err != nil {
+		return nil, err
+	}
+	if reject, ok := resp.(*Reject); ok {
+		return nil, &RejectError{Code: reject.Code}
+	}
+	return resp.(*Ack), nil
+}
+
+func (c *Client) roundTrip(id uint64, msg interface{}, timeout time.Duration) (interface{}, error) {
+	frame, err := Encode(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan interface{}, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	c.writeMu.Lock()
+	_, err = c.conn.Write(frame)
+	c.writeMu.Unlock()
+	if err != nil {
+		c.forget(id)
+		return nil, err
+	}
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-c.closed:
+		return nil, c.closeErr()
+	case <-deadline:
+		c.forget(id)
+		return nil, errors.New("binproto: request timed out")
+	}
+}
+
+func (c *Client) forget(id uint64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *Client) closeErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Client) readLoop() {
+	reader := bufio.NewReader(c.conn)
+	buf := make([]byte, 0, 128)
+
+	var err error
+	for {
+		var payload []byte
+		if payload, err = ReadFrame(reader, buf); err != nil {
+			break
+		}
+		var msg interface{}
+		if msg, err = Decode(payload); err != nil {
+			break
+		}
+
+		switch m := msg.(type) {
+		case *Ack:
+			c.deliver(m.ClientOrderID, m)
+		case *Reject:
+			c.deliver(m.ClientOrderID, m)
+		case *Execution:
+			c.executions <- m
+		}
+	}
+
+	c.mu.Lock()
+	c.err = ErrClosed
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		c.err = err
+	}
+	c.mu.Unlock()
+	close(c.closed)
+	close(c.executions)
+}
+
+func (c *Client) deliver(id uint64, msg interface{}) {
+	c.mu.Lock()
+	ch, exists := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+
+	if exists {
+		ch <- msg
+	}
+}
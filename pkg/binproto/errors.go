@@ -0,0 +1,100 @@
+package binproto
+
+// Reject codes. They mirror the exchange's error codes so both APIs report failures the same way.
+const (
+	CodeUnknown                uint16 = 0xFFFF
+	CodeInvalidUserID          uint16 = 1
+	CodeInvalidPrice           uint16 = 2
+	CodeInvalidQuantity        uint16 = 3
+	CodeInvalidSide            uint16 = 4
+	CodePairNotFound           uint16 = 5
+	CodeOrderNotFound          uint16 = 6
+	CodeOrderNotOpen           uint16 = 7
+	CodeInvalidReplaceQuantity uint16 = 8
+	CodeNotLoggedIn            uint16 = 9
+	CodeAlreadyLoggedIn        uint16 = 10
+	CodeTooManyOpenOrders      uint16 = 11
+	CodeOrderToTradeRatio      uint16 = 12
+	CodePairHalted             uint16 = 13
+	CodePairCancelOnly         uint16 = 14
+	CodePairDelisted           uint16 = 15
+	CodePostOnlyWouldCross     uint16 = 16
+	CodePriceOutsideBand       uint16 = 17
+	CodeMissingCredentials     uint16 = 18
+	CodeInvalidAPIKey          uint16 = 19
+	CodeInvalidSignature       uint16 = 20
+	CodeRequestExpired         uint16 = 21
+	CodeNonceReused            uint16 = 22
+	CodePermissionDenied       uint16 = 23
+	CodeIPNotAllowed           uint16 = 24
+	CodeRateLimited            uint16 = 25
+	CodeDuplicateClientOrderID uint16 = 26
+	CodeInvalidClientOrderID   uint16 = 27
+	CodeInvalidPriceIncrement  uint16 = 28
+	CodeInvalidQtyIncrement    uint16 = 29
+)
+
+var codeNames = map[uint16]string{
+	CodeInvalidUserID:          "INVALID_USER_ID",
+	CodeInvalidPrice:           "INVALID_PRICE",
+	CodeInvalidQuantity:        "INVALID_QUANTITY",
+	CodeInvalidSide:            "INVALID_SIDE",
+	CodePairNotFound:           "PAIR_NOT_FOUND",
+	CodeOrderNotFound:          "ORDER_NOT_FOUND",
+	CodeOrderNotOpen:           "ORDER_NOT_OPEN",
+	CodeInvalidReplaceQuantity: "INVALID_REPLACE_QUANTITY",
+	CodeNotLoggedIn:            "NOT_LOGGED_IN",
+	CodeAlreadyLoggedIn:        "ALREADY_LOGGED_IN",
+	CodeTooManyOpenOrders:      "TOO_MANY_OPEN_ORDERS",
+	CodeOrderToTradeRatio:      "ORDER_TO_TRADE_RATIO_EXCEEDED",
+	CodePairHalted:             "PAIR_HALTED",
+	CodePairCancelOnly:         "PAIR_CANCEL_ONLY",
+	CodePairDelisted:           "PAIR_DELISTED",
+	CodePostOnlyWouldCross:     "POST_ONLY_WOULD_CROSS",
+	CodePriceOutsideBand:       "PRICE_OUTSIDE_BAND",
+	CodeMissingCredentials:     "MISSING_CREDENTIALS",
+	CodeInvalidAPIKey:          "INVALID_API_KEY",
+	CodeInvalidSignature:       "INVALID_SIGNATURE",
+	CodeRequestExpired:         "REQUEST_EXPIRED",
+	CodeNonceReused:            "NONCE_REUSED",
+	CodePermissionDenied:       "PERMISSION_DENIED",
+	CodeIPNotAllowed:           "IP_NOT_ALLOWED",
+	CodeRateLimited:            "RATE_LIMITED",
+	CodeDuplicateClientOrderID: "DUPLICATE_CLIENT_ORDER_ID",
+	CodeInvalidClientOrderID:   "INVALID_CLIENT_ORDER_ID",
+	CodeInvalidPriceIncrement:  "INVALID_PRICE_INCREMENT",
+	CodeInvalidQtyIncrement:    "INVALID_QUANTITY_INCREMENT",
+}
+
+var nameCodes = func() map[string]uint16 {
+	codes := make(map[string]uint16, len(codeNames))
+	for code, name := range codeNames {
+		codes[name] = code
+	}
+	return codes
+}()
+
+// ErrorCode maps an exchange error code such as "PAIR_NOT_FOUND" to its reject code
+func ErrorCode(name string) uint16 {
+	if code, ok := nameCodes[name]; ok {
+		return code
+	}
+	return CodeUnknown
+}
+
+// CodeName maps a reject code back to the exchange error code
+func CodeName(code uint16) string {
+	if name, ok := codeNames[code]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// RejectError is returned by the client when the exchange rejects a request
+type RejectError struct {
+	Code uint16
+}
+
+func (err *RejectError) Error() string {
+	return "binproto: request rejected: " + CodeName(err.Code)
+}
@@ -0,0 +1,392 @@
+// Package binproto implements the exchange's compact binary order entry protocol.
+//
+// Every message is a frame of a 2 byte big endian length followed by a fixed size
+// payload whose first byte is the message type. All integers are big endian and
+// decimals are IEEE 754 float64.
+package binproto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Message types sent by clients
+const (
+	TypeLogin       byte = 'L'
+	TypeNewOrder    byte = 'N'
+	TypeCancelOrder byte = 'C'
+	TypeAmendOrder  byte = 'A'
+	TypeOptions     byte = 'O'
+	TypeHeartbeat   byte = 'H'
+)
+
+// Message types sent by the exchange
+const (
+	TypeAck       byte = 'K'
+	TypeReject    byte = 'R'
+	TypeExecution byte = 'E'
+)
+
+// Fixed payload sizes per message type
+const (
+	LoginSize       = 1 + APIKeySize + 8 + 8 + SignatureSize
+	NewOrderSize    = 1 + 8 + PairSize + 1 + 8 + 8
+	CancelOrderSize = 1 + 8 + 8
+	AmendOrderSize  = 1 + 8 + 8 + 8 + 8
+	OptionsSize     = 1 + 1 + 4 + 4
+	HeartbeatSize   = 1
+	AckSize         = 1 + 1 + 8 + 8 + 8 + 1 + 8 + 8 + 8
+	RejectSize      = 1 + 1 + 8 + 2
+	ExecutionSize   = 1 + 8 + 8 + 8 + 8 + 8 + 8 + 8 + 1
+
+	// PairSize is the width of the zero padded pair symbol, e.g. "BTC/USDT"
+	PairSize = 16
+	// APIKeySize is the width of the zero padded API key
+	APIKeySize = 32
+	// SignatureSize is the width of the hex encoded login signature
+	SignatureSize = 64
+)
+
+// Login signatures are computed like signed REST requests, over timestamp + nonce + LoginMethod +
+// LoginPath with an empty body
+const (
+	LoginMethod = "POST"
+	LoginPath   = "binproto.Login"
+)
+
+// Side values
+const (
+	SideBuy  byte = 1
+	SideSell byte = 2
+)
+
+// Order status values
+const (
+	StatusOpen      byte = 0
+	StatusPartial   byte = 1
+	StatusFilled    byte = 2
+	StatusCancelled byte = 3
+)
+
+var (
+	ErrUnknownType = errors.New("binproto: unknown message type")
+	ErrBadSize     = errors.New("binproto: unexpected message size")
+	ErrPairTooLong = errors.New("binproto: pair longer than 16 bytes")
+	ErrKeyTooLong  = errors.New("binproto: API key or signature too long")
+)
+
+// Login authenticates the connection with an API key holding the trade permission and must be the
+// first message. Timestamp is unix milliseconds and Signature is SignLogin's hex HMAC; a nonce may
+// only be used once per key.
+type Login struct {
+	APIKey    string
+	Timestamp int64
+	Nonce     uint64
+	Signature string
+}
+
+// SignLogin computes a Login signature with the API key's secret
+func SignLogin(secret string, timestamp int64, nonce uint64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte(strconv.FormatUint(nonce, 10)))
+	mac.Write([]byte(LoginMethod))
+	mac.Write([]byte(LoginPath))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewOrder places a limit order
+type NewOrder struct {
+	ClientOrderID uint64
+	Pair          string
+	Side          byte
+	Price         float64
+	Quantity      float64
+}
+
+// CancelOrder cancels an open order
+type CancelOrder struct {
+	ClientOrderID uint64
+	OrderID       int64
+}
+
+// AmendOrder replaces an open order's price and total quantity
+type AmendOrder struct {
+	ClientOrderID uint64
+	OrderID       int64
+	Price         float64
+	Quantity      float64
+}
+
+// SessionOptions configures the logged in session. With CancelOnDisconnect the session's open
+// orders are cancelled when the connection closes, unless the user logs in again within
+// GracePeriodMs. With a HeartbeatIntervalMs the exchange closes the connection once nothing,
+// heartbeats included, has arrived for two intervals. Acknowledged with client order ID 0.
+type SessionOptions struct {
+	CancelOnDisconnect  bool
+	GracePeriodMs       uint32
+	HeartbeatIntervalMs uint32
+}
+
+// Heartbeat keeps an idle session alive. It is not acknowledged.
+type Heartbeat struct{}
+
+// Ack confirms a request. For amends OrderID is the replacement and OrigOrderID the amended order.
+type Ack struct {
+	RequestType   byte
+	ClientOrderID uint64
+	OrderID       int64
+	OrigOrderID   int64
+	Status        byte
+	Price         float64
+	Quantity      float64
+	Filled        float64
+}
+
+// Reject refuses a request with a numeric error code, see ErrorCode
+type Reject struct {
+	RequestType   byte
+	ClientOrderID uint64
+	Code          uint16
+}
+
+// Execution reports a fill on an order. An order cancelled by the exchange rather than through
+// the connection, e.g. by a mass cancel over REST, is reported with StatusCancelled and TradeID 0.
+type Execution struct {
+	ClientOrderID uint64
+	OrderID       int64
+	TradeID       int64
+	LastPrice     float64
+	LastQuantity  float64
+	Filled        float64
+	Remaining     float64
+	Status        byte
+}
+
+// Encode serializes a message into a length prefixed frame
+func Encode(msg interface{}) ([]byte, error) {
+	var payload []byte
+
+	switch m := msg.(type) {
+	case *Login:
+		if len(m.APIKey) > APIKeySize || len(m.Signature) > SignatureSize {
+			return nil, ErrKeyTooLong
+		}
+		payload = make([]byte, LoginSize)
+		payload[0] = TypeLogin
+		copy(payload[1:1+APIKeySize], m.APIKey)
+		putInt64(payload[1+APIKeySize:], m.Timestamp)
+		binary.BigEndian.PutUint64(payload[9+APIKeySize:], m.Nonce)
+		copy(payload[17+APIKeySize:], m.Signature)
+	case *NewOrder:
+		if len(m.Pair) > PairSize {
+			return nil, ErrPairTooLong
+		}
+		payload = make([]byte, NewOrderSize)
+		payload[0] = TypeNewOrder
+		binary.BigEndian.PutUint64(payload[1:], m.ClientOrderID)
+		copy(payload[9:9+PairSize], m.Pair)
+		payload[9+PairSize] = m.Side
+		putFloat64(payload[10+PairSize:], m.Price)
+		putFloat64(payload[18+PairSize:], m.Quantity)
+	case *CancelOrder:
+		payload = make([]byte, CancelOrderSize)
+		payload[0] = TypeCancelOrder
+		binary.BigEndian.PutUint64(payload[1:], m.ClientOrderID)
+		putInt64(payload[9:], m.OrderID)
+	case *AmendOrder:
+		payload = make([]byte, AmendOrderSize)
+		payload[0] = TypeAmendOrder
+		binary.BigEndian.PutUint64(payload[1:], m.ClientOrderID)
+		putInt64(payload[9:], m.OrderID)
+		putFloat64(payload[17:], m.Price)
+		putFloat64(payload[25:], m.Quantity)
+	case *SessionOptions:
+		payload = make([]byte, OptionsSize)
+		payload[0] = TypeOptions
+		if m.CancelOnDisconnect {
+			payload[1] = 1
+		}
+		binary.BigEndian.PutUint32(payload[2:], m.GracePeriodMs)
+		binary.BigEndian.PutUint32(payload[6:], m.HeartbeatIntervalMs)
+	case *Heartbeat:
+		payload = []byte{TypeHeartbeat}
+	case *Ack:
+		payload = make([]byte, AckSize)
+		payload[0] = TypeAck
+		payload[1] = m.RequestType
+		binary.BigEndian.PutUint64(payload[2:], m.ClientOrderID)
+		putInt64(payload[10:], m.OrderID)
+		putInt64(payload[18:], m.OrigOrderID)
+		payload[26] = m.Status
+		putFloat64(payload[27:], m.Price)
+		putFloat64(payload[35:], m.Quantity)
+		putFloat64(payload[43:], m.Filled)
+	case *Reject:
+		payload = make([]byte, RejectSize)
+		payload[0] = TypeReject
+		payload[1] = m.RequestType
+		binary.BigEndian.PutUint64(payload[2:], m.ClientOrderID)
+		binary.BigEndian.PutUint16(payload[10:], m.Code)
+	case *Execution:
+		payload = make([]byte, ExecutionSize)
+		payload[0] = TypeExecution
+		binary.BigEndian.PutUint64(payload[1:], m.ClientOrderID)
+		putInt64(payload[9:], m.OrderID)
+		putInt64(payload[17:], m.TradeID)
+		putFloat64(payload[25:], m.LastPrice)
+		putFloat64(payload[33:], m.LastQuantity)
+		putFloat64(payload[41:], m.Filled)
+		putFloat64(payload[49:], m.Remaining)
+		payload[57] = m.Status
+	default:
+		return nil, ErrUnknownType
+	}
+
+	frame := make([]byte, 2+len(payload))
+	binary.BigEndian.PutUint16(frame, uint16(len(payload)))
+	copy(frame[2:], payload)
+	return frame, nil
+}
+
+// Decode parses a frame payload into its message struct
+func Decode(payload []byte) (interface{}, error) {
+	if len(payload) == 0 {
+		return nil, ErrBadSize
+	}
+
+	switch payload[0] {
+	case TypeLogin:
+		if len(payload) != LoginSize {
+			return nil, ErrBadSize
+		}
+		return &Login{
+			APIKey:    strings.TrimRight(string(payload[1:1+APIKeySize]), "\x00"),
+			Timestamp: getInt64(payload[1+APIKeySize:]),
+			Nonce:     binary.BigEndian.Uint64(payload[9+APIKeySize:]),
+			Signature: strings.TrimRight(string(payload[17+APIKeySize:]), "\x00"),
+		}, nil
+	case TypeNewOrder:
+		if len(payload) != NewOrderSize {
+			return nil, ErrBadSize
+		}
+		return &NewOrder{
+			ClientOrderID: binary.BigEndian.Uint64(payload[1:]),
+			Pair:          strings.TrimRight(string(payload[9:9+PairSize]), "\x00"),
+			Side:          payload[9+PairSize],
+			Price:         getFloat64(payload[10+PairSize:]),
+			Quantity:      getFloat64(payload[18+PairSize:]),
+		}, nil
+	case TypeCancelOrder:
+		if len(payload) != CancelOrderSize {
+			return nil, ErrBadSize
+		}
+		return &CancelOrder{
+			ClientOrderID: binary.BigEndian.Uint64(payload[1:]),
+			OrderID:       getInt64(payload[9:]),
+		}, nil
+	case TypeAmendOrder:
+		if len(payload) != AmendOrderSize {
+			return nil, ErrBadSize
+		}
+		return &AmendOrder{
+			ClientOrderID: binary.BigEndian.Uint64(payload[1:]),
+			OrderID:       getInt64(payload[9:]),
+			Price:         getFloat64(payload[17:]),
+			Quantity:      getFloat64(payload[25:]),
+		}, nil
+	case TypeOptions:
+		if len(payload) != OptionsSize {
+			return nil, ErrBadSize
+		}
+		return &SessionOptions{
+			CancelOnDisconnect:  payload[1] != 0,
+			GracePeriodMs:       binary.BigEndian.Uint32(payload[2:]),
+			HeartbeatIntervalMs: binary.BigEndian.Uint32(payload[6:]),
+		}, nil
+	case TypeHeartbeat:
+		if len(payload) != HeartbeatSize {
+			return nil, ErrBadSize
+		}
+		return &Heartbeat{}, nil
+	case TypeAck:
+		if len(payload) != AckSize {
+			return nil, ErrBadSize
+		}
+		return &Ack{
+			RequestType:   payload[1],
+			ClientOrderID: binary.BigEndian.Uint64(payload[2:]),
+			OrderID:       getInt64(payload[10:]),
+			OrigOrderID:   getInt64(payload[18:]),
+			Status:        payload[26],
+			Price:         getFloat64(payload[27:]),
+			Quantity:      getFloat64(payload[35:]),
+			Filled:        getFloat64(payload[43:]),
+		}, nil
+	case TypeReject:
+		if len(payload) != RejectSize {
+			return nil, ErrBadSize
+		}
+		return &Reject{
+			RequestType:   payload[1],
+			ClientOrderID: binary.BigEndian.Uint64(payload[2:]),
+			Code:          binary.BigEndian.Uint16(payload[10:]),
+		}, nil
+	case TypeExecution:
+		if len(payload) != ExecutionSize {
+			return nil, ErrBadSize
+		}
+		return &Execution{
+			ClientOrderID: binary.BigEndian.Uint64(payload[1:]),
+			OrderID:       getInt64(payload[9:]),
+			TradeID:       getInt64(payload[17:]),
+			LastPrice:     getFloat64(payload[25:]),
+			LastQuantity:  getFloat64(payload[33:]),
+			Filled:        getFloat64(payload[41:]),
+			Remaining:     getFloat64(payload[49:]),
+			Status:        payload[57],
+		}, nil
+	}
+
+	return nil, ErrUnknownType
+}
+
+// ReadFrame reads one length prefixed frame and returns its payload
+func ReadFrame(r io.Reader, buf []byte) ([]byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := int(binary.BigEndian.Uint16(header[:]))
+	if cap(buf) < size {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func putInt64(b []byte, v int64) {
+	binary.BigEndian.PutUint64(b, uint64(v))
+}
+
+func getInt64(b []byte) int64 {
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func putFloat64(b []byte, v float64) {
+	binary.BigEndian.PutUint64(b, math.Float64bits(v))
+}
+
+func getFloat64(b []byte) float64 {
+	return math.Float64frombits(binary.BigEndian.Uint64(b))
+}
@@ -0,0 +1,99 @@
+package binproto
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	messages := []interface{}{
+		&Login{APIKey: "0123456789abcdef0123456789abcdef", Timestamp: 1700000000000, Nonce: 42, Signature: SignLogin("secret", 1700000000000, 42)},
+		&NewOrder{ClientOrderID: 1, Pair: "BTC/USDT", Side: SideBuy, Price: 13000.5, Quantity: 0.25},
+		&CancelOrder{ClientOrderID: 2, OrderID: 7},
+		&AmendOrder{ClientOrderID: 3, OrderID: 7, Price: 12999, Quantity: 2},
+		&SessionOptions{CancelOnDisconnect: true, GracePeriodMs: 2000, HeartbeatIntervalMs: 1000},
+		&Heartbeat{},
+		&Ack{RequestType: TypeAmendOrder, ClientOrderID: 3, OrderID: 8, OrigOrderID: 7, Status: StatusPartial, Price: 12999, Quantity: 2, Filled: 1},
+		&Reject{RequestType: TypeNewOrder, ClientOrderID: 1, Code: CodePairNotFound},
+		&Execution{ClientOrderID: 1, OrderID: 7, TradeID: 3, LastPrice: 13000, LastQuantity: 0.25, Filled: 0.25, Remaining: 0, Status: StatusFilled},
+	}
+
+	for _, msg := range messages {
+		frame, err := Encode(msg)
+		if err != nil {
+			t.Fatalf("Encode(%T): %v", msg, err)
+		}
+		decoded, err := Decode(frame[2:])
+		if err != nil {
+			t.Fatalf("Decode(%T): %v", msg, err)
+		}
+		if !reflect.DeepEqual(decoded, msg) {
+			t.Fatalf("round trip of %T = %+v, want %+v", msg, decoded, msg)
+		}
+	}
+}
+
+func TestDecodeKeepsNonFiniteFloats(t *testing.T) {
+	// Validation happens in the exchange, the codec passes the raw IEEE 754 value through
+	frame, _ := Encode(&NewOrder{ClientOrderID: 1, Pair: "BTC/USDT", Side: SideBuy, Price: math.Inf(1), Quantity: math.NaN()})
+	decoded, err := Decode(frame[2:])
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	order := decoded.(*NewOrder)
+	if !math.IsInf(order.Price, 1) || !math.IsNaN(order.Quantity) {
+		t.Fatalf("decoded price %v and quantity %v", order.Price, order.Quantity)
+	}
+}
+
+func TestEncodeRejectsLongFields(t *testing.T) {
+	if _, err := Encode(&NewOrder{Pair: "A_VERY_LONG_PAIR/NAME"}); err != ErrPairTooLong {
+		t.Fatalf("long pair: got %v, want %v", err, ErrPairTooLong)
+	}
+	if _, err := Encode(&Login{APIKey: "0123456789abcdef0123456789abcdef0"}); err != ErrKeyTooLong {
+		t.Fatalf("long API key: got %v, want %v", err, ErrKeyTooLong)
+	}
+}
+
+func BenchmarkEncodeNewOrder(b *testing.B) {
+	msg := &NewOrder{ClientOrderID: 1, Pair: "BTC/USDT", Side: SideBuy, Price: 13000.5, Quantity: 0.25}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Encode(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeNewOrder(b *testing.B) {
+	frame, _ := Encode(&NewOrder{ClientOrderID: 1, Pair: "BTC/USDT", Side: SideBuy, Price: 13000.5, Quantity: 0.25})
+	payload := frame[2:]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeExecution(b *testing.B) {
+	msg := &Execution{ClientOrderID: 1, OrderID: 7, TradeID: 3, LastPrice: 13000, LastQuantity: 0.25, Filled: 0.25, Status: StatusFilled}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Encode(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeExecution(b *testing.B) {
+	frame, _ := Encode(&Execution{ClientOrderID: 1, OrderID: 7, TradeID: 3, LastPrice: 13000, LastQuantity: 0.25, Filled: 0.25, Status: StatusFilled})
+	payload := frame[2:]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}